@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/conneroisu/steroscopic-hardware/pkg/despair"
+)
+
+// Batch runs the batch subcommand, which computes the disparity maps of the
+// stereo pairs of a directory into an output directory with a manifest of
+// the parameters, timings and statistics. An interrupted batch resumes
+// where it stopped when run again with the same output directory.
+func Batch(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("batch", flag.ContinueOnError)
+	dir := flags.String("dir", "./testdata", "directory of the stereo pairs")
+	leftPattern := flags.String("left-pattern", despair.DefaultLeftPattern, "file names of the left images relative to -dir, * standing for the name of the pair")
+	rightPattern := flags.String("right-pattern", despair.DefaultRightPattern, "file names of the right images relative to -dir, * standing for the name of the pair")
+	out := flags.String("out", "", "directory to write the disparity maps and manifest to (required)")
+	workers := flags.Int("workers", despair.DefaultBatchWorkers, "number of pairs processed at once")
+	calibration := flags.String("calibration", "", "calibration file to rectify the pairs with")
+	params := addParamFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *out == "" {
+		return errors.New("batch needs an -out directory")
+	}
+	if *workers < 1 {
+		return errors.New("batch needs at least one worker")
+	}
+
+	opts := despair.BatchOptions{Out: *out, Workers: *workers}
+	var err error
+	opts.Params, err = params.parameters()
+	if err != nil {
+		return err
+	}
+	if *calibration != "" {
+		cal, err := despair.LoadCalibration(*calibration)
+		if err != nil {
+			return err
+		}
+		opts.Rectifier, err = despair.NewRectifier(cal)
+		if err != nil {
+			return fmt.Errorf("invalid calibration: %w", err)
+		}
+	}
+	pairs, err := despair.FindStereoPairs(*dir, *leftPattern, *rightPattern)
+	if err != nil {
+		return err
+	}
+	if len(pairs) == 0 {
+		return fmt.Errorf("no stereo pairs found in %s", *dir)
+	}
+
+	opts.Progress = func(p despair.BatchProgress) {
+		switch e := p.Last; {
+		case e == nil:
+			fmt.Printf("%d stereo pairs, %d already processed\n", p.Total, p.Skipped)
+		case e.Error != "":
+			fmt.Printf("[%d/%d] %s failed: %s\n", p.Done, p.Total, e.Name, e.Error)
+		default:
+			fmt.Printf("[%d/%d] %s in %s, %.1f%% valid, mean disparity %.2f px\n",
+				p.Done, p.Total, e.Name, e.Duration.Round(time.Millisecond),
+				100*e.Stats.ValidRatio, e.Stats.Mean)
+		}
+	}
+
+	ctx, cancel := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	start := time.Now()
+	manifest, err := despair.RunBatch(ctx, pairs, opts)
+	manifestPath := filepath.Join(*out, despair.ManifestFile)
+	if errors.Is(err, context.Canceled) {
+		return fmt.Errorf("interrupted after %d of %d pairs, run again to resume (see %s)",
+			len(manifest.Entries), len(pairs), manifestPath)
+	}
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, e := range manifest.Entries {
+		if e.Error != "" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d pairs failed, see %s", failed, len(pairs), manifestPath)
+	}
+	fmt.Printf("processed %d pairs in %s, wrote %s\n",
+		len(pairs), time.Since(start).Round(time.Millisecond), manifestPath)
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/conneroisu/steroscopic-hardware/pkg/despair"
+)
+
+// Bench runs the bench subcommand, which times the disparity algorithms on
+// a stereo pair and prints the time per frame of each.
+func Bench(_ context.Context, args []string) error {
+	flags := flag.NewFlagSet("bench", flag.ContinueOnError)
+	left := flags.String("left", "./testdata/L_00001.png", "left image of the stereo pair")
+	right := flags.String("right", "./testdata/R_00001.png", "right image of the stereo pair")
+	calibration := flags.String("calibration", "", "calibration file to rectify the pair with")
+	algorithms := flags.String("algorithms", "", "comma-separated algorithms to time (default all)")
+	n := flags.Int("n", 5, "number of frames to time each algorithm over")
+	params := addParamFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *n < 1 {
+		return errors.New("bench needs at least one frame")
+	}
+
+	p, err := params.parameters()
+	if err != nil {
+		return err
+	}
+	names := despair.Matchers()
+	if *algorithms != "" {
+		names = nil
+		for s := range strings.SplitSeq(*algorithms, ",") {
+			name, err := despair.ParseAlgorithm(s)
+			if err != nil {
+				return err
+			}
+			names = append(names, name)
+		}
+	}
+	leftImg, rightImg, err := loadPair(*left, *right, *calibration)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%dx%d pair, block size %d, max disparity %d, cost %s, %d frames\n",
+		leftImg.Rect.Dx(), leftImg.Rect.Dy(), p.BlockSize, p.MaxDisparity, p.Cost, *n)
+	fmt.Printf("%-10s %12s %12s %8s\n", "algorithm", "mean", "min", "fps")
+	for _, name := range names {
+		p.Algorithm = name
+		var total, fastest time.Duration
+		for i := range *n {
+			start := time.Now()
+			despair.Run(leftImg, rightImg, p)
+			elapsed := time.Since(start)
+			total += elapsed
+			if i == 0 || elapsed < fastest {
+				fastest = elapsed
+			}
+		}
+		mean := total / time.Duration(*n)
+		fmt.Printf("%-10s %12s %12s %8.2f\n", name,
+			mean.Round(time.Microsecond), fastest.Round(time.Microsecond), float64(time.Second)/float64(mean))
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"github.com/conneroisu/steroscopic-hardware/pkg/calibrate"
+	"github.com/conneroisu/steroscopic-hardware/pkg/camera"
+	"github.com/conneroisu/steroscopic-hardware/pkg/despair"
+	"github.com/conneroisu/steroscopic-hardware/pkg/homedir"
+)
+
+// Calibrate runs the calibrate subcommand, which solves the stereo
+// calibration offline from a directory of L_*.png and R_*.png checkerboard
+// pairs and writes it to a calibration file.
+func Calibrate(_ context.Context, args []string) error {
+	flags := flag.NewFlagSet("calibrate", flag.ContinueOnError)
+	dir := flags.String("dir", "./testdata", "directory of L_*.png and R_*.png stereo pairs")
+	cols := flags.Int("cols", 9, "inner corners across the checkerboard")
+	rows := flags.Int("rows", 6, "inner corners down the checkerboard")
+	square := flags.Float64("square", 0.025, "checkerboard square size, in meters")
+	out := flags.String("out", "", "calibration file to write (default $HOME/"+camera.CalibrationFile+")")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *out == "" {
+		home, err := homedir.Dir()
+		if err != nil {
+			return err
+		}
+		*out = filepath.Join(home, camera.CalibrationFile)
+	}
+
+	pattern := calibrate.Pattern{Cols: *cols, Rows: *rows, SquareSize: *square}
+	res, err := calibrate.Dir(*dir, pattern, func(pair despair.StereoPair, err error) {
+		fmt.Printf("skipping %s: %v\n", pair.Name, err)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to calibrate: %w", err)
+	}
+	rectifier, err := despair.NewRectifier(res.Calibration)
+	if err != nil {
+		return fmt.Errorf("invalid calibration: %w", err)
+	}
+
+	err = despair.SaveCalibration(*out, res.Calibration)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("calibrated from %d views: reprojection error %.3f px (left %.3f, right %.3f)\n",
+		res.Views, res.RMS, res.LeftRMS, res.RightRMS)
+	fmt.Printf("baseline %.4f, wrote %s\n", rectifier.Baseline(), *out)
+
+	return nil
+}
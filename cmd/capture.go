@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/conneroisu/steroscopic-hardware/pkg/camera"
+	"github.com/conneroisu/steroscopic-hardware/pkg/despair"
+)
+
+// Capture runs the capture subcommand, which reads a number of frames from
+// a serial camera and writes them to a directory as PNG images, without
+// the web UI.
+func Capture(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("capture", flag.ContinueOnError)
+	port := flags.String("port", "", "serial port of the camera (required)")
+	baud := flags.Int("baud", 115200, "baud rate of the serial port")
+	protocol := flags.String("protocol", string(camera.ProtocolLegacy), "serial protocol: legacy or framed")
+	compression := flags.String("compression", camera.CompressionNone.String(), "compression to request: none, lzma or range")
+	width := flags.Int("width", camera.DefaultImageWidth, "width of the frames of the legacy protocol")
+	height := flags.Int("height", camera.DefaultImageHeight, "height of the frames of the legacy protocol")
+	format := flags.String("format", camera.PixelGray8.String(), "pixel format: gray8, gray16le, gray16be or bayer-rggb8")
+	bitDepth := flags.Int("bit-depth", 0, "significant bits of 16 bit samples, sent in the least significant bits (default all 16)")
+	n := flags.Int("n", 1, "number of frames to capture")
+	dir := flags.String("dir", ".", "directory to write the frames to")
+	prefix := flags.String("prefix", "frame", "file name prefix of the frames, as in frame_00001.png")
+	timeout := flags.Duration("timeout", 0, "time to wait for the frames (default no limit)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *port == "" {
+		return errors.New("capture needs a -port")
+	}
+	if *n < 1 {
+		return errors.New("capture needs at least one frame")
+	}
+
+	config := camera.Config{
+		Port:     *port,
+		BaudRate: *baud,
+		Protocol: camera.Protocol(*protocol),
+		Width:    *width,
+		Height:   *height,
+		BitDepth: *bitDepth,
+	}
+	var err error
+	config.Compression, err = camera.ParseCompression(*compression)
+	if err != nil {
+		return err
+	}
+	config.Format, err = camera.ParsePixelFormat(*format)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(*dir, 0o755)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	if *timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	cam, err := camera.NewSerialCamera(ctx, camera.LeftCameraType, config)
+	if err != nil {
+		return err
+	}
+	defer cam.Close()
+
+	// The stream ends without closing the channel, when the camera fails
+	// or the context ends
+	frames := make(camera.ImageChannel, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cam.Stream(ctx, frames)
+	}()
+
+	start := time.Now()
+	for i := range *n {
+		select {
+		case img := <-frames:
+			name := filepath.Join(*dir, fmt.Sprintf("%s_%05d.png", *prefix, i+1))
+			err = despair.SavePNG(name, img)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("wrote %s\n", name)
+		case <-done:
+			return fmt.Errorf("camera stopped after %d of %d frames", i, *n)
+		case <-ctx.Done():
+			return fmt.Errorf("captured %d of %d frames: %w", i, *n, ctx.Err())
+		}
+	}
+	fmt.Printf("captured %d frames in %s\n", *n, time.Since(start).Round(time.Millisecond))
+
+	return nil
+}
@@ -0,0 +1,40 @@
+// Code generated by templ - DO NOT EDIT.
+
+// templ: version: v0.3.865
+package components
+
+//lint:file-ignore SA4006 This context is only used if a nested component is present.
+
+import "github.com/a-h/templ"
+import templruntime "github.com/a-h/templ/runtime"
+
+func Calibrate() templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var1 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var1 == nil {
+			templ_7745c5c3_Var1 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<div class=\"container mx-auto px-4 grid grid-cols-1 lg:grid-cols-4 gap-6\"><div class=\"lg:col-span-3 space-y-6\"><div class=\"bg-gray-800 rounded-lg shadow-lg p-4\"><div class=\"grid grid-cols-1 md:grid-cols-2 gap-4\"><div class=\"flex flex-col items-center\"><h2 class=\"text-xl font-semibold text-gray-200 mb-2\">Left Camera</h2><div class=\"w-full h-64 bg-black rounded-lg overflow-hidden relative\"><img style=\"width: 100%; height: 100%;\" class=\"absolute inset-0 w-full h-full\" src=\"/stream/left\"></div></div><div class=\"flex flex-col items-center\"><h2 class=\"text-xl font-semibold text-gray-200 mb-2\">Right Camera</h2><div class=\"w-full h-64 bg-black rounded-lg overflow-hidden relative\"><img style=\"width: 100%; height: 100%;\" class=\"absolute inset-0 w-full h-full\" src=\"/stream/right\"></div></div></div></div></div><div class=\"lg:col-span-1 space-y-6\"><div class=\"bg-gray-800 rounded-lg shadow-lg p-4\" id=\"calibration-controls\"><h2 class=\"text-xl font-semibold text-gray-200 mb-4\">Calibration</h2><form id=\"calibration-form\" class=\"space-y-2\" hx-post=\"/calibrate/capture\" hx-target=\"#calibration-status\" hx-indicator=\"#calibration-indicator\"><p class=\"text-sm text-gray-400\">Hold a checkerboard in view of both cameras and capture it from several distances and angles.</p><div class=\"flex items-center justify-between\"><label for=\"calibration-cols\" class=\"text-sm text-gray-300\">Inner corners across:</label> <input id=\"calibration-cols\" name=\"cols\" type=\"number\" min=\"2\" value=\"9\" class=\"bg-gray-700 text-gray-200 rounded px-3 py-1 text-sm border border-gray-600 w-20\"></div><div class=\"flex items-center justify-between\"><label for=\"calibration-rows\" class=\"text-sm text-gray-300\">Inner corners down:</label> <input id=\"calibration-rows\" name=\"rows\" type=\"number\" min=\"2\" value=\"6\" class=\"bg-gray-700 text-gray-200 rounded px-3 py-1 text-sm border border-gray-600 w-20\"></div><div class=\"flex items-center justify-between\"><label for=\"calibration-square\" class=\"text-sm text-gray-300\">Square size (m):</label> <input id=\"calibration-square\" name=\"squareSize\" type=\"number\" min=\"0\" step=\"0.001\" value=\"0.025\" class=\"bg-gray-700 text-gray-200 rounded px-3 py-1 text-sm border border-gray-600 w-20\"></div><div class=\"flex justify-end gap-2 mt-2 items-center\"><span id=\"calibration-indicator\" class=\"htmx-indicator text-xs text-blue-400\">Working...</span> <button type=\"submit\" class=\"bg-blue-600 hover:bg-blue-700 text-white rounded px-3 py-1 text-sm\">Capture</button> <button type=\"button\" hx-post=\"/calibrate/solve\" hx-target=\"#calibration-status\" hx-indicator=\"#calibration-indicator\" class=\"bg-green-600 hover:bg-green-700 text-white rounded px-3 py-1 text-sm\">Solve</button> <button type=\"button\" hx-post=\"/calibrate/reset\" hx-target=\"#calibration-status\" class=\"bg-gray-600 hover:bg-gray-700 text-white rounded px-3 py-1 text-sm\">Reset</button></div><div id=\"calibration-status\" class=\"mt-2\"></div></form></div></div></div>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return nil
+	})
+}
+
+var _ = templruntime.GeneratedTemplate
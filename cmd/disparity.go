@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"time"
+
+	"github.com/conneroisu/steroscopic-hardware/pkg/despair"
+)
+
+// Disparity runs the disparity subcommand, which computes the disparity map
+// of a stereo pair of PNG images and writes it as a PNG image, without the
+// web UI.
+func Disparity(_ context.Context, args []string) error {
+	flags := flag.NewFlagSet("disparity", flag.ContinueOnError)
+	left := flags.String("left", "", "left image of the stereo pair (required)")
+	right := flags.String("right", "", "right image of the stereo pair (required)")
+	out := flags.String("out", "disparity.png", "disparity map to write, normalized for display")
+	raw := flags.String("raw", "", "16-bit disparity map to write, scaled by the fixed-point scale")
+	mask := flags.String("mask", "", "validity mask to write, 255 for trusted pixels")
+	calibration := flags.String("calibration", "", "calibration file to rectify the pair with")
+	params := addParamFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *left == "" || *right == "" {
+		return errors.New("disparity needs -left and -right images")
+	}
+
+	p, err := params.parameters()
+	if err != nil {
+		return err
+	}
+	leftImg, rightImg, err := loadPair(*left, *right, *calibration)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	res := despair.Run(leftImg, rightImg, p)
+	elapsed := time.Since(start)
+
+	outputs := []struct {
+		path string
+		img  image.Image
+	}{{*out, res.Disparity}, {*raw, res.Raw}, {*mask, res.Valid}}
+	for _, o := range outputs {
+		if o.path == "" {
+			continue
+		}
+		err = despair.SavePNG(o.path, o.img)
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", o.path, err)
+		}
+	}
+	fmt.Printf("computed %dx%d %s disparity in %s, wrote %s\n",
+		leftImg.Rect.Dx(), leftImg.Rect.Dy(), p.Algorithm, elapsed.Round(time.Millisecond), *out)
+
+	return nil
+}
+
+// loadPair loads a stereo pair of PNG images of the same size, rectified
+// with the calibration file when one is given.
+func loadPair(left, right, calibration string) (*image.Gray, *image.Gray, error) {
+	leftImg, err := despair.LoadPNG(left)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load %s: %w", left, err)
+	}
+	rightImg, err := despair.LoadPNG(right)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load %s: %w", right, err)
+	}
+	if leftImg.Rect.Size() != rightImg.Rect.Size() {
+		return nil, nil, fmt.Errorf("%s is %v but %s is %v",
+			left, leftImg.Rect.Size(), right, rightImg.Rect.Size())
+	}
+	if calibration == "" {
+		return leftImg, rightImg, nil
+	}
+
+	cal, err := despair.LoadCalibration(calibration)
+	if err != nil {
+		return nil, nil, err
+	}
+	rectifier, err := despair.NewRectifier(cal)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid calibration: %w", err)
+	}
+
+	return rectifier.Rectify(leftImg, rightImg)
+}
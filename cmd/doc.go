@@ -5,6 +5,8 @@
 //   - API endpoints for camera configuration and image streaming
 //   - Depth map generation from stereo image pairs
 //   - Graceful shutdown handling
+//   - Subcommands for headless use: disparity, batch, capture, ports,
+//     bench, calibrate and simulate
 //
 // The main packages are:
 //   - Server: HTTP server implementation with proper timeouts
@@ -0,0 +1,505 @@
+package handlers
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/jpeg"
+	"image/png"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/conneroisu/steroscopic-hardware/pkg/camera"
+	"github.com/conneroisu/steroscopic-hardware/pkg/despair"
+)
+
+// APIPrefix is the path prefix of the versioned JSON API.
+const APIPrefix = "/api/v1"
+
+// maxRequestBody bounds the size of a JSON request body.
+const maxRequestBody = 1 << 20
+
+// APIError is the body of a failed JSON API request.
+type APIError struct {
+	// Status is the HTTP status code of the response.
+	Status int `json:"status"`
+	// Code is a stable, machine readable error code.
+	Code string `json:"code"`
+	// Message describes the error.
+	Message string `json:"message"`
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// Error codes of the JSON API.
+const (
+	// CodeBadRequest is the code of requests with invalid parameters or
+	// bodies.
+	CodeBadRequest = "bad_request"
+	// CodeNotFound is the code of requests for cameras or rigs that do not
+	// exist.
+	CodeNotFound = "not_found"
+	// CodeConflict is the code of requests creating a rig whose ID is
+	// taken.
+	CodeConflict = "conflict"
+	// CodeNoFrame is the code of frame requests before the camera's first
+	// frame.
+	CodeNoFrame = "no_frame"
+	// CodeInternal is the code of unexpected failures.
+	CodeInternal = "internal"
+)
+
+// badRequest wraps an error as a bad request.
+func badRequest(err error) *APIError {
+	return &APIError{Status: http.StatusBadRequest, Code: CodeBadRequest, Message: err.Error()}
+}
+
+// Param is a path or query parameter of an endpoint.
+type Param struct {
+	Name string
+	// In is "path" or "query".
+	In          string
+	Description string
+	Enum        []string
+}
+
+// Endpoint is a route of the JSON API. Both the route's handler and its
+// operation in the OpenAPI document are derived from it.
+type Endpoint struct {
+	Method  string
+	Path    string
+	ID      string
+	Summary string
+	Params  []Param
+	// Request is the type of the JSON request body, or nil without one.
+	Request reflect.Type
+	// Response is the type of the JSON response body, or nil when the
+	// response has one of MediaTypes instead.
+	Response   reflect.Type
+	MediaTypes []string
+	// Handle serves the endpoint, writing errors as APIError bodies.
+	Handle APIFn
+}
+
+// noBody is the request type of endpoints without a request body.
+type noBody struct{}
+
+// jsonEndpoint creates an endpoint that decodes a JSON request body of type
+// Req, unless Req is noBody, and encodes fn's result as the JSON response.
+func jsonEndpoint[Req, Resp any](
+	method, path, id, summary string,
+	params []Param,
+	fn func(r *http.Request, req Req) (Resp, error),
+) Endpoint {
+	e := Endpoint{
+		Method:   method,
+		Path:     path,
+		ID:       id,
+		Summary:  summary,
+		Params:   params,
+		Response: reflect.TypeFor[Resp](),
+	}
+	hasBody := reflect.TypeFor[Req]() != reflect.TypeFor[noBody]()
+	if hasBody {
+		e.Request = reflect.TypeFor[Req]()
+	}
+	e.Handle = func(w http.ResponseWriter, r *http.Request) error {
+		var req Req
+		if hasBody {
+			dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody))
+			dec.DisallowUnknownFields()
+			err := dec.Decode(&req)
+			if err != nil {
+				return writeAPIError(w, r, badRequest(fmt.Errorf("invalid request body: %w", err)))
+			}
+		}
+		resp, err := fn(r, req)
+		if err != nil {
+			return writeAPIError(w, r, err)
+		}
+
+		return writeJSON(w, http.StatusOK, resp)
+	}
+
+	return e
+}
+
+// writeJSON writes a JSON response.
+func writeJSON(w http.ResponseWriter, status int, body any) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	return json.NewEncoder(w).Encode(body)
+}
+
+// writeAPIError writes an error as an APIError body. Errors that are not
+// an *APIError are internal errors.
+func writeAPIError(w http.ResponseWriter, r *http.Request, err error) error {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		apiErr = &APIError{Status: http.StatusInternalServerError, Code: CodeInternal, Message: err.Error()}
+	}
+	slog.Error("api error", "err", err, "url", r.URL, "method", r.Method)
+
+	return writeJSON(w, apiErr.Status, apiErr)
+}
+
+// CameraStatus describes a camera of the camera manager.
+type CameraStatus struct {
+	Type camera.Type `json:"type"`
+	// Kind is serial, static or output.
+	Kind  string       `json:"kind"`
+	State camera.State `json:"state"`
+	// Config is the configuration of a serial camera.
+	Config *camera.Config `json:"config,omitempty"`
+}
+
+// RigStatus describes a rig and its cameras.
+type RigStatus struct {
+	ID      string    `json:"id"`
+	Created time.Time `json:"created"`
+	// Path is the path of the rig's page in the web UI.
+	Path    string         `json:"path"`
+	Cameras []CameraStatus `json:"cameras"`
+}
+
+// RigRequest is the body of a request creating a rig.
+type RigRequest struct {
+	ID string `json:"id"`
+}
+
+// PortInfo describes a serial port.
+type PortInfo struct {
+	Name         string `json:"name"`
+	IsUSB        bool   `json:"isUSB"`
+	VID          string `json:"vid,omitempty"`
+	PID          string `json:"pid,omitempty"`
+	SerialNumber string `json:"serialNumber,omitempty"`
+	Product      string `json:"product,omitempty"`
+}
+
+// cameraParam is the path parameter naming a camera.
+var cameraParam = Param{
+	Name:        "type",
+	In:          "path",
+	Description: "Camera type.",
+	Enum: []string{
+		string(camera.LeftCameraType),
+		string(camera.RightCameraType),
+		string(camera.OutputCameraType),
+	},
+}
+
+// rigParam is the path parameter naming a rig.
+var rigParam = Param{
+	Name:        "id",
+	In:          "path",
+	Description: "Rig ID.",
+}
+
+// APIv1 returns the endpoints of the JSON API. Rigs created and cameras
+// configured through it stream for the lifetime of ctx.
+//
+// The endpoints of the cameras and parameters of a rig are served under
+// /rigs/{id}, and without the prefix for the default rig.
+func APIv1(ctx context.Context) []Endpoint {
+	logger := slog.Default().WithGroup("api")
+
+	endpoints := []Endpoint{
+		jsonEndpoint(http.MethodGet, APIPrefix+"/rigs", "listRigs",
+			"List the rigs and their cameras.", nil,
+			func(_ *http.Request, _ noBody) ([]RigStatus, error) {
+				rigs := camera.Rigs()
+				statuses := make([]RigStatus, 0, len(rigs))
+				for _, rig := range rigs {
+					statuses = append(statuses, rigStatus(rig))
+				}
+
+				return statuses, nil
+			}),
+		jsonEndpoint(http.MethodPost, APIPrefix+"/rigs", "createRig",
+			"Create a rig with an output camera and no input cameras.", nil,
+			func(_ *http.Request, req RigRequest) (RigStatus, error) {
+				rig, err := camera.CreateRig(ctx, req.ID)
+				if errors.Is(err, camera.ErrRigExists) {
+					return RigStatus{}, &APIError{Status: http.StatusConflict, Code: CodeConflict, Message: err.Error()}
+				}
+				if err != nil {
+					return RigStatus{}, badRequest(err)
+				}
+				logger.Info("rig created", "rig", rig.ID())
+
+				return rigStatus(rig), nil
+			}),
+		jsonEndpoint(http.MethodGet, APIPrefix+"/rigs/{id}", "getRig",
+			"Get a rig and its cameras.", []Param{rigParam},
+			func(r *http.Request, _ noBody) (RigStatus, error) {
+				rig, err := rigOf(r)
+				if err != nil {
+					return RigStatus{}, err
+				}
+
+				return rigStatus(rig), nil
+			}),
+		jsonEndpoint(http.MethodDelete, APIPrefix+"/rigs/{id}", "deleteRig",
+			"Close the cameras of a rig and remove it. The default rig cannot be removed.", []Param{rigParam},
+			func(r *http.Request, _ noBody) (RigStatus, error) {
+				rig, err := rigOf(r)
+				if err != nil {
+					return RigStatus{}, err
+				}
+				if rig.ID() == camera.DefaultRig {
+					return RigStatus{}, badRequest(errors.New("the default rig cannot be removed"))
+				}
+				status := rigStatus(rig)
+				err = camera.RemoveRig(rig.ID())
+				if errors.Is(err, camera.ErrRigNotFound) {
+					return RigStatus{}, &APIError{Status: http.StatusNotFound, Code: CodeNotFound, Message: err.Error()}
+				}
+				if err != nil {
+					return RigStatus{}, err
+				}
+				logger.Info("rig removed", "rig", rig.ID())
+
+				return status, nil
+			}),
+		jsonEndpoint(http.MethodGet, APIPrefix+"/ports", "listPorts",
+			"List the serial ports and the ports of running simulators.", nil,
+			func(_ *http.Request, _ noBody) ([]PortInfo, error) {
+				ports, err := camera.ListPorts()
+				if err != nil {
+					return nil, fmt.Errorf("failed to list serial ports: %w", err)
+				}
+				infos := make([]PortInfo, 0, len(ports))
+				for _, port := range ports {
+					infos = append(infos, PortInfo{
+						Name:         port.Name,
+						IsUSB:        port.IsUSB,
+						VID:          port.VID,
+						PID:          port.PID,
+						SerialNumber: port.SerialNumber,
+						Product:      port.Product,
+					})
+				}
+
+				return infos, nil
+			}),
+	}
+	for _, e := range rigEndpoints(ctx, logger) {
+		endpoints = append(endpoints, e, rigEndpoint(e))
+	}
+
+	return endpoints
+}
+
+// rigEndpoints returns the endpoints of the cameras and parameters of a
+// rig, with the paths of the default rig.
+func rigEndpoints(ctx context.Context, logger *slog.Logger) []Endpoint {
+	return []Endpoint{
+		jsonEndpoint(http.MethodGet, APIPrefix+"/params", "getParams",
+			"Get the disparity parameters.", nil,
+			func(r *http.Request, _ noBody) (despair.Parameters, error) {
+				rig, err := rigOf(r)
+				if err != nil {
+					return despair.Parameters{}, err
+				}
+
+				return currentParams(rig), nil
+			}),
+		jsonEndpoint(http.MethodPut, APIPrefix+"/params", "putParams",
+			"Replace the disparity parameters; the output camera switches at its next frame.", nil,
+			func(r *http.Request, params despair.Parameters) (despair.Parameters, error) {
+				rig, err := rigOf(r)
+				if err != nil {
+					return params, err
+				}
+				err = validateParams(params)
+				if err != nil {
+					return params, badRequest(err)
+				}
+				// Spell out the names that empty values default to
+				params.Subpixel, _ = despair.ParseSubpixelMode(string(params.Subpixel))
+				params.Cost, _ = despair.ParseCostFunction(string(params.Cost))
+				params.Algorithm, _ = despair.ParseAlgorithm(string(params.Algorithm))
+				applyParams(rig, params)
+				logger.Info("parameters updated", "rig", rig.ID(), "params", params)
+
+				return params, nil
+			}),
+		jsonEndpoint(http.MethodGet, APIPrefix+"/cameras", "listCameras",
+			"List the cameras and their states.", nil,
+			func(r *http.Request, _ noBody) ([]CameraStatus, error) {
+				rig, err := rigOf(r)
+				if err != nil {
+					return nil, err
+				}
+
+				return cameraStatuses(rig), nil
+			}),
+		jsonEndpoint(http.MethodGet, APIPrefix+"/cameras/{type}", "getCamera",
+			"Get a camera and its state.", []Param{cameraParam},
+			func(r *http.Request, _ noBody) (CameraStatus, error) {
+				rig, err := rigOf(r)
+				if err != nil {
+					return CameraStatus{}, err
+				}
+
+				return cameraStatus(rig, camera.Type(r.PathValue("type")))
+			}),
+		jsonEndpoint(http.MethodPut, APIPrefix+"/cameras/{type}/config", "configureCamera",
+			"Replace an input camera with a serial camera of the given configuration.", []Param{cameraParam},
+			func(r *http.Request, config camera.Config) (CameraStatus, error) {
+				rig, err := rigOf(r)
+				if err != nil {
+					return CameraStatus{}, err
+				}
+				typ := camera.Type(r.PathValue("type"))
+				if typ != camera.LeftCameraType && typ != camera.RightCameraType {
+					return CameraStatus{}, badRequest(fmt.Errorf("camera %q is not an input camera", typ))
+				}
+				if config.Port == "" {
+					return CameraStatus{}, badRequest(errors.New("port not provided"))
+				}
+				if config.BaudRate <= 0 {
+					return CameraStatus{}, badRequest(errors.New("baud rate must be positive"))
+				}
+				err = config.Compression.Validate()
+				if err != nil {
+					return CameraStatus{}, badRequest(err)
+				}
+				err = config.ValidateFrame()
+				if err != nil {
+					return CameraStatus{}, badRequest(err)
+				}
+				err = configureSerial(ctx, logger, rig, typ, config)
+				if err != nil {
+					return CameraStatus{}, err
+				}
+
+				return cameraStatus(rig, typ)
+			}),
+		{
+			Method: http.MethodGet,
+			Path:   APIPrefix + "/cameras/{type}/frame",
+			ID:     "getFrame",
+			Summary: "Get the last frame of a camera. The X-Frame-Seq and X-Frame-Time " +
+				"headers carry the frame's number and capture time.",
+			Params: []Param{cameraParam, {
+				Name:        "format",
+				In:          "query",
+				Description: "Image format, png by default.",
+				Enum:        []string{"png", "jpeg"},
+			}},
+			MediaTypes: []string{"image/png", "image/jpeg"},
+			Handle:     handleFrame,
+		},
+	}
+}
+
+// rigEndpoint returns the endpoint of a named rig for an endpoint of the
+// default rig.
+func rigEndpoint(e Endpoint) Endpoint {
+	e.Path = APIPrefix + "/rigs/{id}" + strings.TrimPrefix(e.Path, APIPrefix)
+	e.ID += "OfRig"
+	e.Params = append([]Param{rigParam}, e.Params...)
+
+	return e
+}
+
+// rigStatus describes a rig and its cameras.
+func rigStatus(rig *camera.Rig) RigStatus {
+	return RigStatus{
+		ID:      rig.ID(),
+		Created: rig.Created(),
+		Path:    cmp.Or(RigPath(rig.ID()), "/"),
+		Cameras: cameraStatuses(rig),
+	}
+}
+
+// cameraStatuses describes the cameras that are set in a rig.
+func cameraStatuses(rig *camera.Rig) []CameraStatus {
+	statuses := make([]CameraStatus, 0, 3)
+	for _, typ := range []camera.Type{
+		camera.LeftCameraType,
+		camera.RightCameraType,
+		camera.OutputCameraType,
+	} {
+		status, err := cameraStatus(rig, typ)
+		if err == nil {
+			statuses = append(statuses, status)
+		}
+	}
+
+	return statuses
+}
+
+// cameraStatus describes a camera of a rig.
+func cameraStatus(rig *camera.Rig, typ camera.Type) (CameraStatus, error) {
+	cam := rig.GetCamera(typ)
+	if cam == nil {
+		return CameraStatus{}, &APIError{
+			Status:  http.StatusNotFound,
+			Code:    CodeNotFound,
+			Message: fmt.Sprintf("no %s camera", typ),
+		}
+	}
+	status := CameraStatus{Type: typ, State: rig.States()[typ]}
+	switch cam.(type) {
+	case *camera.SerialCamera:
+		status.Kind = "serial"
+		config := cam.Config()
+		status.Config = &config
+	case *camera.StaticCamera:
+		status.Kind = "static"
+	case *camera.OutputCamera:
+		status.Kind = "output"
+	}
+
+	return status, nil
+}
+
+// handleFrame serves the last frame a camera of a rig published on the
+// frame bus.
+func handleFrame(w http.ResponseWriter, r *http.Request) error {
+	rig, err := rigOf(r)
+	if err != nil {
+		return writeAPIError(w, r, err)
+	}
+	typ := camera.Type(r.PathValue("type"))
+	if _, err := cameraStatus(rig, typ); err != nil {
+		return writeAPIError(w, r, err)
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "png" && format != "jpeg" {
+		return writeAPIError(w, r, badRequest(fmt.Errorf("unknown image format %q", format)))
+	}
+	c, ok := rig.Bus().Latest(typ)
+	if !ok {
+		return writeAPIError(w, r, &APIError{
+			Status:  http.StatusServiceUnavailable,
+			Code:    CodeNoFrame,
+			Message: fmt.Sprintf("no frame from the %s camera yet", typ),
+		})
+	}
+
+	w.Header().Set("X-Frame-Seq", strconv.FormatUint(c.Seq, 10))
+	w.Header().Set("X-Frame-Time", c.Time.Format(time.RFC3339Nano))
+	if format == "jpeg" {
+		w.Header().Set("Content-Type", "image/jpeg")
+
+		return jpeg.Encode(w, c.Image, &jpeg.Options{Quality: DefaultStreamQuality})
+	}
+	w.Header().Set("Content-Type", "image/png")
+	encoder := png.Encoder{CompressionLevel: png.BestSpeed}
+
+	return encoder.Encode(w, c.Image)
+}
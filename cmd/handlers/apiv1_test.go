@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/conneroisu/steroscopic-hardware/pkg/camera"
+	"github.com/conneroisu/steroscopic-hardware/pkg/despair"
+)
+
+// testRig is the ID of the rig the tests create.
+const testRig = "api-test"
+
+// newAPIMux serves the JSON API and its OpenAPI document as the server
+// routes them, with a rig named testRig besides the default rig.
+func newAPIMux(t *testing.T) (*http.ServeMux, []Endpoint) {
+	t.Helper()
+	defaults := *despair.DefaultParams()
+	t.Cleanup(func() { despair.SetDefaultParams(defaults) })
+	_, err := camera.CreateRig(t.Context(), testRig)
+	if err != nil {
+		t.Fatalf("CreateRig() error = %v", err)
+	}
+	t.Cleanup(func() { _ = camera.RemoveRig(testRig) })
+
+	mux := http.NewServeMux()
+	endpoints := APIv1(t.Context())
+	for _, e := range endpoints {
+		mux.HandleFunc(e.Method+" "+e.Path, Make(e.Handle))
+	}
+	mux.HandleFunc("GET "+APIPrefix+"/openapi.json", Make(HandleOpenAPI(endpoints)))
+
+	return mux, endpoints
+}
+
+// serve serves a request with a JSON body and decodes the JSON response
+// into resp.
+func serve(t *testing.T, mux *http.ServeMux, method, path, body string, resp any) int {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if got := rec.Header().Get("Content-Type"); got != "application/json" {
+		t.Fatalf("%s %s: Content-Type = %q, want application/json", method, path, got)
+	}
+	err := json.NewDecoder(rec.Body).Decode(resp)
+	if err != nil {
+		t.Fatalf("%s %s: failed to decode the response: %v", method, path, err)
+	}
+
+	return rec.Code
+}
+
+func TestAPIParams(t *testing.T) {
+	mux, _ := newAPIMux(t)
+	for _, prefix := range []string{"", "/rigs/" + testRig} {
+		path := APIPrefix + prefix + "/params"
+		t.Run(path, func(t *testing.T) {
+			var got despair.Parameters
+			status := serve(t, mux, http.MethodGet, path, "", &got)
+			if status != http.StatusOK {
+				t.Fatalf("GET status = %d, want %d", status, http.StatusOK)
+			}
+			if got.BlockSize == 0 || got.MaxDisparity == 0 {
+				t.Errorf("GET = %+v, want the current parameters", got)
+			}
+
+			want := despair.Parameters{
+				BlockSize:    7,
+				MaxDisparity: 32,
+				LRCheck:      true,
+				LRThreshold:  2,
+				Subpixel:     despair.SubpixelParabolic,
+				Cost:         despair.CostSAD,
+				Algorithm:    despair.AlgorithmBlock,
+				Paths:        8,
+			}
+			body := `{"blockSize": 7, "maxDisparity": 32, "lrCheck": true, "lrThreshold": 2,
+				"subpixel": "parabolic", "paths": 8}`
+			status = serve(t, mux, http.MethodPut, path, body, &got)
+			if status != http.StatusOK {
+				t.Fatalf("PUT status = %d, want %d", status, http.StatusOK)
+			}
+			// The names of empty values are spelled out
+			if got != want {
+				t.Errorf("PUT = %+v, want %+v", got, want)
+			}
+			got = despair.Parameters{}
+			serve(t, mux, http.MethodGet, path, "", &got)
+			if got != want {
+				t.Errorf("GET after PUT = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestAPIParamsErrors(t *testing.T) {
+	mux, _ := newAPIMux(t)
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{"malformed body", http.MethodPut, "/params", `{"blockSize": `, http.StatusBadRequest, CodeBadRequest},
+		{"unknown field", http.MethodPut, "/params", `{"blockSize": 7, "size": 3}`, http.StatusBadRequest, CodeBadRequest},
+		{"even block size", http.MethodPut, "/params", `{"blockSize": 4, "maxDisparity": 32, "paths": 4}`,
+			http.StatusBadRequest, CodeBadRequest},
+		{"unknown cost", http.MethodPut, "/params", `{"blockSize": 7, "maxDisparity": 32, "paths": 4, "cost": "ssd"}`,
+			http.StatusBadRequest, CodeBadRequest},
+		{"unknown algorithm", http.MethodPut, "/params", `{"blockSize": 7, "maxDisparity": 32, "paths": 4, "algorithm": "sgn"}`,
+			http.StatusBadRequest, CodeBadRequest},
+		{"rig body", http.MethodPut, "/rigs/" + testRig + "/params", `{"blockSize": 7, "maxDisparity": 20, "paths": 4}`,
+			http.StatusBadRequest, CodeBadRequest},
+		{"unknown rig", http.MethodGet, "/rigs/missing/params", "", http.StatusNotFound, CodeNotFound},
+		{"unknown rig body", http.MethodPut, "/rigs/missing/params", `{"blockSize": 7, "maxDisparity": 32, "paths": 4}`,
+			http.StatusNotFound, CodeNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := *despair.DefaultParams()
+			var got APIError
+			status := serve(t, mux, tt.method, APIPrefix+tt.path, tt.body, &got)
+			if status != tt.status {
+				t.Errorf("status = %d, want %d", status, tt.status)
+			}
+			if got.Status != tt.status || got.Code != tt.code || got.Message == "" {
+				t.Errorf("error = %+v, want status %d and code %q with a message", got, tt.status, tt.code)
+			}
+			if *despair.DefaultParams() != before {
+				t.Error("a failed request changed the parameters")
+			}
+		})
+	}
+}
+
+func TestOpenAPIListsRoutes(t *testing.T) {
+	mux, endpoints := newAPIMux(t)
+	var doc struct {
+		OpenAPI string                               `json:"openapi"`
+		Paths   map[string]map[string]map[string]any `json:"paths"`
+	}
+	status := serve(t, mux, http.MethodGet, APIPrefix+"/openapi.json", "", &doc)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, want %d", status, http.StatusOK)
+	}
+	if doc.OpenAPI == "" {
+		t.Error("document has no OpenAPI version")
+	}
+
+	operations := 0
+	for _, item := range doc.Paths {
+		operations += len(item)
+	}
+	if operations != len(endpoints) {
+		t.Errorf("document has %d operations, want one for each of the %d routes", operations, len(endpoints))
+	}
+	ids := map[string]bool{}
+	for _, e := range endpoints {
+		op, ok := doc.Paths[e.Path][strings.ToLower(e.Method)]
+		if !ok {
+			t.Errorf("document lacks %s %s", e.Method, e.Path)
+
+			continue
+		}
+		if op["operationId"] != e.ID {
+			t.Errorf("%s %s has operationId %v, want %s", e.Method, e.Path, op["operationId"], e.ID)
+		}
+		if ids[e.ID] {
+			t.Errorf("operationId %s is used twice", e.ID)
+		}
+		ids[e.ID] = true
+	}
+	// The routes of the default rig are served for named rigs as well
+	for _, e := range rigEndpoints(t.Context(), slog.Default()) {
+		path := rigEndpoint(e).Path
+		if _, ok := doc.Paths[path][strings.ToLower(e.Method)]; !ok {
+			t.Errorf("document lacks %s %s", e.Method, path)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/conneroisu/steroscopic-hardware/cmd/components"
+	"github.com/conneroisu/steroscopic-hardware/pkg/camera"
+	"github.com/conneroisu/steroscopic-hardware/pkg/despair"
+	"github.com/conneroisu/steroscopic-hardware/pkg/homedir"
+)
+
+// BatchRunner runs the batches launched from the web UI, one at a time,
+// and keeps the progress of the last one.
+type BatchRunner struct {
+	ctx    context.Context
+	logger *slog.Logger
+
+	mu     sync.Mutex
+	cancel context.CancelFunc // Cancels the running batch, nil when none runs
+	status components.BatchStatus
+}
+
+// NewBatchRunner creates a batch runner whose batches are canceled when ctx
+// ends.
+func NewBatchRunner(ctx context.Context) *BatchRunner {
+	return &BatchRunner{
+		ctx:    ctx,
+		logger: slog.Default().WithGroup("batch"),
+	}
+}
+
+// start runs a batch in the background unless one is running.
+func (b *BatchRunner) start(pairs []despair.StereoPair, opts despair.BatchOptions) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.cancel != nil {
+		return errors.New("a batch is already running")
+	}
+	ctx, cancel := context.WithCancel(b.ctx)
+	b.cancel = cancel
+	b.status = components.BatchStatus{
+		Running:  true,
+		Out:      opts.Out,
+		Started:  time.Now(),
+		Progress: despair.BatchProgress{Total: len(pairs)},
+	}
+	opts.Progress = func(p despair.BatchProgress) {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.status.Progress = p
+	}
+
+	go func() {
+		defer cancel()
+		_, err := despair.RunBatch(ctx, pairs, opts)
+		if err != nil {
+			b.logger.Error("batch stopped", "out", opts.Out, "err", err)
+		} else {
+			b.logger.Info("batch finished", "out", opts.Out, "pairs", len(pairs))
+		}
+
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.cancel = nil
+		b.status.Running = false
+		b.status.Err = err
+	}()
+
+	return nil
+}
+
+// stop cancels the running batch, if any. The pairs being matched are
+// recorded in the manifest before the batch stops.
+func (b *BatchRunner) stop() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.cancel == nil {
+		return false
+	}
+	b.cancel()
+
+	return true
+}
+
+// Status returns the status of the running or last batch.
+func (b *BatchRunner) Status() components.BatchStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.status
+}
+
+// BatchStart handles client requests to compute the disparity maps of the
+// stereo pairs of a directory with the parameters of the default rig.
+//
+// The pairs are found in the dir form value by the leftPattern and
+// rightPattern form values and written with a manifest to the out form
+// value, where a batch interrupted before resumes. workers sets the number
+// of pairs processed at once and rectify, when set, rectifies the pairs
+// with the calibration of the default rig.
+func BatchStart(runner *BatchRunner) APIFn {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		opts, pairs, err := parseBatch(r)
+		if err != nil {
+			return writeStatus(w, "", err)
+		}
+		err = runner.start(pairs, opts)
+		if err != nil {
+			return writeStatus(w, "", err)
+		}
+		runner.logger.Info("batch started", "out", opts.Out, "pairs", len(pairs))
+
+		return writeStatus(w, fmt.Sprintf("Started a batch of %d pairs", len(pairs)), nil)
+	}
+}
+
+// BatchCancel handles client requests to stop the running batch.
+func BatchCancel(runner *BatchRunner) APIFn {
+	return func(w http.ResponseWriter, _ *http.Request) error {
+		if !runner.stop() {
+			return writeStatus(w, "", errors.New("no batch is running"))
+		}
+
+		return writeStatus(w, "Stopping the batch after the pairs being matched", nil)
+	}
+}
+
+// BatchStatusHandler handles client requests for the progress of the
+// running or last batch.
+func BatchStatusHandler(runner *BatchRunner) APIFn {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		return components.BatchProgress(runner.Status()).Render(r.Context(), w)
+	}
+}
+
+// parseBatch parses the form values of a batch and finds its pairs.
+func parseBatch(r *http.Request) (despair.BatchOptions, []despair.StereoPair, error) {
+	var opts despair.BatchOptions
+	if err := r.ParseForm(); err != nil {
+		return opts, nil, fmt.Errorf("failed to parse form data: %w", err)
+	}
+	rig, ok := camera.GetRig(camera.DefaultRig)
+	if !ok {
+		return opts, nil, errors.New("no default rig")
+	}
+	opts.Params = currentParams(rig)
+
+	dir, err := homedir.Expand(r.FormValue("dir"))
+	if err != nil {
+		return opts, nil, err
+	}
+	opts.Out, err = homedir.Expand(r.FormValue("out"))
+	if err != nil {
+		return opts, nil, err
+	}
+	if dir == "" || opts.Out == "" {
+		return opts, nil, errors.New("a batch needs a directory of pairs and an output directory")
+	}
+	opts.Workers, err = strconv.Atoi(r.FormValue("workers"))
+	if err != nil || opts.Workers < 1 {
+		return opts, nil, errors.New("workers must be a positive number")
+	}
+	if r.FormValue("rectify") != "" {
+		output, ok := rig.GetCamera(camera.OutputCameraType).(*camera.OutputCamera)
+		if ok {
+			opts.Rectifier = output.Rectifier()
+		}
+		if opts.Rectifier == nil {
+			return opts, nil, errors.New("the default rig is not calibrated")
+		}
+	}
+
+	pairs, err := despair.FindStereoPairs(dir, r.FormValue("leftPattern"), r.FormValue("rightPattern"))
+	if err != nil {
+		return opts, nil, err
+	}
+	if len(pairs) == 0 {
+		return opts, nil, fmt.Errorf("no stereo pairs found in %s", dir)
+	}
+
+	return opts, pairs, nil
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/conneroisu/steroscopic-hardware/pkg/calibrate"
+	"github.com/conneroisu/steroscopic-hardware/pkg/camera"
+)
+
+// CalibrateCapture handles client requests to capture the current stereo
+// pair as a calibration view.
+//
+// The checkerboard is described by the cols, rows and squareSize form
+// values; changing it discards the views captured so far.
+func CalibrateCapture(session *calibrate.Session) APIFn {
+	logger := slog.Default().WithGroup("calibrate-handler")
+
+	return func(w http.ResponseWriter, r *http.Request) error {
+		pattern, err := parsePattern(r)
+		if err != nil {
+			return writeStatus(w, "", err)
+		}
+		if pattern != session.Pattern() {
+			session.Reset(pattern)
+		}
+
+		left, right, err := camera.LatestPair()
+		if err != nil {
+			return writeStatus(w, "", fmt.Errorf("failed to read stereo pair: %w", err))
+		}
+		err = session.Add(left, right)
+		if err != nil {
+			return writeStatus(w, "", err)
+		}
+		logger.Info("calibration view captured", "views", session.Len())
+
+		return writeStatus(w, fmt.Sprintf(
+			"Captured view %d of at least %d", session.Len(), calibrate.MinViews), nil)
+	}
+}
+
+// CalibrateSolve handles client requests to solve the calibration from the
+// captured views and apply it to the output camera.
+func CalibrateSolve(session *calibrate.Session) APIFn {
+	logger := slog.Default().WithGroup("calibrate-handler")
+
+	return func(w http.ResponseWriter, _ *http.Request) error {
+		res, err := session.Calibrate()
+		if err != nil {
+			return writeStatus(w, "", err)
+		}
+		err = camera.ApplyCalibration(res.Calibration)
+		if err != nil {
+			return writeStatus(w, "", fmt.Errorf("failed to apply calibration: %w", err))
+		}
+		logger.Info("calibration solved",
+			"views", res.Views,
+			"rms", res.RMS,
+			"leftRms", res.LeftRMS,
+			"rightRms", res.RightRMS)
+
+		return writeStatus(w, fmt.Sprintf(
+			"Calibrated from %d views, reprojection error %.3f px (left %.3f, right %.3f)",
+			res.Views, res.RMS, res.LeftRMS, res.RightRMS), nil)
+	}
+}
+
+// CalibrateReset handles client requests to discard the captured views.
+func CalibrateReset(session *calibrate.Session) APIFn {
+	return func(w http.ResponseWriter, _ *http.Request) error {
+		session.Reset(session.Pattern())
+
+		return writeStatus(w, "Views cleared", nil)
+	}
+}
+
+// parsePattern parses the checkerboard pattern form values.
+func parsePattern(r *http.Request) (calibrate.Pattern, error) {
+	var pattern calibrate.Pattern
+	if err := r.ParseForm(); err != nil {
+		return pattern, fmt.Errorf("failed to parse form data: %w", err)
+	}
+
+	cols, err := strconv.Atoi(r.FormValue("cols"))
+	if err != nil {
+		return pattern, fmt.Errorf("invalid cols value: %w", err)
+	}
+	rows, err := strconv.Atoi(r.FormValue("rows"))
+	if err != nil {
+		return pattern, fmt.Errorf("invalid rows value: %w", err)
+	}
+	squareSize, err := strconv.ParseFloat(r.FormValue("squareSize"), 64)
+	if err != nil {
+		return pattern, fmt.Errorf("invalid square size value: %w", err)
+	}
+	pattern = calibrate.Pattern{Cols: cols, Rows: rows, SquareSize: squareSize}
+
+	return pattern, pattern.Validate()
+}
+
+// writeStatus writes a status fragment showing the error, if any, or the
+// message.
+func writeStatus(w http.ResponseWriter, message string, err error) error {
+	fragment := `<span class="text-sm text-green-500">` + html.EscapeString(message) + `</span>`
+	if err != nil {
+		fragment = `<span class="text-sm text-red-500">Failure: ` + html.EscapeString(err.Error()) + `</span>`
+	}
+	_, err = w.Write([]byte(fragment))
+
+	return err
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+
+	"github.com/conneroisu/steroscopic-hardware/pkg/camera"
+	"github.com/conneroisu/steroscopic-hardware/pkg/config"
+)
+
+// ApplyProfile sets the cameras and parameters of the default rig and
+// creates the named rigs of a profile, whose cameras stream for the
+// lifetime of ctx. Cameras and rigs that cannot be set up are reported in
+// the returned error; the others are set up nonetheless.
+func ApplyProfile(ctx context.Context, p config.Profile) error {
+	logger := slog.Default().WithGroup("config")
+
+	rig, ok := camera.GetRig(camera.DefaultRig)
+	if !ok {
+		return errors.New("no default rig")
+	}
+	err := rig.SetCamera(ctx, camera.OutputCameraType, camera.NewOutputCamera(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to initialize output camera: %w", err)
+	}
+	errs := []error{applyRig(ctx, logger, rig, p.Rig)}
+
+	for _, id := range slices.Sorted(maps.Keys(p.Rigs)) {
+		rig, err := camera.CreateRig(ctx, id)
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+		errs = append(errs, applyRig(ctx, logger, rig, p.Rigs[id]))
+	}
+
+	return errors.Join(errs...)
+}
+
+// applyRig sets the input cameras and parameters of a rig.
+func applyRig(ctx context.Context, logger *slog.Logger, rig *camera.Rig, desc config.Rig) error {
+	var errs []error
+	for typ, cam := range map[camera.Type]*config.Camera{
+		camera.LeftCameraType:  desc.Left,
+		camera.RightCameraType: desc.Right,
+	} {
+		var err error
+		switch {
+		case cam == nil:
+			continue
+		case cam.Serial != nil:
+			err = configureSerial(ctx, logger, rig, typ, *cam.Serial)
+		default:
+			err = rig.SetCamera(ctx, typ, camera.NewStaticCamera(ctx, cam.Static, typ))
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rig %s: %s camera: %w", rig.ID(), typ, err))
+		}
+	}
+
+	// Parameters the rig already has are kept as they are, even the
+	// defaults that predate the limits of the UI
+	current := currentParams(rig)
+	params, err := desc.Parameters(current)
+	if err == nil && params != current {
+		err = validateParams(params)
+	}
+	if err != nil {
+		errs = append(errs, fmt.Errorf("rig %s: %w", rig.ID(), err))
+	} else {
+		applyParams(rig, params)
+	}
+
+	return errors.Join(errs...)
+}
+
+// SaveConfig handles client requests to save the live cameras and
+// parameters of the rigs as the current profile of the configuration
+// file. The server address and output directory are kept as loaded.
+func SaveConfig(current *config.Current) APIFn {
+	logger := slog.Default().WithGroup("config")
+
+	return func(_ http.ResponseWriter, _ *http.Request) error {
+		p, err := snapshotProfile(current.Profile)
+		if err != nil {
+			return err
+		}
+		err = current.Save(p)
+		if err != nil {
+			return fmt.Errorf("failed to save configuration: %w", err)
+		}
+		logger.Info("configuration saved", "path", current.Path, "profile", current.Name)
+
+		return nil
+	}
+}
+
+// snapshotProfile describes the live rigs as a profile with the address
+// and output directory of base.
+func snapshotProfile(base config.Profile) (config.Profile, error) {
+	p := config.Profile{Address: base.Address, Output: base.Output}
+	for _, rig := range camera.Rigs() {
+		desc := config.Rig{
+			Left:  snapshotCamera(rig.GetCamera(camera.LeftCameraType)),
+			Right: snapshotCamera(rig.GetCamera(camera.RightCameraType)),
+		}
+		err := desc.SetParameters(currentParams(rig))
+		if err != nil {
+			return p, err
+		}
+		if rig.ID() == camera.DefaultRig {
+			p.Rig = desc
+
+			continue
+		}
+		if p.Rigs == nil {
+			p.Rigs = map[string]config.Rig{}
+		}
+		p.Rigs[rig.ID()] = desc
+	}
+
+	return p, nil
+}
+
+// snapshotCamera describes a static or serial camera, or returns nil for
+// other cameras.
+func snapshotCamera(cam camera.Camera) *config.Camera {
+	switch cam := cam.(type) {
+	case *camera.StaticCamera:
+		return &config.Camera{Static: cam.Path()}
+	case *camera.SerialCamera:
+		c := cam.Config()
+
+		return &config.Camera{Serial: &c}
+	default:
+		return nil
+	}
+}
@@ -57,12 +57,50 @@ func ConfigureMiddleware(apiFn APIFn) APIFn {
 		if err != nil {
 			return fmt.Errorf("invalid compression value: %w", err)
 		}
+		err = camera.Compression(compression).Validate()
+		if err != nil {
+			return err
+		}
+
+		// The protocol is optional and defaults to the legacy protocol
+		protocol, err := camera.ParseProtocol(r.FormValue("protocol"))
+		if err != nil {
+			return err
+		}
+
+		// The frame size, pixel format and bit depth are optional and
+		// default to those of the camera
+		width, err := optionalInt(r.FormValue("width"))
+		if err != nil {
+			return fmt.Errorf("invalid width value: %w", err)
+		}
+		height, err := optionalInt(r.FormValue("height"))
+		if err != nil {
+			return fmt.Errorf("invalid height value: %w", err)
+		}
+		format, err := optionalInt(r.FormValue("format"))
+		if err != nil {
+			return fmt.Errorf("invalid pixel format value: %w", err)
+		}
+		bitDepth, err := optionalInt(r.FormValue("bitDepth"))
+		if err != nil {
+			return fmt.Errorf("invalid bit depth value: %w", err)
+		}
 
 		// Create config
 		config := camera.Config{
 			Port:        portStr,
 			BaudRate:    baudRate,
-			Compression: compression,
+			Compression: camera.Compression(compression),
+			Protocol:    protocol,
+			Width:       width,
+			Height:      height,
+			Format:      camera.PixelFormat(format),
+			BitDepth:    bitDepth,
+		}
+		err = config.ValidateFrame()
+		if err != nil {
+			return err
 		}
 
 		// Add to request context
@@ -73,6 +111,15 @@ func ConfigureMiddleware(apiFn APIFn) APIFn {
 	}
 }
 
+// optionalInt parses an optional integer form value, zero when empty.
+func optionalInt(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	return strconv.Atoi(s)
+}
+
 // ConfigureCamera handles client requests to configure camera parameters.
 func ConfigureCamera(ctx context.Context, typ camera.Type) APIFn {
 	logger := slog.Default().WithGroup("configure-camera")
@@ -84,25 +131,13 @@ func ConfigureCamera(ctx context.Context, typ camera.Type) APIFn {
 			return errors.New("camera configuration not found in request context")
 		}
 
-		// Log configuration
-		logger.Info(
-			"configuring camera",
-			"type", string(typ),
-			"port", config.Port,
-			"baud", config.BaudRate,
-			"compression", config.Compression,
-		)
-
-		// Create and configure the camera - using the application context instead of request context
-		cam, err := camera.NewSerialCamera(ctx, typ, config.Port, config.BaudRate, config.Compression)
+		rig, err := rigOf(r)
 		if err != nil {
-			return fmt.Errorf("failed to create serial camera: %w", err)
+			return err
 		}
-
-		// Set the camera in the manager
-		err = camera.SetCamera(ctx, typ, cam)
+		err = configureSerial(ctx, logger, rig, typ, config)
 		if err != nil {
-			return fmt.Errorf("failed to set camera: %w", err)
+			return err
 		}
 
 		// Return success HTML
@@ -118,3 +153,43 @@ func ConfigureCamera(ctx context.Context, typ camera.Type) APIFn {
 		return nil
 	}
 }
+
+// configureSerial replaces a camera of a rig with a serial camera of the
+// given configuration, streaming for the lifetime of the application
+// context.
+func configureSerial(
+	ctx context.Context,
+	logger *slog.Logger,
+	rig *camera.Rig,
+	typ camera.Type,
+	config camera.Config,
+) error {
+	// Log configuration
+	logger.Info(
+		"configuring camera",
+		"rig", rig.ID(),
+		"type", string(typ),
+		"port", config.Port,
+		"baud", config.BaudRate,
+		"compression", config.Compression,
+		"protocol", config.Protocol,
+		"width", config.Width,
+		"height", config.Height,
+		"format", config.Format,
+		"bitDepth", config.BitDepth,
+	)
+
+	// Create and configure the camera - using the application context instead of request context
+	cam, err := camera.NewSerialCamera(ctx, typ, config)
+	if err != nil {
+		return fmt.Errorf("failed to create serial camera: %w", err)
+	}
+
+	// Set the camera in the manager
+	err = rig.SetCamera(ctx, typ, cam)
+	if err != nil {
+		return fmt.Errorf("failed to set camera: %w", err)
+	}
+
+	return nil
+}
@@ -37,15 +37,48 @@
 //     found
 //     - Formats port information for direct use in form select elements
 //
-//  4. **Image Streaming (`StreamHandlerFn`):**
-//     - Sets up MJPEG streaming with multipart boundaries
-//     - Manages client registration and connection lifecycle
+//  4. **Image Streaming (`HandleCameraStream`):**
+//     - Streams the frames each camera publishes on the camera frame bus as
+//     MJPEG (multipart/x-mixed-replace) on /stream/left, /stream/right and
+//     /stream/out
+//     - Per-client frame rate (`fps`, 10 by default) and JPEG quality
+//     (`quality`) query values
+//     - Tracks connected clients per stream in a `StreamRegistry`, which
+//     disconnects them on shutdown
 //     - Implements performance optimizations:
 //     - Buffer pooling to minimize memory allocation
-//     - JPEG quality control and compression
-//     - Frame rate limiting (10 FPS)
-//     - Connection timeouts (30 minutes)
-//     - Efficient image encoding with reusable buffers
+//     - Skips frames arriving faster than the client's frame rate
+//     - Disconnects stalled clients and clients connected for 30 minutes
+//
+//  5. **Live Events (`HandleEvents`):**
+//     - Streams log entries, camera state changes and output frame timings
+//     as server-sent events on /events
+//     - Event data are HTML fragments swapped into the page by the elements
+//     with a matching `sse-swap` attribute
+//
+//  6. **JSON API (`APIv1`):**
+//     - Versioned JSON endpoints under /api/v1 for rigs, parameters, camera
+//     status and configuration, serial ports and frames
+//     - Errors are `APIError` bodies with a status, code and message
+//     - `OpenAPI` derives the OpenAPI document from the same `Endpoint`
+//     definitions the routes are registered from
+//
+//  7. **Rigs (`LivePage`, `RigPath`):**
+//     - The camera, parameter, stream and event routes act on the rig named
+//     by the `id` path value of routes under /rigs/{id}, and on the default
+//     rig without one
+//     - Streams and event streams of a rig end when it is removed
+//
+//  8. **Configuration (`ApplyProfile`, `SaveConfig`):**
+//     - Sets up the cameras, parameters and rigs of a configuration profile
+//     at startup
+//     - Saves the live state of the rigs back to the profile on
+//     /config/save
+//
+//  9. **Batches (`BatchRunner`, `BatchStart`):**
+//     - Runs one batch of disparity maps at a time from the Batch page with
+//     the parameters of the default rig
+//     - Reports its progress on /batch/status, which the page polls
 //
 // ### UI Integration
 //
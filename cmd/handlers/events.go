@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/a-h/templ"
+
+	"github.com/conneroisu/steroscopic-hardware/cmd/components"
+	"github.com/conneroisu/steroscopic-hardware/pkg/camera"
+	"github.com/conneroisu/steroscopic-hardware/pkg/logger"
+)
+
+const (
+	// EventLog is the server-sent event carrying a log entry.
+	EventLog = "log"
+	// EventTiming is the server-sent event carrying the timing of an output
+	// frame.
+	EventTiming = "timing"
+	// eventStatePrefix prefixes the server-sent events carrying the state of
+	// a camera, which are named camera-<type>.
+	eventStatePrefix = "camera-"
+
+	// eventQueueSize is the number of events queued for a slow client.
+	eventQueueSize = 64
+	// keepAliveInterval is the time after which an idle event stream sends a
+	// comment to keep proxies from closing it.
+	keepAliveInterval = 15 * time.Second
+)
+
+// StateEvent returns the name of the server-sent event carrying the state
+// of a camera.
+func StateEvent(typ camera.Type) string {
+	return eventStatePrefix + string(typ)
+}
+
+// HandleEvents streams log entries and the camera state changes and output
+// frame timings of a rig as server-sent events until the client disconnects
+// or the rig is removed.
+//
+// The data of every event is an HTML fragment to be swapped into the page:
+// log entries as "log" events, the states of the cameras as "camera-<type>"
+// events and the frame timings as "timing" events. The states of all
+// cameras are sent when the client connects.
+func HandleEvents(ctx context.Context, logs *logger.Logger) APIFn {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		rig, err := rigOf(r)
+		if err != nil {
+			return err
+		}
+		entries, stopLogs := logs.Subscribe(eventQueueSize)
+		defer stopLogs()
+		states, stopStates := rig.SubscribeStates(eventQueueSize)
+		defer stopStates()
+		var timings <-chan camera.FrameTiming
+		if output, ok := rig.GetCamera(camera.OutputCameraType).(*camera.OutputCamera); ok {
+			var stopTimings func()
+			timings, stopTimings = output.SubscribeTimings(1)
+			defer stopTimings()
+		}
+
+		rc := http.NewResponseController(w)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+
+		var buf bytes.Buffer
+		send := func(event string, comp templ.Component) error {
+			buf.Reset()
+			err := comp.Render(r.Context(), &buf)
+			if err != nil {
+				return err
+			}
+			err = rc.SetWriteDeadline(time.Now().Add(frameWriteTimeout))
+			if err != nil {
+				return err
+			}
+			err = writeEvent(w, event, buf.String())
+			if err != nil {
+				return err
+			}
+
+			return rc.Flush()
+		}
+
+		for typ, state := range rig.States() {
+			if send(StateEvent(typ), components.CameraStateEvent(state)) != nil {
+				return nil
+			}
+		}
+
+		keepAlive := time.NewTicker(keepAliveInterval)
+		defer keepAlive.Stop()
+		for {
+			// Write errors mean the client went away
+			var err error
+			select {
+			case <-r.Context().Done():
+				return nil
+			case <-ctx.Done():
+				return nil
+			case <-rig.Done():
+				return nil
+			case entry, ok := <-entries:
+				if !ok {
+					return nil
+				}
+				err = send(EventLog, components.LogEvent(entry))
+			case change, ok := <-states:
+				if !ok {
+					return nil
+				}
+				err = send(StateEvent(change.Camera), components.CameraStateEvent(change.State))
+			case timing := <-timings:
+				err = send(EventTiming, components.FrameTimingEvent(timing))
+			case <-keepAlive.C:
+				_, err = fmt.Fprint(w, ": keep-alive\n\n")
+				if err == nil {
+					err = rc.Flush()
+				}
+			}
+			if err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// writeEvent writes a server-sent event, splitting its data into lines.
+func writeEvent(w http.ResponseWriter, event, data string) error {
+	_, err := fmt.Fprintf(w, "event: %s\n", event)
+	if err != nil {
+		return err
+	}
+	for line := range strings.SplitSeq(data, "\n") {
+		_, err = fmt.Fprintf(w, "data: %s\n", line)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprint(w, "\n")
+
+	return err
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/conneroisu/steroscopic-hardware/pkg/camera"
+	"github.com/conneroisu/steroscopic-hardware/pkg/despair"
+)
+
+// pathParamPattern matches the parameters of a route path.
+var pathParamPattern = regexp.MustCompile(`\{([^}$]+)\}`)
+
+// enumValues returns the values of the string types with a fixed set of
+// values.
+func enumValues() map[reflect.Type][]string {
+	algorithms := make([]string, 0)
+	for _, a := range despair.Matchers() {
+		algorithms = append(algorithms, string(a))
+	}
+
+	return map[reflect.Type][]string{
+		reflect.TypeFor[despair.Algorithm](): algorithms,
+		reflect.TypeFor[despair.CostFunction](): {
+			string(despair.CostSAD), string(despair.CostCensus),
+		},
+		reflect.TypeFor[despair.SubpixelMode](): {
+			string(despair.SubpixelNone), string(despair.SubpixelParabolic), string(despair.SubpixelEquiangular),
+		},
+		reflect.TypeFor[camera.Type](): cameraParam.Enum,
+		reflect.TypeFor[camera.Protocol](): {
+			string(camera.ProtocolLegacy), string(camera.ProtocolFramed),
+		},
+		reflect.TypeFor[camera.State](): {
+			string(camera.StateStarting), string(camera.StateStreaming), string(camera.StateStopped),
+			string(camera.StateConnected), string(camera.StateDegraded), string(camera.StateReconnecting),
+			string(camera.StateFailed),
+		},
+	}
+}
+
+// OpenAPI returns the OpenAPI 3.1 document describing the endpoints.
+func OpenAPI(endpoints []Endpoint) map[string]any {
+	s := schemas{defs: map[string]any{}, enums: enumValues()}
+	errorSchema := s.of(reflect.TypeFor[APIError]())
+
+	paths := map[string]any{}
+	for _, e := range endpoints {
+		op := map[string]any{
+			"operationId": e.ID,
+			"summary":     e.Summary,
+		}
+
+		var params []any
+		for _, p := range e.Params {
+			schema := map[string]any{"type": "string"}
+			if len(p.Enum) > 0 {
+				schema["enum"] = p.Enum
+			}
+			params = append(params, map[string]any{
+				"name":        p.Name,
+				"in":          p.In,
+				"description": p.Description,
+				"required":    p.In == "path",
+				"schema":      schema,
+			})
+		}
+		if len(params) > 0 {
+			op["parameters"] = params
+		}
+
+		if e.Request != nil {
+			op["requestBody"] = map[string]any{
+				"required": true,
+				"content": map[string]any{
+					"application/json": map[string]any{"schema": s.of(e.Request)},
+				},
+			}
+		}
+
+		content := map[string]any{}
+		if e.Response != nil {
+			content["application/json"] = map[string]any{"schema": s.of(e.Response)}
+		}
+		for _, mediaType := range e.MediaTypes {
+			content[mediaType] = map[string]any{
+				"schema": map[string]any{"type": "string", "format": "binary"},
+			}
+		}
+		op["responses"] = map[string]any{
+			"200": map[string]any{"description": "Success.", "content": content},
+			"default": map[string]any{
+				"description": "Error.",
+				"content": map[string]any{
+					"application/json": map[string]any{"schema": errorSchema},
+				},
+			},
+		}
+
+		path := pathParamPattern.ReplaceAllString(e.Path, "{$1}")
+		item, ok := paths[path].(map[string]any)
+		if !ok {
+			item = map[string]any{}
+			paths[path] = item
+		}
+		item[strings.ToLower(e.Method)] = op
+	}
+
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":       "Stereoscopic Hardware API",
+			"version":     strings.TrimPrefix(APIPrefix, "/api/"),
+			"description": "JSON API of the stereo camera web UI.",
+		},
+		"paths":      paths,
+		"components": map[string]any{"schemas": s.defs},
+	}
+}
+
+// HandleOpenAPI serves the OpenAPI document of the endpoints.
+func HandleOpenAPI(endpoints []Endpoint) APIFn {
+	return func(w http.ResponseWriter, _ *http.Request) error {
+		return writeJSON(w, http.StatusOK, OpenAPI(endpoints))
+	}
+}
+
+// schemas derives JSON schemas from Go types, collecting the schemas of
+// named struct types as components.
+type schemas struct {
+	defs  map[string]any
+	enums map[reflect.Type][]string
+}
+
+// of returns the JSON schema of values of type t as encoded by
+// encoding/json.
+func (s *schemas) of(t reflect.Type) map[string]any {
+	switch t {
+	case reflect.TypeFor[time.Time]():
+		return map[string]any{"type": "string", "format": "date-time"}
+	case reflect.TypeFor[time.Duration]():
+		return map[string]any{"type": "integer", "description": "Duration in nanoseconds."}
+	}
+	if values, ok := s.enums[t]; ok {
+		return map[string]any{"type": "string", "enum": values}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return s.of(t.Elem())
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+
+		return map[string]any{"type": "array", "items": s.of(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": s.of(t.Elem())}
+	case reflect.Struct:
+		return s.object(t)
+	default:
+		return map[string]any{}
+	}
+}
+
+// object returns the schema of a struct type, as a reference to a
+// component for named types.
+func (s *schemas) object(t reflect.Type) map[string]any {
+	name := t.Name()
+	ref := map[string]any{"$ref": "#/components/schemas/" + name}
+	if name != "" {
+		if _, ok := s.defs[name]; ok {
+			return ref
+		}
+		// Reserve the name so recursive types terminate
+		s.defs[name] = map[string]any{}
+	}
+
+	properties := map[string]any{}
+	var required []string
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		key, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if key == "-" {
+			continue
+		}
+		if key == "" {
+			key = field.Name
+		}
+		properties[key] = s.of(field.Type)
+		if !strings.Contains(opts, "omitempty") && field.Type.Kind() != reflect.Pointer {
+			required = append(required, key)
+		}
+	}
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	if name == "" {
+		return schema
+	}
+	s.defs[name] = schema
+
+	return ref
+}
@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// HandlePairStats renders the stereo pairing counters of a rig as an HTML
+// fragment.
+func HandlePairStats(w http.ResponseWriter, r *http.Request) error {
+	rig, err := rigOf(r)
+	if err != nil {
+		return err
+	}
+	stats := rig.PairStats()
+	_, err = fmt.Fprintf(w,
+		`<span class="text-sm text-gray-300">Pairs: %d, dropped: %d, mismatched: %d, stale: %d, skew: %s</span>`,
+		stats.Pairs, stats.Dropped, stats.Mismatched, stats.Stale, stats.LastSkew)
+
+	return err
+}
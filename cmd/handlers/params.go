@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/conneroisu/steroscopic-hardware/pkg/camera"
 	"github.com/conneroisu/steroscopic-hardware/pkg/despair"
 )
 
@@ -20,6 +21,11 @@ func ParametersHandler() APIFn {
 			return fmt.Errorf("failed to parse form data: %w", err)
 		}
 
+		rig, err := rigOf(r)
+		if err != nil {
+			return err
+		}
+
 		// Get form values
 		blockSizeStr := r.FormValue("blockSize")
 		maxDisparityStr := r.FormValue("maxDisparity")
@@ -33,11 +39,6 @@ func ParametersHandler() APIFn {
 			return fmt.Errorf("invalid block size value: %w", err)
 		}
 
-		// Block size must be odd and within range
-		if blockSize < 3 || blockSize > 31 || blockSize%2 == 0 {
-			return errors.New("block size must be odd and between 3 and 31")
-		}
-
 		// Validate max disparity
 		if maxDisparityStr == "" {
 			return errors.New("max disparity not provided")
@@ -47,23 +48,152 @@ func ParametersHandler() APIFn {
 			return fmt.Errorf("invalid max disparity value: %w", err)
 		}
 
-		// Max disparity must be within range and divisible by 16
-		if maxDisparity < 16 || maxDisparity > 256 || maxDisparity%16 != 0 {
-			return errors.New("max disparity must be between 16 and 256 and divisible by 16")
+		// Start from the output pipeline's parameters so fields the
+		// form omits keep their current values.
+		params := currentParams(rig)
+		params.BlockSize = blockSize
+		params.MaxDisparity = maxDisparity
+
+		// The left-right consistency check is optional; absent values
+		// leave the current setting untouched.
+		lrCheckStr := r.FormValue("lrCheck")
+		if lrCheckStr != "" {
+			params.LRCheck, err = strconv.ParseBool(lrCheckStr)
+			if err != nil {
+				return fmt.Errorf("invalid lr check value: %w", err)
+			}
+		}
+		lrThresholdStr := r.FormValue("lrThreshold")
+		if lrThresholdStr != "" {
+			params.LRThreshold, err = strconv.Atoi(lrThresholdStr)
+			if err != nil {
+				return fmt.Errorf("invalid lr threshold value: %w", err)
+			}
 		}
 
-		// Update parameters
-		despair.SetDefaultParams(despair.Parameters{
-			BlockSize:    blockSize,
-			MaxDisparity: maxDisparity,
-		})
+		subpixelStr := r.FormValue("subpixel")
+		if subpixelStr != "" {
+			params.Subpixel, err = despair.ParseSubpixelMode(subpixelStr)
+			if err != nil {
+				return err
+			}
+		}
+
+		costStr := r.FormValue("cost")
+		if costStr != "" {
+			params.Cost, err = despair.ParseCostFunction(costStr)
+			if err != nil {
+				return err
+			}
+		}
+
+		algorithmStr := r.FormValue("algorithm")
+		if algorithmStr != "" {
+			params.Algorithm, err = despair.ParseAlgorithm(algorithmStr)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Semi-global matching penalties and paths
+		p1Str := r.FormValue("p1")
+		if p1Str != "" {
+			params.P1, err = strconv.Atoi(p1Str)
+			if err != nil {
+				return fmt.Errorf("invalid p1 value: %w", err)
+			}
+		}
+		p2Str := r.FormValue("p2")
+		if p2Str != "" {
+			params.P2, err = strconv.Atoi(p2Str)
+			if err != nil {
+				return fmt.Errorf("invalid p2 value: %w", err)
+			}
+		}
+		pathsStr := r.FormValue("paths")
+		if pathsStr != "" {
+			params.Paths, err = strconv.Atoi(pathsStr)
+			if err != nil {
+				return fmt.Errorf("invalid paths value: %w", err)
+			}
+		}
+
+		err = validateParams(params)
+		if err != nil {
+			return err
+		}
+		applyParams(rig, params)
 
 		logger.Info(
 			"parameters updated",
+			"rig", rig.ID(),
 			"blockSize", blockSize,
 			"maxDisparity", maxDisparity,
+			"lrCheck", params.LRCheck,
+			"lrThreshold", params.LRThreshold,
+			"subpixel", params.Subpixel,
+			"cost", params.Cost,
+			"algorithm", params.Algorithm,
 		)
 
 		return nil
 	}
 }
+
+// currentParams returns the parameters of a rig's output pipeline, or the
+// defaults without an output camera.
+func currentParams(rig *camera.Rig) despair.Parameters {
+	output, ok := rig.GetCamera(camera.OutputCameraType).(*camera.OutputCamera)
+	if !ok {
+		return *despair.DefaultParams()
+	}
+
+	return output.Params()
+}
+
+// applyParams makes the parameters the parameters of a rig's output camera,
+// which switches at its next frame. The parameters of the default rig are
+// also made the defaults.
+func applyParams(rig *camera.Rig, params despair.Parameters) {
+	if rig.ID() == camera.DefaultRig {
+		despair.SetDefaultParams(params)
+	}
+	output, ok := rig.GetCamera(camera.OutputCameraType).(*camera.OutputCamera)
+	if ok {
+		output.SetParams(params)
+	}
+}
+
+// validateParams reports whether disparity parameters are within the
+// ranges the UI and API accept.
+func validateParams(params despair.Parameters) error {
+	// Block size must be odd and within range
+	if params.BlockSize < 3 || params.BlockSize > 31 || params.BlockSize%2 == 0 {
+		return errors.New("block size must be odd and between 3 and 31")
+	}
+	// Max disparity must be within range and divisible by 16
+	if params.MaxDisparity < 16 || params.MaxDisparity > 256 || params.MaxDisparity%16 != 0 {
+		return errors.New("max disparity must be between 16 and 256 and divisible by 16")
+	}
+	if params.LRThreshold < 0 || params.LRThreshold > params.MaxDisparity {
+		return errors.New("lr threshold must be between 0 and the max disparity")
+	}
+	if params.P1 < 0 || params.P2 < 0 {
+		return errors.New("penalties must not be negative")
+	}
+	if params.P1 > 0 && params.P2 > 0 && params.P2 < params.P1 {
+		return errors.New("p2 must not be smaller than p1")
+	}
+	if params.Paths != 4 && params.Paths != 8 {
+		return errors.New("paths must be 4 or 8")
+	}
+	if _, err := despair.ParseSubpixelMode(string(params.Subpixel)); err != nil {
+		return err
+	}
+	if _, err := despair.ParseCostFunction(string(params.Cost)); err != nil {
+		return err
+	}
+	_, err := despair.ParseAlgorithm(string(params.Algorithm))
+
+	return err
+}
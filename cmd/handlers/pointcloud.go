@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/conneroisu/steroscopic-hardware/pkg/camera"
+	"github.com/conneroisu/steroscopic-hardware/pkg/despair"
+)
+
+// HandlePointCloud serves the point cloud of the last frame of a rig's
+// output camera as a file download.
+//
+// The format query value selects ply (the default), ply-binary or pcd, and
+// intensity=false leaves out the left image's intensity as point color.
+func HandlePointCloud(w http.ResponseWriter, r *http.Request) error {
+	format := despair.FormatPLY
+	var err error
+	if s := r.URL.Query().Get("format"); s != "" {
+		format, err = despair.ParsePointCloudFormat(s)
+		if err != nil {
+			return err
+		}
+	}
+	intensity := true
+	if s := r.URL.Query().Get("intensity"); s != "" {
+		intensity, err = strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid intensity value: %w", err)
+		}
+	}
+
+	rig, err := rigOf(r)
+	if err != nil {
+		return err
+	}
+	output, ok := rig.GetCamera(camera.OutputCameraType).(*camera.OutputCamera)
+	if !ok {
+		return errors.New("output camera not available")
+	}
+	frame := output.LastFrame()
+	if frame == nil {
+		return errors.New("no disparity frame computed yet")
+	}
+	cloud, err := frame.PointCloud(intensity)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition",
+		`attachment; filename="pointcloud`+format.Extension()+`"`)
+
+	return cloud.Write(w, format)
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/conneroisu/steroscopic-hardware/pkg/camera"
 	"github.com/conneroisu/steroscopic-hardware/pkg/logger"
 	"go.bug.st/serial/enumerator"
 )
@@ -23,7 +24,7 @@ func GetPorts(
 			err        error
 		)
 		for {
-			ports, err = enumerator.GetDetailedPortsList()
+			ports, err = camera.ListPorts()
 			if err != nil || len(ports) == 0 {
 				if tries > 3 {
 					return errors.New("no serial ports found")
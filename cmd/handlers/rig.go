@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+
+	"github.com/a-h/templ"
+
+	"github.com/conneroisu/steroscopic-hardware/cmd/components"
+	"github.com/conneroisu/steroscopic-hardware/pkg/camera"
+)
+
+// RigPath returns the path prefix of the routes of a rig: /rigs/{id}, or
+// no prefix for the default rig, whose routes are the unprefixed ones.
+func RigPath(id string) string {
+	if id == camera.DefaultRig {
+		return ""
+	}
+
+	return "/rigs/" + id
+}
+
+// rigOf returns the rig named by the id path value of a request, or the
+// default rig for routes without one.
+func rigOf(r *http.Request) (*camera.Rig, error) {
+	id := cmp.Or(r.PathValue("id"), camera.DefaultRig)
+	rig, ok := camera.GetRig(id)
+	if !ok {
+		return nil, &APIError{
+			Status:  http.StatusNotFound,
+			Code:    CodeNotFound,
+			Message: fmt.Sprintf("no rig %s", id),
+		}
+	}
+
+	return rig, nil
+}
+
+// LivePage serves the live page of a rig, the default rig's for routes
+// without an id path value.
+func LivePage(wrapper func(templ.Component) templ.Component) APIFn {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		rig, err := rigOf(r)
+		if err != nil {
+			return err
+		}
+		MorphableHandler(
+			wrapper,
+			components.Live(RigPath(rig.ID()), currentParams(rig)),
+		)(w, r)
+
+		return nil
+	}
+}
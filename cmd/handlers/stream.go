@@ -1,52 +1,276 @@
 package handlers
 
 import (
-	"io"
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"log/slog"
 	"net/http"
-	"os"
-	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/conneroisu/steroscopic-hardware/pkg/camera"
-	"github.com/conneroisu/steroscopic-hardware/pkg/homedir"
 )
 
+const (
+	// DefaultStreamFPS is the frame rate of a stream whose client asks for
+	// none.
+	DefaultStreamFPS = 10
+	// MaxStreamFPS is the highest frame rate a client can ask for.
+	MaxStreamFPS = 30
+	// DefaultStreamQuality is the JPEG quality of a stream whose client asks
+	// for none.
+	DefaultStreamQuality = 75
+
+	// streamBoundary separates the frames of a multipart stream.
+	streamBoundary = "frame"
+	// frameWriteTimeout disconnects a client that takes longer to receive a
+	// frame.
+	frameWriteTimeout = 10 * time.Second
+	// maxStreamDuration disconnects a client after this long; browsers
+	// reconnect the image on their own.
+	maxStreamDuration = 30 * time.Minute
+)
+
+// bufferPool holds the buffers frames are encoded into.
+var bufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// StreamClient is a client connected to a camera stream.
+type StreamClient struct {
+	ID        uint64      `json:"id"`
+	Rig       string      `json:"rig"`
+	Camera    camera.Type `json:"camera"`
+	Remote    string      `json:"remote"`
+	FPS       int         `json:"fps"`
+	Quality   int         `json:"quality"`
+	Connected time.Time   `json:"connected"`
+
+	frames atomic.Uint64
+}
+
+// Frames returns the number of frames sent to the client.
+func (c *StreamClient) Frames() uint64 {
+	return c.frames.Load()
+}
+
+// StreamRegistry keeps track of the clients connected to the camera streams
+// and disconnects them when closed.
+type StreamRegistry struct {
+	mu      sync.Mutex
+	nextID  uint64
+	clients map[streamKey]map[*StreamClient]struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+// NewStreamRegistry creates a registry without clients.
+func NewStreamRegistry() *StreamRegistry {
+	return &StreamRegistry{
+		clients: map[streamKey]map[*StreamClient]struct{}{},
+		done:    make(chan struct{}),
+	}
+}
+
+// streamKey identifies the stream of a camera of a rig.
+type streamKey struct {
+	rig string
+	typ camera.Type
+}
+
+// Clients returns the clients connected to the stream of a rig's camera.
+func (s *StreamRegistry) Clients(rig string, typ camera.Type) []*StreamClient {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := streamKey{rig, typ}
+	clients := make([]*StreamClient, 0, len(s.clients[key]))
+	for c := range s.clients[key] {
+		clients = append(clients, c)
+	}
+
+	return clients
+}
+
+// Close disconnects the connected clients and those connecting later.
+func (s *StreamRegistry) Close() {
+	s.once.Do(func() { close(s.done) })
+}
+
+func (s *StreamRegistry) add(c *StreamClient) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	c.ID = s.nextID
+	key := streamKey{c.Rig, c.Camera}
+	if s.clients[key] == nil {
+		s.clients[key] = map[*StreamClient]struct{}{}
+	}
+	s.clients[key][c] = struct{}{}
+}
+
+func (s *StreamRegistry) remove(c *StreamClient) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.clients[streamKey{c.Rig, c.Camera}], c)
+}
+
 // HandleCameraStream is a generic handler for streaming camera images.
-func HandleCameraStream(camType camera.Type) APIFn {
-	return func(w http.ResponseWriter, _ *http.Request) error {
-		// read $HOME/{type}.png
-		dir, err := homedir.Dir()
+//
+// It streams the frames a rig's camera publishes on the frame bus as MJPEG,
+// a multipart/x-mixed-replace response of JPEG images, until the client
+// disconnects or the rig is removed. The fps and quality query values set
+// the client's frame rate, up to MaxStreamFPS, and JPEG quality; frames
+// arriving faster than the frame rate are skipped.
+func HandleCameraStream(streams *StreamRegistry, camType camera.Type) APIFn {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		rig, err := rigOf(r)
 		if err != nil {
 			return err
 		}
-		f, err := os.Open(filepath.Join(dir, string(camType)+".png"))
-		if err != nil {
-			return err
+		client := &StreamClient{
+			Rig:       rig.ID(),
+			Camera:    camType,
+			Remote:    r.RemoteAddr,
+			FPS:       DefaultStreamFPS,
+			Quality:   DefaultStreamQuality,
+			Connected: time.Now(),
 		}
-		defer f.Close()
-		bdy, err := io.ReadAll(f)
-		if err != nil {
-			return err
+		if s := r.URL.Query().Get("fps"); s != "" {
+			client.FPS, err = strconv.Atoi(s)
+			if err != nil || client.FPS < 1 || client.FPS > MaxStreamFPS {
+				return fmt.Errorf("invalid fps %q: must be between 1 and %d", s, MaxStreamFPS)
+			}
 		}
-		_, err = w.Write(bdy)
-		if err != nil {
-			return err
+		if s := r.URL.Query().Get("quality"); s != "" {
+			client.Quality, err = strconv.Atoi(s)
+			if err != nil || client.Quality < 1 || client.Quality > 100 {
+				return fmt.Errorf("invalid quality %q: must be between 1 and 100", s)
+			}
 		}
 
-		return nil
+		sub := rig.Bus().Subscribe(1, camType)
+		defer sub.Close()
+		streams.add(client)
+		defer streams.remove(client)
+		logger := slog.Default().WithGroup("stream").With(
+			"rig", client.Rig, "camera", camType, "client", client.ID, "remote", client.Remote)
+		logger.Debug("client connected", "fps", client.FPS, "quality", client.Quality)
+		defer func() {
+			logger.Debug("client disconnected", "frames", client.Frames())
+		}()
+
+		ctx, cancel := context.WithTimeout(r.Context(), maxStreamDuration)
+		defer cancel()
+		rc := http.NewResponseController(w)
+		w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary="+streamBoundary)
+		w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+		w.Header().Set("Connection", "close")
+
+		interval := time.Second / time.Duration(client.FPS)
+		// Start with the last frame so slow cameras show up at once
+		c, ok := rig.Bus().Latest(camType)
+		var sent uint64
+		for {
+			if ok && c.Seq != sent {
+				sent = c.Seq
+				// Write errors mean the client went away
+				if writeFrame(w, rc, c.Image, client.Quality) != nil {
+					return nil
+				}
+				client.frames.Add(1)
+
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-streams.done:
+					return nil
+				case <-rig.Done():
+					return nil
+				case <-time.After(interval):
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-streams.done:
+				return nil
+			case <-rig.Done():
+				return nil
+			case c, ok = <-sub.C():
+				if !ok {
+					return nil
+				}
+			}
+		}
 	}
 }
 
+// writeFrame writes an image as a JPEG part of a multipart stream.
+func writeFrame(w http.ResponseWriter, rc *http.ResponseController, img image.Image, quality int) error {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	defer bufferPool.Put(buf)
+	buf.Reset()
+	err := jpeg.Encode(buf, img, &jpeg.Options{Quality: quality})
+	if err != nil {
+		return err
+	}
+
+	err = rc.SetWriteDeadline(time.Now().Add(frameWriteTimeout))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "--%s\r\nContent-Type: image/jpeg\r\nContent-Length: %d\r\n\r\n",
+		streamBoundary, buf.Len())
+	if err != nil {
+		return err
+	}
+	_, err = buf.WriteTo(w)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write([]byte("\r\n"))
+	if err != nil {
+		return err
+	}
+
+	return rc.Flush()
+}
+
 // HandleLeftStream returns a handler for streaming the left camera.
-func HandleLeftStream(w http.ResponseWriter, r *http.Request) error {
-	return HandleCameraStream(camera.LeftCameraType)(w, r)
+func HandleLeftStream(streams *StreamRegistry) APIFn {
+	return HandleCameraStream(streams, camera.LeftCameraType)
 }
 
 // HandleRightStream returns a handler for streaming the right camera.
-func HandleRightStream(w http.ResponseWriter, r *http.Request) error {
-	return HandleCameraStream(camera.RightCameraType)(w, r)
+func HandleRightStream(streams *StreamRegistry) APIFn {
+	return HandleCameraStream(streams, camera.RightCameraType)
 }
 
 // HandleOutputStream returns a handler for streaming the output camera.
-func HandleOutputStream(w http.ResponseWriter, r *http.Request) error {
-	return HandleCameraStream(camera.OutputCameraType)(w, r)
+func HandleOutputStream(streams *StreamRegistry) APIFn {
+	return HandleCameraStream(streams, camera.OutputCameraType)
+}
+
+// HandleStreamClients renders the number of clients of each camera stream
+// of a rig as an HTML fragment.
+func HandleStreamClients(streams *StreamRegistry) APIFn {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		rig, err := rigOf(r)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w,
+			`<span class="text-sm text-gray-300">Left: %d, right: %d, output: %d</span>`,
+			len(streams.Clients(rig.ID(), camera.LeftCameraType)),
+			len(streams.Clients(rig.ID(), camera.RightCameraType)),
+			len(streams.Clients(rig.ID(), camera.OutputCameraType)))
+
+		return err
+	}
 }
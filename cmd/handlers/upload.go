@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"io"
@@ -17,6 +18,11 @@ func UploadHandler(appCtx context.Context, typ camera.Type) APIFn {
 	logger := slog.Default().WithGroup(fmt.Sprintf("upload-handler-%s", typ))
 
 	return func(w http.ResponseWriter, r *http.Request) error {
+		rig, err := rigOf(r)
+		if err != nil {
+			return err
+		}
+
 		// Parse multipart form
 		if err := r.ParseMultipartForm(32 << 20); err != nil { // 32MB max
 			return fmt.Errorf("failed to parse multipart form: %w", err)
@@ -47,21 +53,23 @@ func UploadHandler(appCtx context.Context, typ camera.Type) APIFn {
 
 		logger.Info("file saved", "path", path, "size", len(body))
 
-		old := camera.GetCamera(typ)
-		err = old.Close()
-		if err != nil {
-			return err
+		old := rig.GetCamera(typ)
+		if old != nil {
+			err = old.Close()
+			if err != nil {
+				return err
+			}
 		}
 		// Create static camera - Using the application context
 		staticCam := camera.NewStaticCamera(appCtx, path, typ)
 
 		// Set camera in manager - Using the application context
-		err = camera.SetCamera(appCtx, typ, staticCam)
+		err = rig.SetCamera(appCtx, typ, staticCam)
 		if err != nil {
 			return fmt.Errorf("failed to set static camera: %w", err)
 		}
 
-		logger.Info("camera configured from uploaded file", "type", typ)
+		logger.Info("camera configured from uploaded file", "rig", rig.ID(), "type", typ)
 
 		// Return success HTML for HTMX to replace the form
 		successHTML := fmt.Sprintf(`
@@ -77,7 +85,7 @@ func UploadHandler(appCtx context.Context, typ camera.Type) APIFn {
 			<div class="flex justify-between mt-2 items-center">
 				<span class="text-sm text-green-400">Camera now streaming from static image</span>
 				<button 
-					hx-get="/"
+					hx-get="%s"
 					hx-push-url="true"
 					hx-target="#app"
 					class="bg-blue-600 hover:bg-blue-700 text-white rounded px-3 py-1 text-sm"
@@ -85,7 +93,7 @@ func UploadHandler(appCtx context.Context, typ camera.Type) APIFn {
 					Reload UI
 				</button>
 			</div>
-		</div>`, string(typ), header.Filename)
+		</div>`, string(typ), header.Filename, cmp.Or(RigPath(rig.ID()), "/"))
 
 		_, err = w.Write([]byte(successHTML))
 		if err != nil {
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"strings"
+
+	"github.com/conneroisu/steroscopic-hardware/pkg/despair"
+)
+
+// paramFlags are the flags of the disparity parameters, shared by the
+// subcommands that compute disparities.
+type paramFlags struct {
+	params    despair.Parameters
+	subpixel  *string
+	cost      *string
+	algorithm *string
+}
+
+// addParamFlags adds the flags of the disparity parameters to flags, with
+// the default parameters as defaults.
+func addParamFlags(flags *flag.FlagSet) *paramFlags {
+	defaults := *despair.DefaultParams()
+	p := &paramFlags{params: defaults}
+	flags.IntVar(&p.params.BlockSize, "block-size", defaults.BlockSize, "size of the matching blocks, in pixels")
+	flags.IntVar(&p.params.MaxDisparity, "max-disparity", defaults.MaxDisparity, "largest disparity searched, in pixels")
+	flags.BoolVar(&p.params.LRCheck, "lr-check", defaults.LRCheck, "mark pixels failing the left-right consistency check invalid")
+	flags.IntVar(&p.params.LRThreshold, "lr-threshold", defaults.LRThreshold, "largest disagreement of the left-right check, in pixels")
+	flags.IntVar(&p.params.P1, "p1", defaults.P1, "semi-global matching penalty of one pixel changes (0 scales to the block)")
+	flags.IntVar(&p.params.P2, "p2", defaults.P2, "semi-global matching penalty of larger changes (0 scales to the block)")
+	flags.IntVar(&p.params.Paths, "paths", defaults.Paths, "semi-global matching aggregation paths: 4 or 8")
+	p.subpixel = flags.String("subpixel", string(defaults.Subpixel), "subpixel refinement: none, parabolic or equiangular")
+	p.cost = flags.String("cost", string(defaults.Cost), "matching cost: sad or census")
+	p.algorithm = flags.String("algorithm", string(defaults.Algorithm), "disparity algorithm: "+algorithmNames())
+
+	return p
+}
+
+// parameters returns the parameters of the parsed flags.
+func (p *paramFlags) parameters() (despair.Parameters, error) {
+	params := p.params
+	var err error
+	params.Subpixel, err = despair.ParseSubpixelMode(*p.subpixel)
+	if err != nil {
+		return params, err
+	}
+	params.Cost, err = despair.ParseCostFunction(*p.cost)
+	if err != nil {
+		return params, err
+	}
+	params.Algorithm, err = despair.ParseAlgorithm(*p.algorithm)
+	if err != nil {
+		return params, err
+	}
+	if params.BlockSize < 1 || params.MaxDisparity < 1 {
+		return params, errors.New("block size and max disparity must be positive")
+	}
+	if params.Paths != 4 && params.Paths != 8 {
+		return params, errors.New("paths must be 4 or 8")
+	}
+
+	return params, nil
+}
+
+// algorithmNames lists the names of the registered algorithms.
+func algorithmNames() string {
+	names := make([]string, 0, len(despair.Matchers()))
+	for _, name := range despair.Matchers() {
+		names = append(names, string(name))
+	}
+
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/conneroisu/steroscopic-hardware/pkg/camera"
+)
+
+// Ports runs the ports subcommand, which lists the serial ports, simulators
+// included, with the USB identity and product of each.
+func Ports(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("ports", flag.ContinueOnError)
+	watch := flags.Duration("watch", 0, "list the ports again at this interval until interrupted")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	err := printPorts()
+	if err != nil || *watch <= 0 {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	ticker := time.NewTicker(*watch)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			err = printPorts()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// printPorts prints the serial ports.
+func printPorts() error {
+	ports, err := camera.ListPorts()
+	if err != nil {
+		return fmt.Errorf("failed to list serial ports: %w", err)
+	}
+	if len(ports) == 0 {
+		fmt.Println("No serial ports found!")
+
+		return nil
+	}
+	fmt.Printf("Found %d serial ports\n", len(ports))
+	for _, port := range ports {
+		fmt.Printf("Found port: %s\n", port.Name)
+		if port.IsUSB {
+			fmt.Printf("   USB ID     %s:%s\n", port.VID, port.PID)
+			fmt.Printf("   USB serial %s\n", port.SerialNumber)
+		}
+		if port.Product != "" {
+			fmt.Printf("   Product    %s\n", port.Product)
+		}
+	}
+
+	return nil
+}
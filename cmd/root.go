@@ -1,8 +1,10 @@
 package cmd
 
 import (
+	"cmp"
 	"context"
 	"errors"
+	"flag"
 	"fmt"
 	"log/slog"
 	"net"
@@ -13,18 +15,14 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/conneroisu/steroscopic-hardware/cmd/handlers"
 	"github.com/conneroisu/steroscopic-hardware/pkg/camera"
+	"github.com/conneroisu/steroscopic-hardware/pkg/config"
 	"github.com/conneroisu/steroscopic-hardware/pkg/homedir"
 	"github.com/conneroisu/steroscopic-hardware/pkg/logger"
 )
 
 const (
-	// defaultHost is the IP address the server binds to (0.0.0.0 = all interfaces).
-	defaultHost = "0.0.0.0"
-
-	// defaultPort is the TCP port the server listens on.
-	defaultPort = "8080"
-
 	// shutdownTimeout is the maximum time allowed for the server to complete a graceful shutdown.
 	shutdownTimeout = 10 * time.Second
 
@@ -43,19 +41,55 @@ const (
 )
 
 // Run is the entry point for the application that starts the HTTP server and
-// manages its lifecycle.
+// manages its lifecycle. onStart is called with the URL of the web UI once
+// the server starts, unless args has the -no-browser flag.
 //
 // Process:
-//  1. Sets up signal handling for graceful shutdown
-//  2. Initializes the logger and camera system
-//  3. Creates and configures the HTTP server with appropriate timeouts
-//  4. Starts the server and monitors for shutdown signals
-//  5. Performs graceful shutdown when terminated
-func Run(ctx context.Context, onStart func()) error {
+//  1. Loads the profile chosen by the -config and -profile flags of args,
+//     listening on the -host and -port flags when given
+//  2. Sets up signal handling for graceful shutdown
+//  3. Initializes the logger and camera system
+//  4. Creates and configures the HTTP server with appropriate timeouts
+//  5. Starts the server and monitors for shutdown signals
+//  6. Performs graceful shutdown when terminated
+func Run(ctx context.Context, args []string, onStart func(url string)) error {
 	// Use a WaitGroup to track background goroutines
 	var wg sync.WaitGroup
 	start := time.Now()
 
+	// Load the configuration
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	configPath := flags.String("config", "", "configuration file (default $HOME/"+config.FileName+")")
+	profile := flags.String("profile", "", "profile of the configuration file to run")
+	host := flags.String("host", "", "host to listen on (default that of the profile)")
+	port := flags.String("port", "", "port to listen on (default that of the profile)")
+	noBrowser := flags.Bool("no-browser", false, "do not open the web UI in a browser")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if *noBrowser {
+		onStart = func(string) {}
+	}
+	if *configPath == "" {
+		*configPath, err = config.DefaultPath()
+		if err != nil {
+			return err
+		}
+	}
+	current, err := config.Open(*configPath, *profile)
+	if err != nil {
+		return err
+	}
+	output, err := homedir.Expand(current.Profile.Output)
+	if err != nil {
+		return err
+	}
+	address, err := listenAddress(cmp.Or(current.Profile.Address, config.DefaultAddress), *host, *port)
+	if err != nil {
+		return err
+	}
+
 	// Create a context with signal handling
 	innerCtx, cancel := signal.NotifyContext(
 		context.Background(), // Fresh Context
@@ -71,7 +105,14 @@ func Run(ctx context.Context, onStart func()) error {
 	logger := logger.NewLogger()
 
 	// Initialize camera system
-	initCameras(ctx)
+	slog.Info("applying profile", "path", current.Path, "profile", current.Name)
+	err = handlers.ApplyProfile(ctx, current.Profile)
+	if err != nil {
+		slog.Error("failed to apply profile", "err", err)
+	}
+	if rig, ok := camera.GetRig(camera.DefaultRig); ok {
+		go camera.Persist(innerCtx, rig, camera.PersistOptions{Dir: output})
+	}
 	defer func() {
 		err := camera.CloseAll()
 		if err != nil {
@@ -83,11 +124,15 @@ func Run(ctx context.Context, onStart func()) error {
 		}
 	}()
 
-	// Create HTTP server
+	// Create HTTP server. Its context ends when it starts shutting down so
+	// that long-running streams end.
+	serverCtx, stopServer := context.WithCancel(ctx)
+	defer stopServer()
 	handler, err := NewServer(
-		ctx,
+		serverCtx,
 		&logger,
 		cancel,
+		current,
 	)
 	if err != nil {
 		return err
@@ -95,16 +140,14 @@ func Run(ctx context.Context, onStart func()) error {
 
 	// Configure server with timeouts
 	httpServer := &http.Server{
-		Addr: net.JoinHostPort(
-			defaultHost,
-			defaultPort,
-		),
+		Addr:              address,
 		Handler:           handler,
 		ReadTimeout:       readTimeout,
 		WriteTimeout:      writeTimeout,
 		IdleTimeout:       idleTimeout,
 		ReadHeaderTimeout: readHeaderTimeout,
 	}
+	httpServer.RegisterOnShutdown(stopServer)
 
 	// Channel for server errors
 	serverErrors := make(chan error, 1)
@@ -120,7 +163,7 @@ func Run(ctx context.Context, onStart func()) error {
 		)
 
 		// Execute the onStart callback
-		onStart()
+		onStart(browserURL(address))
 
 		// Start the server
 		err := httpServer.ListenAndServe()
@@ -156,36 +199,29 @@ func Run(ctx context.Context, onStart func()) error {
 	}
 }
 
-// initCameras initializes the camera system with default cameras.
-func initCameras(ctx context.Context) {
-	// Initialize left camera with static test image
-	leftCam := camera.NewStaticCamera(ctx, "./testdata/L_00001.png", camera.LeftCameraType)
-	err := camera.SetCamera(ctx, camera.LeftCameraType, leftCam)
+// listenAddress returns address with its host and port replaced by host
+// and port when they are not empty.
+func listenAddress(address, host, port string) (string, error) {
+	h, p, err := net.SplitHostPort(address)
 	if err != nil {
-		slog.Error("failed to initialize left camera", "error", err)
-
-		return
+		return "", fmt.Errorf("invalid address %q: %w", address, err)
 	}
 
-	// Initialize right camera with static test image
-	rightCam := camera.NewStaticCamera(ctx, "./testdata/R_00001.png", camera.RightCameraType)
-	err = camera.SetCamera(ctx, camera.RightCameraType, rightCam)
-	if err != nil {
-		slog.Error("failed to initialize right camera", "error", err)
-
-		return
-	}
+	return net.JoinHostPort(cmp.Or(host, h), cmp.Or(port, p)), nil
+}
 
-	// Initialize output camera for depth mapping
-	outputCam := camera.NewOutputCamera(ctx)
-	err = camera.SetCamera(ctx, camera.OutputCameraType, outputCam)
+// browserURL returns the URL of the web UI served on address, on the local
+// host when the server listens on all interfaces.
+func browserURL(address string) string {
+	host, port, err := net.SplitHostPort(address)
 	if err != nil {
-		slog.Error("failed to initialize output camera", "error", err)
-
-		return
+		return "http://" + address
+	}
+	if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+		host = "localhost"
 	}
 
-	slog.Info("camera system initialized")
+	return "http://" + net.JoinHostPort(host, port)
 }
 
 // gracefulShutdown manages the orderly shutdown of the HTTP server.
@@ -221,14 +257,15 @@ func gracefulShutdown(
 //
 // Parameters:
 //   - logger: The application logger for recording events and errors
-//   - params: Stereoscopic algorithm parameters (block size, max disparity)
 //   - cancel: CancelFunc to gracefully shut down the application
+//   - current: The profile the server runs with, which the live state is saved to
 //
 // Returns an http.Handler and any error encountered during setup.
 func NewServer(
 	ctx context.Context,
 	logger *logger.Logger,
 	cancel context.CancelFunc,
+	current *config.Current,
 ) (http.Handler, error) {
 	mux := http.NewServeMux()
 	err := AddRoutes(
@@ -236,6 +273,7 @@ func NewServer(
 		mux,
 		logger,
 		cancel,
+		current,
 	)
 	if err != nil {
 		return nil, err
@@ -8,7 +8,9 @@ import (
 
 	"github.com/conneroisu/steroscopic-hardware/cmd/components"
 	"github.com/conneroisu/steroscopic-hardware/cmd/handlers"
+	"github.com/conneroisu/steroscopic-hardware/pkg/calibrate"
 	"github.com/conneroisu/steroscopic-hardware/pkg/camera"
+	"github.com/conneroisu/steroscopic-hardware/pkg/config"
 	"github.com/conneroisu/steroscopic-hardware/pkg/logger"
 	"github.com/conneroisu/steroscopic-hardware/pkg/web"
 )
@@ -27,6 +29,7 @@ func AddRoutes(
 	mux *http.ServeMux,
 	logger *logger.Logger,
 	cancel context.CancelFunc,
+	current *config.Current,
 ) error {
 	// Health check endpoint
 	mux.HandleFunc("GET /checkhealth", func(_ http.ResponseWriter, _ *http.Request) {})
@@ -43,66 +46,73 @@ func AddRoutes(
 		cancel()
 	})
 
-	// Main UI page
-	mux.Handle("GET /{$}", handlers.MorphableHandler(
-		components.AppFn(web.LivePageTitle),
-		components.Live(),
-	))
-
-	// Parameter update endpoint
+	// Saves the live state to the configuration file
 	mux.HandleFunc(
-		"POST /update-params",
-		handlers.Make(handlers.ParametersHandler()),
+		"POST /config/save",
+		handlers.Make(handlers.ErrorHandler(handlers.SaveConfig(current))),
 	)
 
-	// Camera stream endpoints
+	// Live page of the default rig and of the named rigs
+	mux.HandleFunc("GET /{$}", handlers.Make(handlers.LivePage(components.AppFn(web.LivePageTitle))))
+	mux.HandleFunc("GET /rigs/{id}", handlers.Make(handlers.LivePage(components.AppFn(web.LivePageTitle))))
+
+	// Calibration page and endpoints
+	session := calibrate.NewSession(calibrate.Pattern{})
+	mux.Handle("GET /calibrate", handlers.MorphableHandler(
+		components.AppFn(web.CalibratePageTitle),
+		components.Calibrate(),
+	))
 	mux.HandleFunc(
-		"GET /stream/left",
-		handlers.Make(handlers.HandleLeftStream),
+		"POST /calibrate/capture",
+		handlers.Make(handlers.CalibrateCapture(session)),
 	)
 	mux.HandleFunc(
-		"GET /stream/right",
-		handlers.Make(handlers.HandleRightStream),
+		"POST /calibrate/solve",
+		handlers.Make(handlers.CalibrateSolve(session)),
 	)
 	mux.HandleFunc(
-		"GET /stream/out",
-		handlers.Make(handlers.HandleOutputStream),
+		"POST /calibrate/reset",
+		handlers.Make(handlers.CalibrateReset(session)),
 	)
 
-	// Left camera configuration and upload endpoints
+	// Batch page and endpoints
+	batches := handlers.NewBatchRunner(ctx)
+	mux.Handle("GET /batch", handlers.MorphableHandler(
+		components.AppFn(web.BatchPageTitle),
+		components.Batch(),
+	))
 	mux.HandleFunc(
-		"POST /left/configure",
-		handlers.Make(
-			handlers.ErrorHandler(
-				handlers.ConfigureMiddleware(
-					handlers.ConfigureCamera(
-						ctx,
-						camera.LeftCameraType,
-					)))),
+		"POST /batch/start",
+		handlers.Make(handlers.BatchStart(batches)),
 	)
 	mux.HandleFunc(
-		"POST /left/upload",
-		handlers.Make(
-			handlers.ErrorHandler(
-				handlers.UploadHandler(ctx, camera.LeftCameraType))),
+		"POST /batch/cancel",
+		handlers.Make(handlers.BatchCancel(batches)),
 	)
-
-	// Right camera configuration and upload endpoints
 	mux.HandleFunc(
-		"POST /right/configure",
-		handlers.Make(
-			handlers.ErrorHandler(
-				handlers.ConfigureMiddleware(
-					handlers.ConfigureCamera(
-						ctx,
-						camera.RightCameraType,
-					)))),
+		"GET /batch/status",
+		handlers.Make(handlers.BatchStatusHandler(batches)),
 	)
+
+	// Camera streams, whose clients are disconnected when the server shuts
+	// down
+	streams := handlers.NewStreamRegistry()
+	context.AfterFunc(ctx, streams.Close)
+
+	// Routes of the cameras and parameters of a rig, served under
+	// /rigs/{id} for named rigs and without a prefix for the default rig
+	for _, prefix := range []string{"", "/rigs/{id}"} {
+		addRigRoutes(ctx, mux, logger, streams, prefix)
+	}
+
+	// Versioned JSON API and its OpenAPI document
+	endpoints := handlers.APIv1(ctx)
+	for _, e := range endpoints {
+		mux.HandleFunc(e.Method+" "+e.Path, handlers.Make(e.Handle))
+	}
 	mux.HandleFunc(
-		"POST /right/upload",
-		handlers.Make(
-			handlers.ErrorHandler(
-				handlers.UploadHandler(ctx, camera.RightCameraType))),
+		"GET "+handlers.APIPrefix+"/openapi.json",
+		handlers.Make(handlers.HandleOpenAPI(endpoints)),
 	)
 
 	// Available ports endpoint
@@ -110,3 +120,66 @@ func AddRoutes(
 
 	return nil
 }
+
+// addRigRoutes registers the routes of the cameras and parameters of a rig
+// under prefix.
+func addRigRoutes(
+	ctx context.Context,
+	mux *http.ServeMux,
+	logger *logger.Logger,
+	streams *handlers.StreamRegistry,
+	prefix string,
+) {
+	// Parameter update endpoint
+	mux.HandleFunc(
+		"POST "+prefix+"/update-params",
+		handlers.Make(handlers.ParametersHandler()),
+	)
+
+	// Camera stream endpoints
+	mux.HandleFunc(
+		"GET "+prefix+"/stream/left",
+		handlers.Make(handlers.HandleLeftStream(streams)),
+	)
+	mux.HandleFunc(
+		"GET "+prefix+"/stream/right",
+		handlers.Make(handlers.HandleRightStream(streams)),
+	)
+	mux.HandleFunc(
+		"GET "+prefix+"/stream/out",
+		handlers.Make(handlers.HandleOutputStream(streams)),
+	)
+	mux.HandleFunc(
+		"GET "+prefix+"/stream/clients",
+		handlers.Make(handlers.HandleStreamClients(streams)),
+	)
+
+	// Live logs, camera states and frame timings
+	mux.HandleFunc("GET "+prefix+"/events", handlers.Make(handlers.HandleEvents(ctx, logger)))
+
+	// Stereo pairing counters
+	mux.HandleFunc("GET "+prefix+"/pairs", handlers.Make(handlers.HandlePairStats))
+
+	// Point cloud export of the last disparity frame
+	mux.HandleFunc(
+		"GET "+prefix+"/pointcloud",
+		handlers.Make(handlers.HandlePointCloud),
+	)
+
+	// Input camera configuration and upload endpoints
+	for _, typ := range []camera.Type{camera.LeftCameraType, camera.RightCameraType} {
+		mux.HandleFunc(
+			"POST "+prefix+"/"+string(typ)+"/configure",
+			handlers.Make(
+				handlers.ErrorHandler(
+					handlers.ConfigureMiddleware(
+						handlers.ConfigureCamera(ctx, typ)))),
+		)
+		mux.HandleFunc(
+			"POST "+prefix+"/"+string(typ)+"/upload",
+			handlers.Make(
+				handlers.ErrorHandler(
+					handlers.UploadHandler(ctx, typ))),
+		)
+	}
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/conneroisu/steroscopic-hardware/pkg/camera"
+)
+
+// Simulate runs the simulate subcommand, which serves a simulated serial
+// camera streaming PNG images on a pseudo-terminal until interrupted. The
+// web UI lists the simulator among the serial ports, so that a camera can
+// be configured with it as with hardware.
+func Simulate(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	name := flags.String("name", "simulator", "name the simulator is listed under with the serial ports")
+	protocol := flags.String("protocol", string(camera.ProtocolLegacy), "serial protocol: legacy or framed")
+	compressions := flags.String("compressions", "lzma", "comma-separated compressions to acknowledge: lzma and the experimental range")
+	width := flags.Int("width", camera.DefaultImageWidth, "width of the frames of the legacy protocol")
+	height := flags.Int("height", camera.DefaultImageHeight, "height of the frames of the legacy protocol")
+	format := flags.String("format", camera.PixelGray8.String(), "pixel format: gray8, gray16le, gray16be or bayer-rggb8")
+	bitDepth := flags.Int("bit-depth", 0, "significant bits of 16 bit samples, sent in the least significant bits (default all 16)")
+	interval := flags.Duration("interval", 0, "time between frames (default 100ms)")
+	latency := flags.Duration("latency", 0, "delay of the acknowledgement and every frame")
+	drop := flags.Float64("drop", 0, "probability of each byte to be dropped")
+	corrupt := flags.Float64("corrupt", 0, "probability of each byte to be corrupted")
+	seed := flags.Uint64("seed", 1, "seed of the dropped and corrupted bytes")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: simulate [flags] [image.png ...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	opts := camera.SimulatorOptions{
+		Protocol:    camera.Protocol(*protocol),
+		Width:       *width,
+		Height:      *height,
+		BitDepth:    *bitDepth,
+		Interval:    *interval,
+		Latency:     *latency,
+		DropRate:    *drop,
+		CorruptRate: *corrupt,
+		Seed:        *seed,
+	}
+	var err error
+	opts.Format, err = camera.ParsePixelFormat(*format)
+	if err != nil {
+		return err
+	}
+	for s := range strings.SplitSeq(*compressions, ",") {
+		if s == "" {
+			continue
+		}
+		c, err := camera.ParseCompression(s)
+		if err != nil {
+			return err
+		}
+		opts.Compressions = append(opts.Compressions, c)
+	}
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"./testdata/L_00001.png"}
+	}
+	sim, err := camera.LoadSimulator(paths, opts)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	return sim.ServePTY(ctx, *name, func(device string) {
+		fmt.Printf("simulating a %s camera of %d images on %s\n", opts.Protocol, len(paths), device)
+	})
+}
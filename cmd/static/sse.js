@@ -0,0 +1,73 @@
+// Minimal server-sent events swapping, following the attributes of the
+// htmx sse extension.
+//
+// An element with an sse-connect attribute opens an EventSource to its URL.
+// Descendants with an sse-swap attribute receive the data of the events
+// named by it, swapped in as their hx-swap attribute says: innerHTML (the
+// default), outerHTML, beforeend or afterbegin. An sse-max attribute limits
+// the children kept by beforeend and afterbegin swaps.
+(function () {
+  function swap(target, html) {
+    const style = target.getAttribute("hx-swap") || "innerHTML";
+    switch (style) {
+      case "beforeend":
+      case "afterbegin":
+        target.insertAdjacentHTML(style, html);
+        break;
+      case "outerHTML":
+        target.outerHTML = html;
+        return;
+      default:
+        target.innerHTML = html;
+    }
+    const max = parseInt(target.getAttribute("sse-max"), 10);
+    while (max > 0 && target.children.length > max) {
+      if (style === "afterbegin") {
+        target.lastElementChild.remove();
+      } else {
+        target.firstElementChild.remove();
+      }
+    }
+    if (style === "beforeend") {
+      target.scrollTop = target.scrollHeight;
+    }
+  }
+
+  function connect(root) {
+    if (root.sseSource) {
+      return;
+    }
+    const source = new EventSource(root.getAttribute("sse-connect"));
+    root.sseSource = source;
+    const names = new Set();
+    root.querySelectorAll("[sse-swap]").forEach(function (el) {
+      el.getAttribute("sse-swap")
+        .split(",")
+        .forEach(function (name) {
+          names.add(name.trim());
+        });
+    });
+    names.forEach(function (name) {
+      source.addEventListener(name, function (event) {
+        if (!document.body.contains(root)) {
+          source.close();
+          return;
+        }
+        root.querySelectorAll("[sse-swap]").forEach(function (el) {
+          const wanted = el.getAttribute("sse-swap").split(",");
+          if (wanted.some((n) => n.trim() === name)) {
+            swap(el, event.data);
+          }
+        });
+      });
+    });
+  }
+
+  function scan() {
+    document.querySelectorAll("[sse-connect]").forEach(connect);
+  }
+
+  document.addEventListener("DOMContentLoaded", scan);
+  // Pages swapped in by htmx navigation bring their own connections
+  document.addEventListener("htmx:afterSettle", scan);
+})();
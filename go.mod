@@ -6,6 +6,8 @@ require (
 	github.com/a-h/templ v0.3.865
 	github.com/samber/slog-multi v1.4.0
 	go.bug.st/serial v1.6.4
+	golang.org/x/sys v0.32.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -13,6 +15,5 @@ require (
 	github.com/davecgh/go-spew v1.1.2-0.20180830191138-d8f796af33cc // indirect
 	github.com/pmezard/go-difflib v1.0.1-0.20181226105442-5d4384ee4fb2 // indirect
 	github.com/samber/lo v1.49.1 // indirect
-	golang.org/x/sys v0.32.0 // indirect
 	golang.org/x/text v0.21.0 // indirect
 )
@@ -4,26 +4,74 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"os"
 	"os/exec"
 	"runtime"
+	"strings"
 
 	"github.com/conneroisu/steroscopic-hardware/cmd"
 )
 
+// commands are the subcommands by name.
+var commands = map[string]func(context.Context, []string) error{
+	"serve": func(ctx context.Context, args []string) error {
+		return cmd.Run(ctx, args, openBrowser)
+	},
+	"disparity": cmd.Disparity,
+	"batch":     cmd.Batch,
+	"capture":   cmd.Capture,
+	"ports":     cmd.Ports,
+	"bench":     cmd.Bench,
+	"calibrate": cmd.Calibrate,
+	"simulate":  cmd.Simulate,
+}
+
+const usage = `usage: steroscopic-hardware <command> [flags]
+
+Commands:
+  serve      run the web UI (the default without a command)
+  disparity  compute the disparity map of a stereo pair
+  batch      compute the disparity maps of a directory of stereo pairs
+  capture    write frames of a serial camera to a directory
+  ports      list the serial ports
+  bench      time the disparity algorithms on a stereo pair
+  calibrate  solve the stereo calibration from checkerboard pairs
+  simulate   serve a simulated serial camera
+
+Run steroscopic-hardware <command> -h for the flags of a command.
+`
+
 func main() {
-	err := cmd.Run(context.Background(), openBrowser)
-	if err != nil {
-		fmt.Println(err)
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		fmt.Print(usage)
 
 		return
 	}
+	command, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", name, usage)
+		os.Exit(2)
+	}
+
+	err := command(context.Background(), args)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
 
-func openBrowser() {
+func openBrowser(url string) {
 	var err error
-	url := "http://localhost:8080"
 
 	switch runtime.GOOS {
 	case "linux":
@@ -36,6 +84,7 @@ func openBrowser() {
 		err = errors.New("unsupported platform")
 	}
 	if err != nil {
-		log.Fatal(err)
+		// A headless machine has no browser to open the web UI in
+		log.Printf("failed to open a browser, the web UI is at %s: %v", url, err)
 	}
 }
@@ -0,0 +1,423 @@
+package calibrate
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/conneroisu/steroscopic-hardware/pkg/despair"
+)
+
+// MinViews is the minimum number of views of the checkerboard needed to
+// calibrate.
+const MinViews = 3
+
+// lmIterations bounds the iterations of the nonlinear refinements.
+const lmIterations = 100
+
+// Pattern describes a checkerboard calibration target.
+type Pattern struct {
+	// Cols and Rows are the number of inner corners along the board's
+	// width and height, one less than its number of squares.
+	Cols int `json:"cols"`
+	Rows int `json:"rows"`
+	// SquareSize is the side length of a square. It sets the unit of the
+	// calibration's translation, typically meters.
+	SquareSize float64 `json:"squareSize"`
+}
+
+// Validate reports whether the pattern describes a usable checkerboard.
+func (p Pattern) Validate() error {
+	if p.Cols < 2 || p.Rows < 2 {
+		return fmt.Errorf("pattern must have at least 2x2 inner corners, got %dx%d", p.Cols, p.Rows)
+	}
+	if p.SquareSize <= 0 {
+		return errors.New("pattern square size must be positive")
+	}
+
+	return nil
+}
+
+// objectPoints returns the corner positions on the board in the order
+// FindCorners returns them.
+func (p Pattern) objectPoints() []Point {
+	points := make([]Point, 0, p.Cols*p.Rows)
+	for row := range p.Rows {
+		for col := range p.Cols {
+			points = append(points, Point{
+				X: float64(col) * p.SquareSize,
+				Y: float64(row) * p.SquareSize,
+			})
+		}
+	}
+
+	return points
+}
+
+// Result is the outcome of a stereo calibration.
+type Result struct {
+	// Calibration is the solved stereo calibration.
+	Calibration despair.Calibration `json:"calibration"`
+	// RMS is the root mean square reprojection error of the stereo
+	// calibration, in pixels.
+	RMS float64 `json:"rms"`
+	// LeftRMS and RightRMS are the reprojection errors of the cameras
+	// calibrated on their own.
+	LeftRMS  float64 `json:"leftRms"`
+	RightRMS float64 `json:"rightRms"`
+	// Views is the number of stereo views used.
+	Views int `json:"views"`
+}
+
+// Stereo solves the stereo calibration from corresponding corner
+// detections of the pattern in the left and right images of several views,
+// each image being width by height pixels.
+//
+// Each camera is first calibrated on its own, initialized with Zhang's
+// closed form solution, and then all intrinsics, distortions, the relative
+// pose of the cameras and the board poses are refined together by
+// minimizing the reprojection error.
+func Stereo(pattern Pattern, left, right [][]Point, width, height int) (Result, error) {
+	if err := pattern.Validate(); err != nil {
+		return Result{}, err
+	}
+	if len(left) != len(right) {
+		return Result{}, errors.New("left and right views must be paired")
+	}
+	if len(left) < MinViews {
+		return Result{}, fmt.Errorf("at least %d views are required, got %d", MinViews, len(left))
+	}
+	for i := range left {
+		if len(left[i]) != pattern.Cols*pattern.Rows || len(right[i]) != pattern.Cols*pattern.Rows {
+			return Result{}, fmt.Errorf("view %d does not have %d corners", i, pattern.Cols*pattern.Rows)
+		}
+	}
+
+	leftCam, leftPoses, leftRMS, err := calibrateCamera(pattern, left, width, height)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to calibrate left camera: %w", err)
+	}
+	rightCam, rightPoses, rightRMS, err := calibrateCamera(pattern, right, width, height)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to calibrate right camera: %w", err)
+	}
+
+	// Initial relative pose: the median over the per view estimates
+	rvecs := make([][3]float64, len(left))
+	tvecs := make([][3]float64, len(left))
+	for i := range left {
+		rl, rr := rodrigues(leftPoses[i].r), rodrigues(rightPoses[i].r)
+		rel := rr.mul(rl.transpose())
+		rvecs[i] = rotationVector(rel)
+		tvecs[i] = rightPoses[i].t.add(rel.apply(leftPoses[i].t).scale(-1))
+	}
+	relR, relT := medianVec(rvecs), medianVec(tvecs)
+
+	// Joint refinement of both intrinsics, the relative pose and the
+	// left board poses
+	objects := pattern.objectPoints()
+	params := make([]float64, 0, 2*numIntrinsics+6+6*len(left))
+	params = append(params, leftCam[:]...)
+	params = append(params, rightCam[:]...)
+	params = append(params, relR[:]...)
+	params = append(params, relT[:]...)
+	for _, p := range leftPoses {
+		params = append(params, p.r[:]...)
+		params = append(params, p.t[:]...)
+	}
+	numResiduals := 4 * len(left) * len(objects)
+	residuals := func(params, out []float64) {
+		var lc, rc intrinsics
+		copy(lc[:], params)
+		copy(rc[:], params[numIntrinsics:])
+		rel := rodrigues(vec3(params[2*numIntrinsics:]))
+		relT := vec3(params[2*numIntrinsics+3:])
+		poses := params[2*numIntrinsics+6:]
+		n := 0
+		for v := range left {
+			rl := rodrigues(vec3(poses[6*v:]))
+			tl := vec3(poses[6*v+3:])
+			rr, tr := rel.mul(rl), rel.apply(tl).add(relT)
+			for k, obj := range objects {
+				p := lc.project(rl, tl, obj)
+				out[n], out[n+1] = p.X-left[v][k].X, p.Y-left[v][k].Y
+				p = rc.project(rr, tr, obj)
+				out[n+2], out[n+3] = p.X-right[v][k].X, p.Y-right[v][k].Y
+				n += 4
+			}
+		}
+	}
+	params = levenbergMarquardt(params, numResiduals, residuals, lmIterations)
+	out := make([]float64, numResiduals)
+	residuals(params, out)
+
+	var lc, rc intrinsics
+	copy(lc[:], params)
+	copy(rc[:], params[numIntrinsics:])
+	rel := rodrigues(vec3(params[2*numIntrinsics:]))
+
+	return Result{
+		Calibration: despair.Calibration{
+			Width:  width,
+			Height: height,
+			Left:   lc.model(),
+			Right:  rc.model(),
+			R:      rel,
+			T:      [3]float64(params[2*numIntrinsics+3:][:3]),
+		},
+		RMS:      math.Sqrt(sumSquares(out) / float64(numResiduals/2)),
+		LeftRMS:  leftRMS,
+		RightRMS: rightRMS,
+		Views:    len(left),
+	}, nil
+}
+
+// numIntrinsics is the number of intrinsic parameters that are estimated:
+// fx, fy, cx, cy and the distortion coefficients k1, k2, p1 and p2.
+const numIntrinsics = 8
+
+// intrinsics holds the estimated intrinsic parameters of a camera.
+type intrinsics [numIntrinsics]float64
+
+// project projects a board point seen with board pose (r, t) into the
+// image.
+func (c intrinsics) project(r mat3, t vec3, obj Point) Point {
+	p := r.apply(vec3{obj.X, obj.Y, 0}).add(t)
+	x, y := p[0]/p[2], p[1]/p[2]
+	k1, k2, p1, p2 := c[4], c[5], c[6], c[7]
+	r2 := x*x + y*y
+	radial := 1 + r2*(k1+r2*k2)
+	xd := x*radial + 2*p1*x*y + p2*(r2+2*x*x)
+	yd := y*radial + p1*(r2+2*y*y) + 2*p2*x*y
+
+	return Point{X: c[0]*xd + c[2], Y: c[1]*yd + c[3]}
+}
+
+// model returns the intrinsics as a camera model.
+func (c intrinsics) model() despair.CameraModel {
+	return despair.CameraModel{
+		Fx:         c[0],
+		Fy:         c[1],
+		Cx:         c[2],
+		Cy:         c[3],
+		Distortion: [5]float64{c[4], c[5], c[6], c[7], 0},
+	}
+}
+
+// pose is the pose of the board in a camera's frame: a rotation vector
+// and a translation.
+type pose struct {
+	r, t vec3
+}
+
+// calibrateCamera calibrates a single camera from its views of the
+// pattern, returning its intrinsics, the board pose of every view and the
+// reprojection error.
+func calibrateCamera(
+	pattern Pattern,
+	views [][]Point,
+	width, height int,
+) (intrinsics, []pose, float64, error) {
+	objects := pattern.objectPoints()
+	homographies := make([]mat3, len(views))
+	for i, view := range views {
+		homographies[i] = homography(objects, view)
+	}
+
+	fx, fy, cx, cy := initialIntrinsics(homographies, width, height)
+	k := mat3{fx, 0, cx, 0, fy, cy, 0, 0, 1}
+	kInv := k.inverse()
+
+	params := []float64{fx, fy, cx, cy, 0, 0, 0, 0}
+	for _, h := range homographies {
+		p := poseFromHomography(kInv, h)
+		params = append(params, p.r[:]...)
+		params = append(params, p.t[:]...)
+	}
+
+	numResiduals := 2 * len(views) * len(objects)
+	residuals := func(params, out []float64) {
+		var c intrinsics
+		copy(c[:], params)
+		n := 0
+		for v, view := range views {
+			r := rodrigues(vec3(params[numIntrinsics+6*v:]))
+			t := vec3(params[numIntrinsics+6*v+3:])
+			for k, obj := range objects {
+				p := c.project(r, t, obj)
+				out[n], out[n+1] = p.X-view[k].X, p.Y-view[k].Y
+				n += 2
+			}
+		}
+	}
+	params = levenbergMarquardt(params, numResiduals, residuals, lmIterations)
+
+	out := make([]float64, numResiduals)
+	residuals(params, out)
+	rms := math.Sqrt(sumSquares(out) / float64(numResiduals/2))
+	if math.IsNaN(rms) || params[0] <= 0 || params[1] <= 0 {
+		return intrinsics{}, nil, 0, errors.New("calibration did not converge")
+	}
+
+	var c intrinsics
+	copy(c[:], params)
+	poses := make([]pose, len(views))
+	for v := range views {
+		poses[v] = pose{
+			r: vec3(params[numIntrinsics+6*v:]),
+			t: vec3(params[numIntrinsics+6*v+3:]),
+		}
+	}
+
+	return c, poses, rms, nil
+}
+
+// homography returns the homography mapping board points to image points,
+// estimated with the normalized direct linear transform.
+func homography(from, to []Point) mat3 {
+	nFrom, nTo := normalization(from), normalization(to)
+	a := make([]float64, 9*9)
+	for i := range from {
+		p := nFrom.apply(vec3{from[i].X, from[i].Y, 1})
+		q := nTo.apply(vec3{to[i].X, to[i].Y, 1})
+		x, y, u, v := p[0], p[1], q[0], q[1]
+		for _, row := range [2][9]float64{
+			{-x, -y, -1, 0, 0, 0, u * x, u * y, u},
+			{0, 0, 0, -x, -y, -1, v * x, v * y, v},
+		} {
+			for r := range 9 {
+				for c := range 9 {
+					a[r*9+c] += row[r] * row[c]
+				}
+			}
+		}
+	}
+	h := mat3(smallestEigenvector(a, 9))
+
+	// Undo the normalizations
+	h = nTo.inverse().mul(h).mul(nFrom)
+	for i := range h {
+		h[i] /= h[8]
+	}
+
+	return h
+}
+
+// normalization returns the similarity that moves the centroid of points
+// to the origin and their mean distance from it to sqrt(2).
+func normalization(points []Point) mat3 {
+	var centroid Point
+	for _, p := range points {
+		centroid = add(centroid, p)
+	}
+	centroid = scale(centroid, 1/float64(len(points)))
+	var dist float64
+	for _, p := range points {
+		dist += length(sub(p, centroid))
+	}
+	s := math.Sqrt2 / max(dist/float64(len(points)), 1e-12)
+
+	return mat3{s, 0, -s * centroid.X, 0, s, -s * centroid.Y, 0, 0, 1}
+}
+
+// initialIntrinsics estimates the focal lengths and principal point from
+// the homographies of several views with Zhang's closed form solution,
+// falling back to a centred principal point and a typical focal length
+// when the views are degenerate.
+func initialIntrinsics(homographies []mat3, width, height int) (float64, float64, float64, float64) {
+	// Work in normalized image coordinates for numerical stability
+	s := float64(max(width, height)) / 2
+	n := mat3{1 / s, 0, -float64(width) / 2 / s, 0, 1 / s, -float64(height) / 2 / s, 0, 0, 1}
+
+	v := make([]float64, 6*6)
+	for _, h := range homographies {
+		h = n.mul(h)
+		col := func(i int) vec3 { return vec3{h[i], h[3+i], h[6+i]} }
+		vij := func(i, j int) [6]float64 {
+			hi, hj := col(i), col(j)
+
+			return [6]float64{
+				hi[0] * hj[0],
+				hi[0]*hj[1] + hi[1]*hj[0],
+				hi[1] * hj[1],
+				hi[2]*hj[0] + hi[0]*hj[2],
+				hi[2]*hj[1] + hi[1]*hj[2],
+				hi[2] * hj[2],
+			}
+		}
+		v12, v11, v22 := vij(0, 1), vij(0, 0), vij(1, 1)
+		var diff [6]float64
+		for i := range diff {
+			diff[i] = v11[i] - v22[i]
+		}
+		for _, row := range [2][6]float64{v12, diff} {
+			for r := range 6 {
+				for c := range 6 {
+					v[r*6+c] += row[r] * row[c]
+				}
+			}
+		}
+	}
+	b := smallestEigenvector(v, 6)
+	if b[0] < 0 {
+		for i := range b {
+			b[i] = -b[i]
+		}
+	}
+	b11, b12, b22, b13, b23, b33 := b[0], b[1], b[2], b[3], b[4], b[5]
+
+	denom := b11*b22 - b12*b12
+	v0 := (b12*b13 - b11*b23) / denom
+	lambda := b33 - (b13*b13+v0*(b12*b13-b11*b23))/b11
+	alpha := math.Sqrt(lambda / b11)
+	beta := math.Sqrt(lambda * b11 / denom)
+	u0 := -b13 * alpha * alpha / lambda
+
+	fx, fy, cx, cy := alpha*s, beta*s, u0*s+float64(width)/2, v0*s+float64(height)/2
+	if math.IsNaN(fx) || math.IsNaN(fy) || fx <= 0 || fy <= 0 ||
+		cx < 0 || cx > float64(width) || cy < 0 || cy > float64(height) {
+		f := float64(max(width, height))
+
+		return f, f, float64(width) / 2, float64(height) / 2
+	}
+
+	return fx, fy, cx, cy
+}
+
+// poseFromHomography recovers the board pose from a homography and the
+// inverse camera matrix.
+func poseFromHomography(kInv, h mat3) pose {
+	h1 := kInv.apply(vec3{h[0], h[3], h[6]})
+	h2 := kInv.apply(vec3{h[1], h[4], h[7]})
+	h3 := kInv.apply(vec3{h[2], h[5], h[8]})
+	lambda := 1 / h1.norm()
+	if h3[2] < 0 {
+		// The board must lie in front of the camera
+		lambda = -lambda
+	}
+	r1, r2, t := h1.scale(lambda), h2.scale(lambda), h3.scale(lambda)
+	r3 := r1.cross(r2)
+	r := nearestRotation(mat3{
+		r1[0], r2[0], r3[0],
+		r1[1], r2[1], r3[1],
+		r1[2], r2[2], r3[2],
+	})
+
+	return pose{r: rotationVector(r), t: t}
+}
+
+// medianVec returns the component-wise median of vectors.
+func medianVec(vectors [][3]float64) vec3 {
+	var out vec3
+	values := make([]float64, len(vectors))
+	for c := range 3 {
+		for i, v := range vectors {
+			values[i] = v[c]
+		}
+		slices.Sort(values)
+		out[c] = values[len(values)/2]
+	}
+
+	return out
+}
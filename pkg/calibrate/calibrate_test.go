@@ -0,0 +1,260 @@
+package calibrate
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/conneroisu/steroscopic-hardware/pkg/despair"
+)
+
+var testPattern = Pattern{Cols: 7, Rows: 5, SquareSize: 0.03}
+
+// testRig is a synthetic stereo rig with distorted lenses.
+var testRig = struct {
+	width, height int
+	left, right   intrinsics
+	r             mat3
+	t             vec3
+}{
+	width:  320,
+	height: 240,
+	left:   intrinsics{300, 302, 161, 118, -0.08, 0.02, 0.001, -0.001},
+	right:  intrinsics{296, 297, 157, 123, -0.06, 0.01, -0.001, 0.0005},
+	r:      rodrigues(vec3{0.005, -0.04, 0.01}),
+	t:      vec3{-0.1, 0.002, 0.003},
+}
+
+// testPoses are board poses in the left camera's frame, as a rotation
+// vector and the position of the board's centre.
+var testPoses = []pose{
+	{r: vec3{0, 0, 0}, t: vec3{0.05, 0, 0.5}},
+	{r: vec3{0.3, 0, 0}, t: vec3{0.05, 0.01, 0.55}},
+	{r: vec3{-0.3, 0.1, 0}, t: vec3{0.04, -0.01, 0.5}},
+	{r: vec3{0, 0.35, 0.05}, t: vec3{0.06, 0, 0.6}},
+	{r: vec3{0.1, -0.35, -0.05}, t: vec3{0.05, 0.02, 0.55}},
+	{r: vec3{0.25, 0.25, 0.1}, t: vec3{0.03, -0.02, 0.6}},
+	{r: vec3{-0.2, -0.25, -0.1}, t: vec3{0.07, 0.01, 0.52}},
+	{r: vec3{0.15, 0.2, 0.2}, t: vec3{0.05, 0, 0.65}},
+}
+
+// boardPose returns the pose of the board in the left camera's frame
+// whose centre is at p.t.
+func boardPose(p pose) (mat3, vec3) {
+	r := rodrigues(p.r)
+	centre := vec3{
+		float64(testPattern.Cols-1) * testPattern.SquareSize / 2,
+		float64(testPattern.Rows-1) * testPattern.SquareSize / 2,
+		0,
+	}
+
+	return r, p.t.add(r.apply(centre).scale(-1))
+}
+
+// rightPose returns a board pose in the right camera's frame.
+func rightPose(r mat3, t vec3) (mat3, vec3) {
+	return testRig.r.mul(r), testRig.r.apply(t).add(testRig.t)
+}
+
+// projectBoard returns the image positions of the pattern's corners.
+func projectBoard(cam intrinsics, r mat3, t vec3) []Point {
+	objects := testPattern.objectPoints()
+	points := make([]Point, len(objects))
+	for i, obj := range objects {
+		points[i] = cam.project(r, t, obj)
+	}
+
+	return points
+}
+
+// renderBoard renders the checkerboard seen by a camera, with a white
+// border of one square around the black and white squares.
+func renderBoard(cam intrinsics, r mat3, t vec3) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, testRig.width, testRig.height))
+	rt := r.transpose()
+	tBoard := rt.apply(t)
+	sq := testPattern.SquareSize
+	const samples = 6
+
+	for y := range testRig.height { // y := 0; y < height; y++
+		for x := range testRig.width { // x := 0; x < width; x++
+			var sum float64
+			for sy := range samples {
+				for sx := range samples {
+					u := float64(x) + (float64(sx)+0.5)/samples - 0.5
+					v := float64(y) + (float64(sy)+0.5)/samples - 0.5
+					ray := rt.apply(cam.undistort(u, v))
+					if ray[2]*tBoard[2] <= 0 {
+						continue
+					}
+					s := tBoard[2] / ray[2]
+					bx, by := s*ray[0]-tBoard[0], s*ray[1]-tBoard[1]
+					i, j := math.Floor(bx/sq), math.Floor(by/sq)
+					switch {
+					case i < -2 || j < -2 || i > float64(testPattern.Cols) || j > float64(testPattern.Rows):
+						sum += 60
+					case i < -1 || j < -1 || i > float64(testPattern.Cols-1) || j > float64(testPattern.Rows-1):
+						sum += 230
+					case int(i+j)%2 == 0:
+						sum += 20
+					default:
+						sum += 230
+					}
+				}
+			}
+			img.Pix[y*img.Stride+x] = uint8(sum / samples / samples)
+		}
+	}
+
+	return img
+}
+
+// undistort returns the ray through a distorted pixel.
+func (c intrinsics) undistort(u, v float64) vec3 {
+	xd, yd := (u-c[2])/c[0], (v-c[3])/c[1]
+	x, y := xd, yd
+	for range 5 {
+		r2 := x*x + y*y
+		radial := 1 + r2*(c[4]+r2*c[5])
+		dx := 2*c[6]*x*y + c[7]*(r2+2*x*x)
+		dy := c[6]*(r2+2*y*y) + 2*c[7]*x*y
+		x, y = (xd-dx)/radial, (yd-dy)/radial
+	}
+
+	return vec3{x, y, 1}
+}
+
+func TestStereoRecoversRig(t *testing.T) {
+	var left, right [][]Point
+	for i, p := range testPoses {
+		r, tr := boardPose(p)
+		rr, trr := rightPose(r, tr)
+		l, rt := projectBoard(testRig.left, r, tr), projectBoard(testRig.right, rr, trr)
+		// Deterministic detection noise of up to 0.05 pixels
+		for k := range l {
+			l[k].X += 0.05 * math.Sin(float64(7*i+k))
+			rt[k].Y += 0.05 * math.Cos(float64(5*i+k))
+		}
+		left, right = append(left, l), append(right, rt)
+	}
+
+	res, err := Stereo(testPattern, left, right, testRig.width, testRig.height)
+	if err != nil {
+		t.Fatalf("Stereo() error = %v", err)
+	}
+	checkCalibration(t, res)
+}
+
+func TestSessionCalibratesRenderedViews(t *testing.T) {
+	session := NewSession(testPattern)
+	for _, p := range testPoses {
+		r, tr := boardPose(p)
+		rr, trr := rightPose(r, tr)
+		err := session.Add(renderBoard(testRig.left, r, tr), renderBoard(testRig.right, rr, trr))
+		if err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	if session.Len() != len(testPoses) {
+		t.Fatalf("Len() = %d, want %d", session.Len(), len(testPoses))
+	}
+
+	res, err := session.Calibrate()
+	if err != nil {
+		t.Fatalf("Calibrate() error = %v", err)
+	}
+	checkCalibration(t, res)
+
+	blank := image.NewGray(image.Rect(0, 0, testRig.width, testRig.height))
+	if err := session.Add(blank, blank); !errors.Is(err, ErrPatternNotFound) {
+		t.Errorf("Add(blank) error = %v, want ErrPatternNotFound", err)
+	}
+	session.Reset(testPattern)
+	if _, err := session.Calibrate(); err == nil {
+		t.Error("Calibrate() succeeded without views")
+	}
+}
+
+func checkCalibration(t *testing.T, res Result) {
+	t.Helper()
+	if res.RMS > 0.3 {
+		t.Errorf("RMS = %v, want at most 0.3", res.RMS)
+	}
+	cal := res.Calibration
+	for _, c := range []struct {
+		name      string
+		got, want float64
+		tolerance float64
+	}{
+		{"left fx", cal.Left.Fx, testRig.left[0], 0.01 * testRig.left[0]},
+		{"left fy", cal.Left.Fy, testRig.left[1], 0.01 * testRig.left[1]},
+		{"left cx", cal.Left.Cx, testRig.left[2], 2},
+		{"left cy", cal.Left.Cy, testRig.left[3], 2},
+		{"right fx", cal.Right.Fx, testRig.right[0], 0.01 * testRig.right[0]},
+		{"right cy", cal.Right.Cy, testRig.right[3], 2},
+		{"left k1", cal.Left.Distortion[0], testRig.left[4], 0.03},
+		{"baseline", vec3(cal.T).norm(), testRig.t.norm(), 0.03 * testRig.t.norm()},
+		{"tx", cal.T[0], testRig.t[0], 0.005},
+	} {
+		if math.Abs(c.got-c.want) > c.tolerance {
+			t.Errorf("%s = %v, want %v ± %v", c.name, c.got, c.want, c.tolerance)
+		}
+	}
+	if angle := rotationVector(mat3(cal.R).mul(testRig.r.transpose())).norm(); angle > 0.01 {
+		t.Errorf("rotation is off by %v rad", angle)
+	}
+}
+
+func TestDir(t *testing.T) {
+	dir := t.TempDir()
+	for i, p := range testPoses[:4] {
+		r, tr := boardPose(p)
+		rr, trr := rightPose(r, tr)
+		name := fmt.Sprintf("%05d.png", i)
+		writePNG(t, filepath.Join(dir, "L_"+name), renderBoard(testRig.left, r, tr))
+		writePNG(t, filepath.Join(dir, "R_"+name), renderBoard(testRig.right, rr, trr))
+	}
+	// A pair without the board is skipped
+	blank := image.NewGray(image.Rect(0, 0, testRig.width, testRig.height))
+	writePNG(t, filepath.Join(dir, "L_blank.png"), blank)
+	writePNG(t, filepath.Join(dir, "R_blank.png"), blank)
+
+	var skipped []string
+	res, err := Dir(dir, testPattern, func(p despair.StereoPair, _ error) { skipped = append(skipped, p.Name) })
+	if err != nil {
+		t.Fatalf("Dir() error = %v", err)
+	}
+	if res.Views != 4 || !slices.Equal(skipped, []string{"blank"}) {
+		t.Errorf("Dir() used %d views and skipped %v, want 4 and [blank]", res.Views, skipped)
+	}
+	if res.RMS > 0.3 {
+		t.Errorf("RMS = %v, want at most 0.3", res.RMS)
+	}
+}
+
+func writePNG(t *testing.T, path string, img image.Image) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRotationVectorRoundTrip(t *testing.T) {
+	for _, v := range []vec3{{0, 0, 0}, {0.1, -0.2, 0.3}, {1, 0.5, -0.2}, {0, 0, 3}} {
+		got := rotationVector(rodrigues(v))
+		if got.add(v.scale(-1)).norm() > 1e-6 {
+			t.Errorf("rotationVector(rodrigues(%v)) = %v", v, got)
+		}
+	}
+}
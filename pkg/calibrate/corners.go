@@ -0,0 +1,476 @@
+package calibrate
+
+import (
+	"image"
+	"math"
+	"slices"
+)
+
+// Point is a position in an image, in pixels, or on the calibration
+// target, in the unit of Pattern.SquareSize.
+type Point struct {
+	X, Y float64
+}
+
+// cornerSigmas are the smoothing scales, in pixels, corners are searched
+// at. Larger scales tolerate blur and noise but merge the corners of small
+// squares.
+var cornerSigmas = []float64{1.5, 3, 5}
+
+// FindCorners locates the inner corners of a checkerboard in img.
+//
+// Corners are returned row by row, Cols corners per row, each refined to
+// subpixel accuracy. Rows run top to bottom and corners within a row left
+// to right as seen in the image, so two views of the same board from a
+// stereo pair list their corners in the same order. It reports false when
+// the full pattern cannot be found.
+func FindCorners(img *image.Gray, pattern Pattern) ([]Point, bool) {
+	for _, sigma := range cornerSigmas {
+		smoothed := blur(img, sigma)
+		candidates := saddlePoints(smoothed, sigma)
+		grid, ok := recoverGrid(candidates, pattern)
+		if !ok {
+			continue
+		}
+
+		// Refine on a lightly smoothed image with windows that stay clear
+		// of the neighbouring corners.
+		fine := blur(img, 0.7)
+		for i, p := range grid {
+			grid[i] = refineCorner(fine, p, refineRadius(grid, pattern, i))
+		}
+
+		return grid, true
+	}
+
+	return nil, false
+}
+
+// floatImage is a single channel image of float32 samples.
+type floatImage struct {
+	pix           []float32
+	width, height int
+}
+
+func (f *floatImage) at(x, y int) float32 {
+	x = min(max(x, 0), f.width-1)
+	y = min(max(y, 0), f.height-1)
+
+	return f.pix[y*f.width+x]
+}
+
+// blur converts img to floating point and smooths it with a Gaussian of
+// standard deviation sigma.
+func blur(img *image.Gray, sigma float64) *floatImage {
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	radius := int(math.Ceil(3 * sigma))
+	kernel := make([]float32, 2*radius+1)
+	var total float32
+	for i := range kernel {
+		d := float64(i - radius)
+		kernel[i] = float32(math.Exp(-d * d / (2 * sigma * sigma)))
+		total += kernel[i]
+	}
+	for i := range kernel {
+		kernel[i] /= total
+	}
+
+	src := &floatImage{pix: make([]float32, width*height), width: width, height: height}
+	for y := range height {
+		row := img.Pix[y*img.Stride:]
+		for x := range width {
+			src.pix[y*width+x] = float32(row[x])
+		}
+	}
+
+	// Separable convolution with clamped edges
+	tmp := &floatImage{pix: make([]float32, width*height), width: width, height: height}
+	for y := range height {
+		for x := range width {
+			var sum float32
+			for k, w := range kernel {
+				sum += w * src.at(x+k-radius, y)
+			}
+			tmp.pix[y*width+x] = sum
+		}
+	}
+	for y := range height {
+		for x := range width {
+			var sum float32
+			for k, w := range kernel {
+				sum += w * tmp.at(x, y+k-radius)
+			}
+			src.pix[y*width+x] = sum
+		}
+	}
+
+	return src
+}
+
+// candidate is a possible checkerboard corner.
+type candidate struct {
+	Point
+	response float32
+}
+
+// saddlePoints returns the local maxima of the saddle response, the
+// negated determinant of the Hessian, which is large where two dark and
+// two light squares meet and small along edges and inside squares.
+func saddlePoints(f *floatImage, sigma float64) []candidate {
+	response := make([]float32, len(f.pix))
+	var peak float32
+	for y := 1; y < f.height-1; y++ {
+		for x := 1; x < f.width-1; x++ {
+			c := f.at(x, y)
+			dxx := f.at(x+1, y) - 2*c + f.at(x-1, y)
+			dyy := f.at(x, y+1) - 2*c + f.at(x, y-1)
+			dxy := (f.at(x+1, y+1) - f.at(x+1, y-1) - f.at(x-1, y+1) + f.at(x-1, y-1)) / 4
+			r := dxy*dxy - dxx*dyy
+			response[y*f.width+x] = r
+			peak = max(peak, r)
+		}
+	}
+	if peak <= 0 {
+		return nil
+	}
+
+	threshold := peak * 0.05
+	radius := max(2, int(math.Round(sigma)))
+	var candidates []candidate
+	for y := radius; y < f.height-radius; y++ {
+		for x := radius; x < f.width-radius; x++ {
+			r := response[y*f.width+x]
+			if r < threshold || !isLocalMax(response, f.width, x, y, radius) {
+				continue
+			}
+
+			// Saddle point of the local quadratic for a subpixel estimate
+			c := f.at(x, y)
+			gx := (f.at(x+1, y) - f.at(x-1, y)) / 2
+			gy := (f.at(x, y+1) - f.at(x, y-1)) / 2
+			dxx := f.at(x+1, y) - 2*c + f.at(x-1, y)
+			dyy := f.at(x, y+1) - 2*c + f.at(x, y-1)
+			dxy := (f.at(x+1, y+1) - f.at(x+1, y-1) - f.at(x-1, y+1) + f.at(x-1, y-1)) / 4
+			det := dxx*dyy - dxy*dxy
+			ox := -(dyy*gx - dxy*gy) / det
+			oy := -(dxx*gy - dxy*gx) / det
+			if math.Abs(float64(ox)) > 1 || math.Abs(float64(oy)) > 1 {
+				ox, oy = 0, 0
+			}
+
+			candidates = append(candidates, candidate{
+				Point:    Point{X: float64(x) + float64(ox), Y: float64(y) + float64(oy)},
+				response: r,
+			})
+		}
+	}
+	slices.SortFunc(candidates, func(a, b candidate) int {
+		switch {
+		case a.response > b.response:
+			return -1
+		case a.response < b.response:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	return candidates
+}
+
+func isLocalMax(response []float32, width, x, y, radius int) bool {
+	r := response[y*width+x]
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			if dx == 0 && dy == 0 {
+				continue
+			}
+			other := response[(y+dy)*width+x+dx]
+			// Break ties towards the top left so plateaus yield one maximum
+			if other > r || (other == r && (dy < 0 || (dy == 0 && dx < 0))) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// maxGridSeeds bounds how many of the strongest candidates are tried as
+// the starting corner of the grid.
+const maxGridSeeds = 20
+
+// minGridResponse is the fraction of the seed's saddle response a
+// candidate needs to join its grid.
+const minGridResponse = 0.4
+
+// recoverGrid finds the pattern's grid of corners among the candidates and
+// returns the corners in canonical order.
+func recoverGrid(candidates []candidate, pattern Pattern) ([]Point, bool) {
+	if len(candidates) < pattern.Cols*pattern.Rows {
+		return nil, false
+	}
+	for seed := range min(maxGridSeeds, len(candidates)) {
+		grid := growGrid(candidates, seed)
+		if corners, ok := grid.extract(pattern); ok {
+			return corners, true
+		}
+	}
+
+	return nil, false
+}
+
+// gridIndex is the column and row of a corner in a grid being grown.
+type gridIndex struct{ i, j int }
+
+// grid maps grid indices to candidate corners.
+type grid struct {
+	points map[gridIndex]Point
+}
+
+// growGrid grows a grid of corners outwards from a seed candidate,
+// predicting each neighbour from the spacing of the corners already found.
+func growGrid(candidates []candidate, seed int) grid {
+	g := grid{points: map[gridIndex]Point{}}
+	used := map[int]bool{seed: true}
+	origin := candidates[seed].Point
+
+	// All corners of a board respond alike, while the junctions along its
+	// outline and background clutter respond much more weakly.
+	for i, c := range candidates {
+		if c.response < minGridResponse*candidates[seed].response {
+			used[i] = true
+		}
+	}
+
+	// The nearest candidate spans the first axis, the nearest one roughly
+	// perpendicular to it the second.
+	first := nearest(candidates, origin, used, func(Point) bool { return true })
+	if first < 0 {
+		return g
+	}
+	u := sub(candidates[first].Point, origin)
+	second := nearest(candidates, origin, used, func(p Point) bool {
+		d := sub(p, origin)
+		cos := dot(d, u) / (length(d) * length(u))
+
+		return math.Abs(cos) < 0.5 && length(d) < 2*length(u)
+	})
+	if second < 0 {
+		return g
+	}
+	v := sub(candidates[second].Point, origin)
+
+	g.points[gridIndex{0, 0}] = origin
+	g.points[gridIndex{1, 0}] = candidates[first].Point
+	g.points[gridIndex{0, 1}] = candidates[second].Point
+	used[first], used[second] = true, true
+
+	queue := []gridIndex{{0, 0}, {1, 0}, {0, 1}}
+	for len(queue) > 0 {
+		at := queue[0]
+		queue = queue[1:]
+		p := g.points[at]
+		for _, step := range []gridIndex{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			next := gridIndex{at.i + step.i, at.j + step.j}
+			if _, ok := g.points[next]; ok {
+				continue
+			}
+
+			// Continue the spacing of the previous step along the same
+			// axis, which follows the perspective foreshortening.
+			delta := u
+			if step.j != 0 {
+				delta = v
+			}
+			delta = scale(delta, float64(step.i+step.j))
+			if prev, ok := g.points[gridIndex{at.i - step.i, at.j - step.j}]; ok {
+				delta = sub(p, prev)
+			} else if parallel, ok := g.parallelStep(at, step); ok {
+				delta = parallel
+			}
+			predicted := add(p, delta)
+
+			radius := 0.3 * length(delta)
+			match := nearest(candidates, predicted, used, func(q Point) bool {
+				return length(sub(q, predicted)) < radius
+			})
+			if match < 0 {
+				continue
+			}
+			used[match] = true
+			g.points[next] = candidates[match].Point
+			queue = append(queue, next)
+		}
+	}
+
+	return g
+}
+
+// parallelStep returns the step from a corner beside at to its neighbour
+// in the step's direction, when both are known.
+func (g grid) parallelStep(at, step gridIndex) (Point, bool) {
+	sides := []gridIndex{{0, 1}, {0, -1}}
+	if step.i == 0 {
+		sides = []gridIndex{{1, 0}, {-1, 0}}
+	}
+	for _, side := range sides {
+		from, ok := g.points[gridIndex{at.i + side.i, at.j + side.j}]
+		if !ok {
+			continue
+		}
+		to, ok := g.points[gridIndex{at.i + side.i + step.i, at.j + side.j + step.j}]
+		if ok {
+			return sub(to, from), true
+		}
+	}
+
+	return Point{}, false
+}
+
+// extract returns the grid's corners in canonical order when the grid is
+// exactly the pattern's size.
+func (g grid) extract(pattern Pattern) ([]Point, bool) {
+	if len(g.points) != pattern.Cols*pattern.Rows {
+		return nil, false
+	}
+	minI, minJ := math.MaxInt, math.MaxInt
+	maxI, maxJ := math.MinInt, math.MinInt
+	for idx := range g.points {
+		minI, maxI = min(minI, idx.i), max(maxI, idx.i)
+		minJ, maxJ = min(minJ, idx.j), max(maxJ, idx.j)
+	}
+	width, height := maxI-minI+1, maxJ-minJ+1
+
+	at := func(i, j int) Point { return g.points[gridIndex{minI + i, minJ + j}] }
+	// Average steps along both grid axes
+	var alongI, alongJ Point
+	for j := range height {
+		alongI = add(alongI, sub(at(width-1, j), at(0, j)))
+	}
+	for i := range width {
+		alongJ = add(alongJ, sub(at(i, height-1), at(i, 0)))
+	}
+
+	// Pick the grid axis that runs along the pattern's columns: the one
+	// with Cols corners, or the more horizontal one on square patterns.
+	transposed := false
+	switch {
+	case width == pattern.Cols && height == pattern.Rows && width != height:
+	case width == pattern.Rows && height == pattern.Cols && width != height:
+		transposed = true
+	case width == height && width == pattern.Cols:
+		transposed = math.Abs(alongI.X)/length(alongI) < math.Abs(alongJ.X)/length(alongJ)
+	default:
+		return nil, false
+	}
+	if transposed {
+		alongI, alongJ = alongJ, alongI
+	}
+
+	// Orient corners left to right and rows top to bottom
+	flipCols, flipRows := alongI.X < 0, alongJ.Y < 0
+	corners := make([]Point, 0, pattern.Cols*pattern.Rows)
+	for row := range pattern.Rows {
+		for col := range pattern.Cols {
+			c, r := col, row
+			if flipCols {
+				c = pattern.Cols - 1 - c
+			}
+			if flipRows {
+				r = pattern.Rows - 1 - r
+			}
+			if transposed {
+				corners = append(corners, at(r, c))
+			} else {
+				corners = append(corners, at(c, r))
+			}
+		}
+	}
+
+	return corners, true
+}
+
+// nearest returns the index of the unused candidate closest to p that
+// satisfies accept, or -1.
+func nearest(candidates []candidate, p Point, used map[int]bool, accept func(Point) bool) int {
+	best, bestDist := -1, math.Inf(1)
+	for i, c := range candidates {
+		if used[i] || !accept(c.Point) {
+			continue
+		}
+		if d := length(sub(c.Point, p)); d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+
+	return best
+}
+
+// refineRadius returns the half size of the refinement window of the
+// corner at index i: a third of the distance to its nearest grid
+// neighbour.
+func refineRadius(corners []Point, pattern Pattern, i int) int {
+	col, row := i%pattern.Cols, i/pattern.Cols
+	spacing := math.Inf(1)
+	for _, n := range [][2]int{{col - 1, row}, {col + 1, row}, {col, row - 1}, {col, row + 1}} {
+		if n[0] < 0 || n[0] >= pattern.Cols || n[1] < 0 || n[1] >= pattern.Rows {
+			continue
+		}
+		spacing = min(spacing, length(sub(corners[n[1]*pattern.Cols+n[0]], corners[i])))
+	}
+
+	return max(2, min(10, int(spacing/3)))
+}
+
+// refineCorner refines a corner position to subpixel accuracy.
+//
+// At an ideal corner every image gradient in its neighbourhood is
+// perpendicular to the vector from the corner to the gradient's position,
+// so the corner is the least squares solution of those constraints.
+func refineCorner(f *floatImage, p Point, radius int) Point {
+	for range 10 {
+		cx, cy := int(math.Round(p.X)), int(math.Round(p.Y))
+		var a, b, c, bx, by float64
+		for dy := -radius; dy <= radius; dy++ {
+			for dx := -radius; dx <= radius; dx++ {
+				x, y := cx+dx, cy+dy
+				gx := float64(f.at(x+1, y)-f.at(x-1, y)) / 2
+				gy := float64(f.at(x, y+1)-f.at(x, y-1)) / 2
+				// Weight the window centre more than its border
+				w := math.Exp(-float64(dx*dx+dy*dy) / float64(radius*radius))
+				gxx, gxy, gyy := w*gx*gx, w*gx*gy, w*gy*gy
+				a += gxx
+				b += gxy
+				c += gyy
+				bx += gxx*float64(x) + gxy*float64(y)
+				by += gxy*float64(x) + gyy*float64(y)
+			}
+		}
+		det := a*c - b*b
+		if det <= 1e-9 {
+			return p
+		}
+		next := Point{X: (c*bx - b*by) / det, Y: (a*by - b*bx) / det}
+		if length(sub(next, p)) > float64(radius) {
+			return p
+		}
+		moved := length(sub(next, p))
+		p = next
+		if moved < 0.01 {
+			break
+		}
+	}
+
+	return p
+}
+
+func add(a, b Point) Point { return Point{X: a.X + b.X, Y: a.Y + b.Y} }
+
+func sub(a, b Point) Point { return Point{X: a.X - b.X, Y: a.Y - b.Y} }
+
+func scale(a Point, s float64) Point { return Point{X: a.X * s, Y: a.Y * s} }
+
+func dot(a, b Point) float64 { return a.X*b.X + a.Y*b.Y }
+
+func length(a Point) float64 { return math.Hypot(a.X, a.Y) }
@@ -0,0 +1,56 @@
+package calibrate
+
+import (
+	"image"
+	"testing"
+)
+
+func TestFindCorners(t *testing.T) {
+	for i, p := range testPoses {
+		r, tr := boardPose(p)
+		img := renderBoard(testRig.left, r, tr)
+		want := projectBoard(testRig.left, r, tr)
+
+		got, ok := FindCorners(img, testPattern)
+		if !ok {
+			t.Fatalf("view %d: FindCorners() did not find the pattern", i)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("view %d: FindCorners() returned %d corners, want %d", i, len(got), len(want))
+		}
+		for k := range want {
+			if d := length(sub(got[k], want[k])); d > 0.2 {
+				t.Errorf("view %d: corner %d at %v, want %v (off by %.2f px)", i, k, got[k], want[k], d)
+			}
+		}
+	}
+}
+
+func TestFindCornersRotatedBoard(t *testing.T) {
+	// A board upside down must still be listed top to bottom, left to
+	// right in the image
+	r, tr := boardPose(pose{r: vec3{0, 0, 3.1}, t: vec3{0, 0, 0.5}})
+	img := renderBoard(testRig.left, r, tr)
+	got, ok := FindCorners(img, testPattern)
+	if !ok {
+		t.Fatal("FindCorners() did not find the pattern")
+	}
+	for k := 1; k < len(got); k++ {
+		if k%testPattern.Cols != 0 && got[k].X <= got[k-1].X {
+			t.Errorf("corner %d at %v is not right of corner %d at %v", k, got[k], k-1, got[k-1])
+		}
+	}
+	if got[0].Y >= got[len(got)-1].Y {
+		t.Errorf("first corner %v is not above last corner %v", got[0], got[len(got)-1])
+	}
+}
+
+func TestFindCornersMissingPattern(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 64, 48))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i * 7)
+	}
+	if _, ok := FindCorners(img, testPattern); ok {
+		t.Error("FindCorners() found a pattern in noise")
+	}
+}
@@ -0,0 +1,9 @@
+// Package calibrate solves the stereo calibration of a camera pair from
+// views of a checkerboard.
+//
+// Corners are detected with FindCorners, views are accumulated in a
+// Session, either live from the cameras or offline from a directory of
+// L_*.png and R_*.png pairs with Dir, and Stereo solves the intrinsics,
+// distortion and relative pose of both cameras as a despair.Calibration
+// that can be saved with despair.SaveCalibration.
+package calibrate
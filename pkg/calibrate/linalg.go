@@ -0,0 +1,351 @@
+package calibrate
+
+import (
+	"math"
+)
+
+// mat3 is a 3x3 matrix in row-major order.
+type mat3 [9]float64
+
+// vec3 is a 3-vector.
+type vec3 [3]float64
+
+var identity3 = mat3{1, 0, 0, 0, 1, 0, 0, 0, 1}
+
+func (a mat3) mul(b mat3) mat3 {
+	var out mat3
+	for i := range 3 {
+		for j := range 3 {
+			for k := range 3 {
+				out[i*3+j] += a[i*3+k] * b[k*3+j]
+			}
+		}
+	}
+
+	return out
+}
+
+func (a mat3) apply(v vec3) vec3 {
+	return vec3{
+		a[0]*v[0] + a[1]*v[1] + a[2]*v[2],
+		a[3]*v[0] + a[4]*v[1] + a[5]*v[2],
+		a[6]*v[0] + a[7]*v[1] + a[8]*v[2],
+	}
+}
+
+func (a mat3) transpose() mat3 {
+	return mat3{
+		a[0], a[3], a[6],
+		a[1], a[4], a[7],
+		a[2], a[5], a[8],
+	}
+}
+
+func (a mat3) det() float64 {
+	return a[0]*(a[4]*a[8]-a[5]*a[7]) -
+		a[1]*(a[3]*a[8]-a[5]*a[6]) +
+		a[2]*(a[3]*a[7]-a[4]*a[6])
+}
+
+// inverse returns the inverse of a, which must not be singular.
+func (a mat3) inverse() mat3 {
+	d := a.det()
+
+	return mat3{
+		(a[4]*a[8] - a[5]*a[7]) / d,
+		(a[2]*a[7] - a[1]*a[8]) / d,
+		(a[1]*a[5] - a[2]*a[4]) / d,
+		(a[5]*a[6] - a[3]*a[8]) / d,
+		(a[0]*a[8] - a[2]*a[6]) / d,
+		(a[2]*a[3] - a[0]*a[5]) / d,
+		(a[3]*a[7] - a[4]*a[6]) / d,
+		(a[1]*a[6] - a[0]*a[7]) / d,
+		(a[0]*a[4] - a[1]*a[3]) / d,
+	}
+}
+
+// nearestRotation returns the rotation closest to a, by iterating the
+// polar decomposition.
+func nearestRotation(a mat3) mat3 {
+	r := a
+	for range 20 {
+		inv := r.inverse().transpose()
+		var next mat3
+		for i := range next {
+			next[i] = (r[i] + inv[i]) / 2
+		}
+		r = next
+	}
+
+	return r
+}
+
+func (v vec3) add(w vec3) vec3 { return vec3{v[0] + w[0], v[1] + w[1], v[2] + w[2]} }
+
+func (v vec3) scale(s float64) vec3 { return vec3{v[0] * s, v[1] * s, v[2] * s} }
+
+func (v vec3) norm() float64 { return math.Sqrt(v[0]*v[0] + v[1]*v[1] + v[2]*v[2]) }
+
+func (v vec3) cross(w vec3) vec3 {
+	return vec3{
+		v[1]*w[2] - v[2]*w[1],
+		v[2]*w[0] - v[0]*w[2],
+		v[0]*w[1] - v[1]*w[0],
+	}
+}
+
+// rodrigues returns the rotation matrix of a rotation vector, whose
+// direction is the rotation axis and whose length is the angle.
+func rodrigues(v vec3) mat3 {
+	theta := v.norm()
+	if theta < 1e-12 {
+		return identity3
+	}
+	k := v.scale(1 / theta)
+	c, s := math.Cos(theta), math.Sin(theta)
+	kx := mat3{0, -k[2], k[1], k[2], 0, -k[0], -k[1], k[0], 0}
+	kx2 := kx.mul(kx)
+
+	r := identity3
+	for i := range r {
+		r[i] += s*kx[i] + (1-c)*kx2[i]
+	}
+
+	return r
+}
+
+// rotationVector returns the rotation vector of a rotation matrix.
+func rotationVector(r mat3) vec3 {
+	cos := max(-1, min(1, (r[0]+r[4]+r[8]-1)/2))
+	theta := math.Acos(cos)
+	if theta < 1e-12 {
+		return vec3{}
+	}
+	if math.Pi-theta < 1e-6 {
+		// Near a half turn the axis is the dominant column of R+I
+		axis := vec3{
+			math.Sqrt(max(0, (r[0]+1)/2)),
+			math.Sqrt(max(0, (r[4]+1)/2)),
+			math.Sqrt(max(0, (r[8]+1)/2)),
+		}
+		if r[1] < 0 {
+			axis[1] = -axis[1]
+		}
+		if r[2] < 0 {
+			axis[2] = -axis[2]
+		}
+
+		return axis.scale(theta / axis.norm())
+	}
+	axis := vec3{r[7] - r[5], r[2] - r[6], r[3] - r[1]}
+
+	return axis.scale(theta / (2 * math.Sin(theta)))
+}
+
+// smallestEigenvector returns the eigenvector of the smallest eigenvalue
+// of the symmetric n x n matrix a, computed with cyclic Jacobi rotations.
+func smallestEigenvector(a []float64, n int) []float64 {
+	a = append([]float64(nil), a...)
+	v := make([]float64, n*n)
+	for i := range n {
+		v[i*n+i] = 1
+	}
+
+	for range 100 {
+		var off float64
+		for i := range n {
+			for j := i + 1; j < n; j++ {
+				off += a[i*n+j] * a[i*n+j]
+			}
+		}
+		if off < 1e-30 {
+			break
+		}
+
+		for p := range n {
+			for q := p + 1; q < n; q++ {
+				if math.Abs(a[p*n+q]) < 1e-300 {
+					continue
+				}
+				theta := (a[q*n+q] - a[p*n+p]) / (2 * a[p*n+q])
+				t := math.Copysign(1, theta) / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+
+				for k := range n {
+					akp, akq := a[k*n+p], a[k*n+q]
+					a[k*n+p] = c*akp - s*akq
+					a[k*n+q] = s*akp + c*akq
+				}
+				for k := range n {
+					apk, aqk := a[p*n+k], a[q*n+k]
+					a[p*n+k] = c*apk - s*aqk
+					a[q*n+k] = s*apk + c*aqk
+				}
+				for k := range n {
+					vkp, vkq := v[k*n+p], v[k*n+q]
+					v[k*n+p] = c*vkp - s*vkq
+					v[k*n+q] = s*vkp + c*vkq
+				}
+			}
+		}
+	}
+
+	smallest := 0
+	for i := range n {
+		if a[i*n+i] < a[smallest*n+smallest] {
+			smallest = i
+		}
+	}
+	out := make([]float64, n)
+	for k := range n {
+		out[k] = v[k*n+smallest]
+	}
+
+	return out
+}
+
+// solveCholesky solves a*x = b for the symmetric positive definite n x n
+// matrix a. It reports false when a is not positive definite.
+func solveCholesky(a, b []float64, n int) ([]float64, bool) {
+	l := make([]float64, n*n)
+	for i := range n {
+		for j := 0; j <= i; j++ {
+			sum := a[i*n+j]
+			for k := range j {
+				sum -= l[i*n+k] * l[j*n+k]
+			}
+			if i == j {
+				if sum <= 0 {
+					return nil, false
+				}
+				l[i*n+i] = math.Sqrt(sum)
+			} else {
+				l[i*n+j] = sum / l[j*n+j]
+			}
+		}
+	}
+
+	// Forward substitution of L*y = b, then back substitution of L^T*x = y
+	x := make([]float64, n)
+	for i := range n {
+		sum := b[i]
+		for k := range i {
+			sum -= l[i*n+k] * x[k]
+		}
+		x[i] = sum / l[i*n+i]
+	}
+	for i := n - 1; i >= 0; i-- {
+		sum := x[i]
+		for k := i + 1; k < n; k++ {
+			sum -= l[k*n+i] * x[k]
+		}
+		x[i] = sum / l[i*n+i]
+	}
+
+	return x, true
+}
+
+// levenbergMarquardt minimizes the sum of squared residuals over params,
+// starting from the given values, with a forward difference Jacobian.
+// residuals writes numResiduals residuals of params into out.
+func levenbergMarquardt(
+	params []float64,
+	numResiduals int,
+	residuals func(params, out []float64),
+	maxIterations int,
+) []float64 {
+	n := len(params)
+	params = append([]float64(nil), params...)
+	current := make([]float64, numResiduals)
+	perturbed := make([]float64, numResiduals)
+	jacobian := make([]float64, numResiduals*n)
+	residuals(params, current)
+	cost := sumSquares(current)
+	lambda := 1e-3
+
+	for range maxIterations {
+		// Numeric Jacobian, one column per parameter
+		for j := range n {
+			saved := params[j]
+			step := 1e-7 * max(1, math.Abs(saved))
+			params[j] = saved + step
+			residuals(params, perturbed)
+			params[j] = saved
+			for i := range numResiduals {
+				jacobian[i*n+j] = (perturbed[i] - current[i]) / step
+			}
+		}
+
+		// Normal equations J^T*J and J^T*r
+		jtj := make([]float64, n*n)
+		jtr := make([]float64, n)
+		for i := range numResiduals {
+			row := jacobian[i*n:][:n]
+			for a, ja := range row {
+				if ja == 0 {
+					continue
+				}
+				jtr[a] += ja * current[i]
+				for b := a; b < n; b++ {
+					jtj[a*n+b] += ja * row[b]
+				}
+			}
+		}
+		for a := range n {
+			for b := range a {
+				jtj[a*n+b] = jtj[b*n+a]
+			}
+		}
+
+		improved := false
+		for !improved && lambda < 1e12 {
+			damped := append([]float64(nil), jtj...)
+			for a := range n {
+				damped[a*n+a] += lambda * max(jtj[a*n+a], 1e-12)
+			}
+			rhs := make([]float64, n)
+			for a := range n {
+				rhs[a] = -jtr[a]
+			}
+			delta, ok := solveCholesky(damped, rhs, n)
+			if !ok {
+				lambda *= 10
+
+				continue
+			}
+
+			candidate := make([]float64, n)
+			for a := range n {
+				candidate[a] = params[a] + delta[a]
+			}
+			residuals(candidate, perturbed)
+			if newCost := sumSquares(perturbed); newCost < cost {
+				improved = true
+				converged := cost-newCost < 1e-12*cost
+				params, cost = candidate, newCost
+				copy(current, perturbed)
+				lambda = max(lambda/10, 1e-12)
+				if converged {
+					return params
+				}
+			} else {
+				lambda *= 10
+			}
+		}
+		if !improved {
+			break
+		}
+	}
+
+	return params
+}
+
+func sumSquares(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v * v
+	}
+
+	return sum
+}
@@ -0,0 +1,145 @@
+package calibrate
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"slices"
+	"sync"
+
+	"github.com/conneroisu/steroscopic-hardware/pkg/despair"
+)
+
+// ErrPatternNotFound is returned when the checkerboard cannot be found in
+// an image.
+var ErrPatternNotFound = errors.New("checkerboard not found")
+
+// Session accumulates stereo views of a checkerboard until enough have
+// been captured to calibrate.
+//
+// It is safe for concurrent use.
+type Session struct {
+	mu            sync.Mutex
+	pattern       Pattern
+	width, height int
+	left, right   [][]Point
+}
+
+// NewSession creates an empty session for the given pattern.
+func NewSession(pattern Pattern) *Session {
+	return &Session{pattern: pattern}
+}
+
+// Pattern returns the pattern the session detects.
+func (s *Session) Pattern() Pattern {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.pattern
+}
+
+// Reset discards the captured views and switches to pattern.
+func (s *Session) Reset(pattern Pattern) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pattern = pattern
+	s.width, s.height = 0, 0
+	s.left, s.right = nil, nil
+}
+
+// Len returns the number of captured views.
+func (s *Session) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.left)
+}
+
+// Add detects the checkerboard in a stereo pair and records the view.
+//
+// It returns ErrPatternNotFound when the board is not fully visible in
+// both images.
+func (s *Session) Add(left, right *image.Gray) error {
+	if left.Rect.Size() != right.Rect.Size() {
+		return fmt.Errorf("stereo pair sizes differ: %v and %v", left.Rect.Size(), right.Rect.Size())
+	}
+	pattern := s.Pattern()
+	if err := pattern.Validate(); err != nil {
+		return err
+	}
+
+	leftCorners, ok := FindCorners(left, pattern)
+	if !ok {
+		return fmt.Errorf("left image: %w", ErrPatternNotFound)
+	}
+	rightCorners, ok := FindCorners(right, pattern)
+	if !ok {
+		return fmt.Errorf("right image: %w", ErrPatternNotFound)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pattern != pattern {
+		return errors.New("pattern changed while detecting corners")
+	}
+	width, height := left.Rect.Dx(), left.Rect.Dy()
+	if len(s.left) > 0 && (width != s.width || height != s.height) {
+		return fmt.Errorf("image size %dx%d does not match earlier views %dx%d",
+			width, height, s.width, s.height)
+	}
+	s.width, s.height = width, height
+	s.left = append(s.left, leftCorners)
+	s.right = append(s.right, rightCorners)
+
+	return nil
+}
+
+// Calibrate solves the stereo calibration from the captured views.
+func (s *Session) Calibrate() (Result, error) {
+	s.mu.Lock()
+	pattern, width, height := s.pattern, s.width, s.height
+	left, right := slices.Clone(s.left), slices.Clone(s.right)
+	s.mu.Unlock()
+
+	return Stereo(pattern, left, right, width, height)
+}
+
+// Dir calibrates from the stereo pairs in dir, as found by
+// despair.FindStereoPairs with the default patterns: the L_<name>.png and
+// R_<name>.png images the camera capture writes.
+//
+// Pairs in which the checkerboard is not found in both images are skipped
+// and reported through skipped, which may be nil.
+func Dir(dir string, pattern Pattern, skipped func(despair.StereoPair, error)) (Result, error) {
+	pairs, err := despair.FindStereoPairs(dir, despair.DefaultLeftPattern, despair.DefaultRightPattern)
+	if err != nil {
+		return Result{}, err
+	}
+	if len(pairs) == 0 {
+		return Result{}, fmt.Errorf("no stereo pairs found in %s", dir)
+	}
+
+	session := NewSession(pattern)
+	for _, pair := range pairs {
+		err = addPair(session, pair)
+		if err != nil && skipped != nil {
+			skipped(pair, err)
+		}
+	}
+
+	return session.Calibrate()
+}
+
+// addPair loads a stereo pair and adds it to the session.
+func addPair(session *Session, pair despair.StereoPair) error {
+	left, err := despair.LoadPNG(pair.Left)
+	if err != nil {
+		return err
+	}
+	right, err := despair.LoadPNG(pair.Right)
+	if err != nil {
+		return err
+	}
+
+	return session.Add(left, right)
+}
@@ -37,9 +37,12 @@ func (b *BaseCamera) Type() Type {
 	return b.cType
 }
 
-// Config returns a pointer to the current configuration of the camera.
-func (b *BaseCamera) Config() *Config {
-	return &b.config
+// Config returns a copy of the current configuration of the camera.
+func (b *BaseCamera) Config() Config {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.config
 }
 
 // Pause sets the camera's paused state to true, temporarily stopping streaming.
@@ -0,0 +1,128 @@
+package camera
+
+import (
+	"image"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// FrameBus is an in-process publish/subscribe bus of camera frames.
+//
+// Cameras publish frames as they capture or compute them and every
+// subscriber receives them through its own bounded queue. A subscriber
+// that falls behind loses its oldest frames rather than slowing down the
+// cameras or the other subscribers.
+//
+// It is safe for concurrent use.
+type FrameBus struct {
+	mu     sync.RWMutex
+	subs   map[*Subscription]struct{}
+	seqs   map[Type]uint64
+	latest map[Type]Capture
+}
+
+// NewFrameBus creates a bus without subscribers.
+func NewFrameBus() *FrameBus {
+	return &FrameBus{
+		subs:   map[*Subscription]struct{}{},
+		seqs:   map[Type]uint64{},
+		latest: map[Type]Capture{},
+	}
+}
+
+// Publish timestamps and numbers a frame of a camera and delivers it to
+// the subscribers of the camera's frames.
+func (b *FrameBus) Publish(typ Type, img *image.Gray) Capture {
+	b.mu.Lock()
+	b.seqs[typ]++
+	c := Capture{Camera: typ, Image: img, Time: time.Now(), Seq: b.seqs[typ]}
+	b.latest[typ] = c
+	b.mu.Unlock()
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for sub := range b.subs {
+		if sub.wants(typ) {
+			sub.deliver(c)
+		}
+	}
+
+	return c
+}
+
+// Latest returns the last frame published by a camera, if any.
+func (b *FrameBus) Latest(typ Type) (Capture, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	c, ok := b.latest[typ]
+
+	return c, ok
+}
+
+// Subscribe subscribes to the frames of the given cameras, or of all
+// cameras when none are given. At most size frames are queued; when the
+// queue is full the oldest frame is dropped.
+func (b *FrameBus) Subscribe(size int, types ...Type) *Subscription {
+	sub := &Subscription{
+		bus:   b,
+		types: types,
+		ch:    make(chan Capture, max(1, size)),
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs[sub] = struct{}{}
+
+	return sub
+}
+
+// Subscription is a subscriber's queue of frames.
+type Subscription struct {
+	bus     *FrameBus
+	types   []Type
+	ch      chan Capture
+	dropped atomic.Uint64
+	once    sync.Once
+}
+
+// C returns the channel the subscribed frames are delivered on. It is
+// closed when the subscription is closed.
+func (s *Subscription) C() <-chan Capture {
+	return s.ch
+}
+
+// Dropped returns the number of frames dropped because the queue was full.
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close unsubscribes from the bus and closes the channel.
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.bus.mu.Lock()
+		defer s.bus.mu.Unlock()
+		delete(s.bus.subs, s)
+		close(s.ch)
+	})
+}
+
+func (s *Subscription) wants(typ Type) bool {
+	return len(s.types) == 0 || slices.Contains(s.types, typ)
+}
+
+// deliver queues a frame, dropping the oldest queued frames to make room.
+func (s *Subscription) deliver(c Capture) {
+	for {
+		select {
+		case s.ch <- c:
+			return
+		default:
+		}
+		select {
+		case <-s.ch:
+			s.dropped.Add(1)
+		default:
+		}
+	}
+}
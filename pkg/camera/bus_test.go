@@ -0,0 +1,59 @@
+package camera
+
+import (
+	"image"
+	"testing"
+)
+
+func TestFrameBusDropsOldest(t *testing.T) {
+	bus := NewFrameBus()
+	sub := bus.Subscribe(2, LeftCameraType)
+	defer sub.Close()
+
+	img := image.NewGray(image.Rect(0, 0, 1, 1))
+	for range 5 {
+		bus.Publish(LeftCameraType, img)
+	}
+	bus.Publish(RightCameraType, img)
+
+	if got := sub.Dropped(); got != 3 {
+		t.Errorf("Dropped() = %d, want 3", got)
+	}
+	for _, want := range []uint64{4, 5} {
+		c := <-sub.C()
+		if c.Camera != LeftCameraType || c.Seq != want {
+			t.Errorf("got %s frame %d, want left frame %d", c.Camera, c.Seq, want)
+		}
+	}
+	select {
+	case c := <-sub.C():
+		t.Errorf("unexpected %s frame %d", c.Camera, c.Seq)
+	default:
+	}
+}
+
+func TestFrameBusLatestAndClose(t *testing.T) {
+	bus := NewFrameBus()
+	if _, ok := bus.Latest(OutputCameraType); ok {
+		t.Error("Latest() returned a frame before any was published")
+	}
+
+	all := bus.Subscribe(4)
+	img := image.NewGray(image.Rect(0, 0, 2, 2))
+	bus.Publish(OutputCameraType, img)
+	c, ok := bus.Latest(OutputCameraType)
+	if !ok || c.Image != img || c.Seq != 1 || c.Time.IsZero() {
+		t.Errorf("Latest() = %+v, %v", c, ok)
+	}
+	if got := <-all.C(); got.Seq != c.Seq || got.Camera != OutputCameraType {
+		t.Errorf("subscriber got %s frame %d", got.Camera, got.Seq)
+	}
+
+	all.Close()
+	all.Close()
+	if _, ok := <-all.C(); ok {
+		t.Error("channel of a closed subscription is open")
+	}
+	// Publishing after the subscriber left must not block or panic
+	bus.Publish(OutputCameraType, img)
+}
@@ -0,0 +1,245 @@
+package camera
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/conneroisu/steroscopic-hardware/pkg/lzma"
+	"github.com/conneroisu/steroscopic-hardware/pkg/rangecode"
+)
+
+// Compression selects how a serial camera compresses the pixels of its
+// frames on the line.
+type Compression int
+
+const (
+	// CompressionNone sends the pixels as they are.
+	CompressionNone Compression = 0
+	// CompressionLZMA sends the pixels of each frame as an LZMA stream, see
+	// the lzma package.
+	CompressionLZMA Compression = 1
+	// CompressionRange sends the pixels of each frame range coded by the
+	// capture firmware's range coder, see the rangecode package.
+	//
+	// CompressionRange is experimental: decoding searches for the coded
+	// bytes and may give up on frames coded with a large adjustment
+	// threshold, which then fail to read.
+	CompressionRange Compression = 2
+)
+
+// String returns the name of the compression.
+func (c Compression) String() string {
+	switch c {
+	case CompressionNone:
+		return "none"
+	case CompressionLZMA:
+		return "lzma"
+	case CompressionRange:
+		return "range"
+	default:
+		return fmt.Sprintf("Compression(%d)", int(c))
+	}
+}
+
+// ParseCompression parses a compression name, as returned by String.
+func ParseCompression(s string) (Compression, error) {
+	for _, c := range []Compression{CompressionNone, CompressionLZMA, CompressionRange} {
+		if s == c.String() {
+			return c, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown compression: %q", s)
+}
+
+// Validate reports whether the compression is known.
+func (c Compression) Validate() error {
+	switch c {
+	case CompressionNone, CompressionLZMA, CompressionRange:
+		return nil
+	default:
+		return fmt.Errorf("unknown compression: %d", int(c))
+	}
+}
+
+// Acknowledgements of the start sequence.
+//
+// A camera asked for a compression by the byte following the start sequence
+// answers with ackCompressed or'ed with the compression it will use. Cameras
+// that do not compress, including all legacy firmware, answer ackRaw and the
+// frames are read uncompressed.
+const (
+	ackRaw        byte = 0x01
+	ackCompressed byte = 0x80
+)
+
+// negotiatedCompression returns the compression a camera acknowledged, or
+// CompressionNone when it did not acknowledge the requested one.
+func negotiatedCompression(requested Compression, ack byte) Compression {
+	if requested == CompressionNone || ack != ackCompressed|byte(requested) {
+		return CompressionNone
+	}
+
+	return requested
+}
+
+const (
+	// compressedLengthSize is the size of the length prefixing a compressed
+	// frame of the legacy protocol.
+	compressedLengthSize = 4
+	// compressionOverhead bounds how much larger than the pixels a
+	// compressed frame may be, covering the LZMA header, the histogram of a
+	// range coded frame and incompressible data.
+	compressionOverhead = 2048
+)
+
+// maxCompressedLength returns the longest compressed payload accepted for a
+// frame of size pixel bytes.
+func maxCompressedLength(size int) int {
+	return size + size/8 + compressionOverhead
+}
+
+// readCompressed reads a compressed frame of the legacy protocol from r: a
+// little-endian uint32 length followed by that many bytes of compressed
+// data, which decompress to size bytes.
+func readCompressed(r io.Reader, c Compression, size int) ([]byte, error) {
+	var prefix [compressedLengthSize]byte
+	_, err := io.ReadFull(r, prefix[:])
+	if err != nil {
+		return nil, err
+	}
+	n := int(binary.LittleEndian.Uint32(prefix[:]))
+	if n > maxCompressedLength(size) {
+		return nil, fmt.Errorf("compressed frame of %d bytes is too long for %d bytes of pixels", n, size)
+	}
+	// The frame's bytes are limited so that a decoder reading ahead does not
+	// consume the next frame's bytes.
+	lr := io.LimitReader(r, int64(n))
+	pix, err := decompressFrom(c, lr, n, size)
+	if err != nil {
+		return nil, err
+	}
+	// Skip what the decoder left of the frame to stay aligned on the next frame
+	_, err = io.Copy(io.Discard, lr)
+	if err != nil {
+		return nil, err
+	}
+
+	return pix, nil
+}
+
+// decompress decompresses a frame's payload into size bytes of pixels.
+func decompress(c Compression, payload []byte, size int) ([]byte, error) {
+	return decompressFrom(c, bytes.NewReader(payload), len(payload), size)
+}
+
+// decompressFrom decompresses a frame's payload of n bytes read from r into
+// size bytes of pixels. LZMA frames are decoded as they are read, the others
+// are read whole first.
+func decompressFrom(c Compression, r io.Reader, n, size int) ([]byte, error) {
+	switch c {
+	case CompressionNone:
+		if n != size {
+			return nil, fmt.Errorf("frame of %d bytes, want %d", n, size)
+		}
+		pix := make([]byte, size)
+		_, err := io.ReadFull(r, pix)
+		if err != nil {
+			return nil, err
+		}
+
+		return pix, nil
+	case CompressionLZMA:
+		pix, err := decompressLZMA(r, size)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress %s frame: %w", c, err)
+		}
+
+		return pix, nil
+	case CompressionRange:
+		payload := make([]byte, n)
+		_, err := io.ReadFull(r, payload)
+		if err != nil {
+			return nil, err
+		}
+		pix := make([]byte, size)
+		err = rangecode.Decode(pix, payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress %s frame: %w", c, err)
+		}
+
+		return pix, nil
+	default:
+		return nil, c.Validate()
+	}
+}
+
+// decompressLZMA decodes size bytes of pixels from the LZMA stream r.
+func decompressLZMA(r io.Reader, size int) ([]byte, error) {
+	zr := lzma.NewReader(r)
+	defer zr.Close()
+	pix := make([]byte, size)
+	_, err := io.ReadFull(zr, pix)
+	if err != nil {
+		return nil, err
+	}
+	// Reading to the end of the stream waits for the decoder to stop
+	// reading r, so that no one else reads it meanwhile
+	extra, err := io.Copy(io.Discard, zr)
+	if err != nil {
+		return nil, err
+	}
+	if extra != 0 {
+		return nil, fmt.Errorf("stream holds %d bytes more than the %d of the frame", extra, size)
+	}
+
+	return pix, nil
+}
+
+// AppendCompressed appends the encoding of a compressed frame of the legacy
+// protocol to buf, as a camera sends it after acknowledging compression c.
+func AppendCompressed(buf []byte, c Compression, pix []byte) ([]byte, error) {
+	payload, err := Compress(c, pix)
+	if err != nil {
+		return nil, err
+	}
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(payload)))
+
+	return append(buf, payload...), nil
+}
+
+// Compress compresses the pixels of a frame, for example into the payload
+// of a framed frame.
+func Compress(c Compression, pix []byte) ([]byte, error) {
+	switch c {
+	case CompressionNone:
+		return pix, nil
+	case CompressionLZMA:
+		var buf bytes.Buffer
+		zw, err := lzma.NewWriterSize(&buf, int64(len(pix)))
+		if err != nil {
+			return nil, err
+		}
+		_, err = zw.Write(pix)
+		if err != nil {
+			return nil, err
+		}
+		err = zw.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		return buf.Bytes(), nil
+	case CompressionRange:
+		code, err := rangecode.Encode(pix, rangecode.DefaultThreshold)
+		if err != nil {
+			return nil, err
+		}
+
+		return code.AppendBinary(nil)
+	default:
+		return nil, c.Validate()
+	}
+}
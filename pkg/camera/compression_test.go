@@ -0,0 +1,107 @@
+package camera
+
+import (
+	"bytes"
+	"testing"
+)
+
+// testPixels returns size bytes of a gradient, which compresses well.
+func testPixels(size int) []byte {
+	pix := make([]byte, size)
+	for i := range pix {
+		pix[i] = byte(i / 64)
+	}
+
+	return pix
+}
+
+func TestReadCompressed(t *testing.T) {
+	pix := testPixels(128 * 96)
+	for _, c := range []Compression{CompressionLZMA, CompressionRange} {
+		t.Run(c.String(), func(t *testing.T) {
+			var line []byte
+			var err error
+			for range 2 {
+				line, err = AppendCompressed(line, c, pix)
+				if err != nil {
+					t.Fatalf("AppendCompressed() error = %v", err)
+				}
+			}
+			if len(line) >= 2*len(pix) {
+				t.Errorf("compressed frames are %d bytes, want fewer than %d", len(line), 2*len(pix))
+			}
+
+			// Both frames decode from one stream, so none of the second
+			// frame is consumed by the first.
+			r := bytes.NewReader(line)
+			for i := range 2 {
+				got, err := readCompressed(r, c, len(pix))
+				if err != nil {
+					t.Fatalf("frame %d: readCompressed() error = %v", i, err)
+				}
+				if !bytes.Equal(got, pix) {
+					t.Errorf("frame %d: decompressed pixels differ", i)
+				}
+			}
+			if r.Len() != 0 {
+				t.Errorf("%d bytes left unread", r.Len())
+			}
+		})
+	}
+}
+
+func TestReadCompressedTooLong(t *testing.T) {
+	line := []byte{0xff, 0xff, 0xff, 0x00}
+	_, err := readCompressed(bytes.NewReader(line), CompressionLZMA, 16)
+	if err == nil {
+		t.Error("readCompressed() accepted a length longer than any frame")
+	}
+}
+
+func TestNegotiatedCompression(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested Compression
+		ack       byte
+		want      Compression
+	}{
+		{"raw", CompressionNone, ackRaw, CompressionNone},
+		{"legacy firmware", CompressionLZMA, ackRaw, CompressionNone},
+		{"accepted", CompressionLZMA, ackCompressed | byte(CompressionLZMA), CompressionLZMA},
+		{"other compression", CompressionLZMA, ackCompressed | byte(CompressionRange), CompressionNone},
+		{"range coder", CompressionRange, ackCompressed | byte(CompressionRange), CompressionRange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := negotiatedCompression(tt.requested, tt.ack)
+			if got != tt.want {
+				t.Errorf("negotiatedCompression(%v, %#x) = %v, want %v", tt.requested, tt.ack, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompressedFramedFrame(t *testing.T) {
+	pix := testPixels(32 * 16)
+	payload, err := Compress(CompressionLZMA, pix)
+	if err != nil {
+		t.Fatalf("Compress() error = %v", err)
+	}
+	h := FrameHeader{Seq: 1, Width: 32, Height: 16, Format: PixelGray8, Compression: CompressionLZMA}
+	fr := NewFrameReader(bytes.NewReader(AppendFrame(nil, h, payload)))
+
+	got, data, err := fr.ReadFrame()
+	if err != nil {
+		t.Fatalf("ReadFrame() error = %v", err)
+	}
+	if got.Compression != CompressionLZMA || got.Length != len(payload) {
+		t.Errorf("ReadFrame() header = %+v, want compression %v and length %d", got, CompressionLZMA, len(payload))
+	}
+	decoded, err := decompress(got.Compression, data, got.Size())
+	if err != nil {
+		t.Fatalf("decompress() error = %v", err)
+	}
+	if !bytes.Equal(decoded, pix) {
+		t.Error("decompressed pixels differ")
+	}
+}
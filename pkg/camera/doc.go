@@ -10,10 +10,65 @@
 // The package also provides a Manager interface and default manager implementation for
 // orchestrating multiple cameras and their data channels.
 //
+// The manager timestamps the frames of the left and right cameras as they
+// arrive and a Pairer matches them by capture time. Only pairs captured
+// within the pairing tolerance reach the output camera, and the frames
+// dropped or left unpaired are counted in PairingStats.
+//
+// Frames never leave the process on their way from the cameras to the
+// consumers: the manager publishes every camera's frames on a FrameBus, and
+// the pairer, the HTTP handlers and the optional disk persistence of
+// Persist each subscribe with a bounded queue that drops the oldest frames
+// when the subscriber falls behind.
+//
+// The manager tracks the State of every camera and broadcasts its changes,
+// and output cameras broadcast the FrameTiming of every computed frame.
+//
+// Serial cameras speak one of two protocols. ProtocolLegacy reads a fixed
+// number of pixels after the start sequence is acknowledged, as existing
+// firmware does. ProtocolFramed reads self-describing frames with a header
+// and a CRC32 trailer (see FrameHeader), skipping corrupted bytes and frames
+// until the stream is back in sync.
+//
+// The frame size and PixelFormat of a serial camera are part of its Config.
+// The legacy protocol reads frames of DefaultImageWidth by
+// DefaultImageHeight gray bytes unless configured otherwise; the framed
+// protocol takes them from the frame headers and rejects frames that do not
+// match a configured size or format. Frames of 16 bit gray or of an RGGB
+// Bayer mosaic are converted to 8 bit gray, 16 bit samples scaled by the
+// configured bit depth when the sensor sends them in the least significant
+// bits.
+//
+// A serial camera configured with a Compression asks the firmware for it
+// along with the start sequence and decompresses frames as they arrive,
+// with the lzma package for CompressionLZMA and the rangecode package for
+// CompressionRange, which is experimental. Firmware that does not
+// acknowledge the compression is read uncompressed.
+//
+// Serial cameras supervise their port. Read errors make a camera
+// StateDegraded; when the port is closed under it, as when its USB adapter
+// is unplugged, or reads keep failing, the camera is StateReconnecting: it
+// finds the adapter again by the VID, PID and SerialNumber of its Config,
+// possibly under a new name, and reopens it with exponential backoff. A
+// camera that cannot reopen its port is StateFailed.
+//
+// Cameras belong to rigs. A Rig is a named Manager with its own cameras,
+// pairing and output camera parameters; the package-level functions act on
+// the DefaultRig, and CreateRig and RemoveRig add and remove other rigs at
+// runtime.
+//
+// A Simulator speaks the firmware's side of the serial protocol, streaming
+// images with optional latency, dropped and corrupted bytes, on an
+// in-memory serial port for tests or on a pseudo-terminal that ListPorts
+// lists with the serial ports.
+//
 // Example usage:
 //
 //	leftCam := camera.NewStaticCamera(ctx, "./testdata/L_00001.png", camera.LeftCameraType)
-//	rightCam := camera.NewSerialCamera(ctx, camera.RightCameraType, "/dev/ttyUSB0", 115200, 0)
+//	rightCam, err := camera.NewSerialCamera(ctx, camera.RightCameraType, camera.Config{
+//		Port:     "/dev/ttyUSB0",
+//		BaudRate: 115200,
+//	})
 //	outputCam := camera.NewOutputCamera(ctx)
 //
 //	camera.SetCamera(ctx, camera.LeftCameraType, leftCam)
@@ -0,0 +1,100 @@
+package camera
+
+import (
+	"sync"
+	"time"
+)
+
+// State is the state of a camera of a manager.
+type State string
+
+const (
+	// StateStarting is the state of a camera that was set but has not
+	// produced a frame yet.
+	StateStarting State = "starting"
+	// StateStreaming is the state of a camera that produces frames.
+	StateStreaming State = "streaming"
+	// StateStopped is the state of a camera that was closed.
+	StateStopped State = "stopped"
+	// StateConnected is the state of a serial camera whose port is open and
+	// acknowledged the start sequence, before its first frame.
+	StateConnected State = "connected"
+	// StateDegraded is the state of a serial camera whose port is open but
+	// fails to deliver frames.
+	StateDegraded State = "degraded"
+	// StateReconnecting is the state of a serial camera whose port was lost
+	// and is being searched for and reopened.
+	StateReconnecting State = "reconnecting"
+	// StateFailed is the state of a serial camera that gave up reconnecting.
+	StateFailed State = "failed"
+)
+
+// StateChange is a change of the state of a camera.
+type StateChange struct {
+	Camera Type      `json:"camera"`
+	State  State     `json:"state"`
+	Time   time.Time `json:"time"`
+}
+
+// FrameTiming is the time an output camera took to compute a frame.
+type FrameTiming struct {
+	// Time is when the frame was computed.
+	Time time.Time `json:"time"`
+	// Rectify is the time spent rectifying the stereo pair.
+	Rectify time.Duration `json:"rectify"`
+	// Match is the time spent computing the disparity.
+	Match time.Duration `json:"match"`
+	// Total is the time from taking the stereo pair to the finished frame.
+	Total time.Duration `json:"total"`
+	// Skew is the capture time difference of the stereo pair.
+	Skew time.Duration `json:"skew"`
+}
+
+// broadcaster fans events out to subscribers with bounded, drop-oldest
+// queues.
+type broadcaster[T any] struct {
+	mu   sync.RWMutex
+	subs map[chan T]struct{}
+}
+
+// subscribe registers a subscriber with a queue of size events and returns
+// its channel and a function that ends the subscription.
+func (b *broadcaster[T]) subscribe(size int) (<-chan T, func()) {
+	ch := make(chan T, max(1, size))
+	b.mu.Lock()
+	if b.subs == nil {
+		b.subs = map[chan T]struct{}{}
+	}
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+	var once sync.Once
+
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			delete(b.subs, ch)
+			close(ch)
+		})
+	}
+}
+
+// publish delivers an event to every subscriber, dropping the oldest queued
+// events of subscribers that fell behind.
+func (b *broadcaster[T]) publish(event T) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for ch := range b.subs {
+		for sent := false; !sent; {
+			select {
+			case ch <- event:
+				sent = true
+			default:
+				select {
+				case <-ch:
+				default:
+				}
+			}
+		}
+	}
+}
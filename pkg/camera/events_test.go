@@ -0,0 +1,21 @@
+package camera
+
+import "testing"
+
+func TestBroadcasterDropsOldest(t *testing.T) {
+	var b broadcaster[int]
+	ch, stop := b.subscribe(2)
+	for i := range 4 {
+		b.publish(i)
+	}
+	if got := []int{<-ch, <-ch}; got[0] != 2 || got[1] != 3 {
+		t.Errorf("received %v, want [2 3]", got)
+	}
+
+	stop()
+	stop()
+	if _, ok := <-ch; ok {
+		t.Error("channel of an ended subscription is open")
+	}
+	b.publish(4)
+}
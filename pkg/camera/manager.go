@@ -3,7 +3,9 @@ package camera
 import (
 	"context"
 	"fmt"
+	"maps"
 	"sync"
+	"time"
 )
 
 // Manager handles all cameras and their associated channels. It provides methods to
@@ -15,20 +17,71 @@ type Manager interface {
 	SetCamera(ctx context.Context, typ Type, cam Camera) error
 	// CloseAll closes all cameras and releases their resources.
 	CloseAll() error
+	// LatestPair returns the most recent synchronized stereo pair, if any.
+	LatestPair() (StereoPair, bool)
+	// PairStats returns the stereo pairing counters.
+	PairStats() PairingStats
+	// SetPairTolerance sets the maximum capture time difference of the
+	// frames of a stereo pair.
+	SetPairTolerance(tolerance time.Duration)
+	// Bus returns the bus the frames of all cameras are published on.
+	Bus() *FrameBus
+	// States returns the state of every camera that was set.
+	States() map[Type]State
+	// SubscribeStates returns a channel the state changes of the cameras
+	// are sent on and a function that ends the subscription.
+	SubscribeStates(size int) (<-chan StateChange, func())
+}
+
+// pairConsumer is implemented by cameras that compute from synchronized
+// stereo pairs rather than capturing frames.
+type pairConsumer interface {
+	setPairs(pairs <-chan StereoPair)
+}
+
+// stateReporter is implemented by cameras that report states of their own,
+// such as serial cameras supervising their port.
+type stateReporter interface {
+	setStateFunc(report func(State))
 }
 
 // manager implements the Manager interface for camera management.
 type manager struct {
-	cameras map[Type]Camera // Map of camera type to camera instance
-	mu      sync.RWMutex    // Mutex for concurrent access
+	cameras map[Type]Camera             // Map of camera type to camera instance
+	stops   map[Type]context.CancelFunc // Stops collecting the frames of each camera
+	bus     *FrameBus                   // Bus the frames of all cameras are published on
+	pairer  *Pairer                     // Pairs the frames of the input cameras
+	frames  *Subscription               // Input frames the pairer is fed
+	mu      sync.RWMutex                // Mutex for concurrent access
+
+	states   map[Type]State           // State of each camera
+	statesMu sync.Mutex               // Guards states
+	changes  broadcaster[StateChange] // State change subscribers
 }
 
 // NewManager creates a new camera manager instance with initialized channels.
 func NewManager() Manager {
+	return newManager()
+}
+
+// newManager creates a camera manager whose pairing close ends.
+func newManager() *manager {
 	m := &manager{
 		cameras: make(map[Type]Camera),
+		stops:   make(map[Type]context.CancelFunc),
+		bus:     NewFrameBus(),
+		pairer:  NewPairer(DefaultPairTolerance),
+		states:  make(map[Type]State),
 	}
 
+	// The pairer is a subscriber of the input cameras' frames
+	m.frames = m.bus.Subscribe(maxPendingFrames, LeftCameraType, RightCameraType)
+	go func() {
+		for c := range m.frames.C() {
+			m.pairer.Push(c)
+		}
+	}()
+
 	return m
 }
 
@@ -69,9 +122,29 @@ func (m *manager) SetCamera(ctx context.Context, typ Type, cam Camera) error {
 		}
 	}
 
-	// Store and start new camera
+	// Stop collecting the frames of the old camera
+	if stop, ok := m.stops[typ]; ok {
+		stop()
+		delete(m.stops, typ)
+	}
+
+	// Store and start new camera. Its frames are published on the bus, and
+	// the output camera receives the pairs of the input cameras' frames.
 	m.cameras[typ] = cam
-	go cam.Stream(ctx, nil)
+	frames := make(ImageChannel, 1)
+	collectCtx, stop := context.WithCancel(ctx)
+	m.stops[typ] = stop
+	m.setState(typ, StateStarting)
+	go m.collect(collectCtx, typ, frames)
+	if consumer, ok := cam.(pairConsumer); ok {
+		consumer.setPairs(m.pairer.Pairs())
+	}
+	if reporter, ok := cam.(stateReporter); ok {
+		reporter.setStateFunc(func(state State) {
+			m.setStateIf(collectCtx, typ, state)
+		})
+	}
+	go cam.Stream(ctx, frames)
 
 	// Resume other cameras
 	for t, c := range m.cameras {
@@ -93,11 +166,93 @@ func (m *manager) CloseAll() error {
 			return fmt.Errorf("failed to close %s camera: %w", typ, err)
 		}
 		delete(m.cameras, typ)
+		if stop, ok := m.stops[typ]; ok {
+			stop()
+			delete(m.stops, typ)
+		}
+		m.setState(typ, StateStopped)
 	}
 
 	return nil
 }
 
+// close closes all cameras and stops pairing their frames. The manager is
+// not used afterwards.
+func (m *manager) close() error {
+	err := m.CloseAll()
+	m.frames.Close()
+
+	return err
+}
+
+// collect publishes the frames a camera sends on the bus as they arrive.
+// A camera that sends a frame is streaming, whatever state it reported.
+func (m *manager) collect(ctx context.Context, typ Type, frames ImageChannel) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case img := <-frames:
+			m.bus.Publish(typ, img)
+			m.setStateIf(ctx, typ, StateStreaming)
+		}
+	}
+}
+
+// setState records and broadcasts the state of a camera.
+func (m *manager) setState(typ Type, state State) {
+	m.setStateIf(context.Background(), typ, state)
+}
+
+// setStateIf records and broadcasts the state of a camera unless ctx, the
+// context of the camera's collection, is done because the camera was
+// replaced or closed.
+func (m *manager) setStateIf(ctx context.Context, typ Type, state State) {
+	m.statesMu.Lock()
+	defer m.statesMu.Unlock()
+	if ctx.Err() != nil || m.states[typ] == state {
+		return
+	}
+	m.states[typ] = state
+	m.changes.publish(StateChange{Camera: typ, State: state, Time: time.Now()})
+}
+
+// States returns the state of every camera that was set.
+func (m *manager) States() map[Type]State {
+	m.statesMu.Lock()
+	defer m.statesMu.Unlock()
+
+	return maps.Clone(m.states)
+}
+
+// SubscribeStates returns a channel the state changes of the cameras are
+// sent on and a function that ends the subscription. At most size changes
+// are queued; the oldest are dropped when the queue is full.
+func (m *manager) SubscribeStates(size int) (<-chan StateChange, func()) {
+	return m.changes.subscribe(size)
+}
+
+// Bus returns the bus the frames of all cameras are published on.
+func (m *manager) Bus() *FrameBus {
+	return m.bus
+}
+
+// LatestPair returns the most recent synchronized stereo pair, if any.
+func (m *manager) LatestPair() (StereoPair, bool) {
+	return m.pairer.Latest()
+}
+
+// PairStats returns the stereo pairing counters.
+func (m *manager) PairStats() PairingStats {
+	return m.pairer.Stats()
+}
+
+// SetPairTolerance sets the maximum capture time difference of the frames
+// of a stereo pair.
+func (m *manager) SetPairTolerance(tolerance time.Duration) {
+	m.pairer.SetTolerance(tolerance)
+}
+
 // Global manager instance for default usage.
 var defaultManager = NewManager()
 
@@ -115,3 +270,30 @@ func SetCamera(ctx context.Context, typ Type, cam Camera) error {
 func CloseAll() error {
 	return defaultManager.CloseAll()
 }
+
+// Bus returns the frame bus of the default manager.
+func Bus() *FrameBus {
+	return defaultManager.Bus()
+}
+
+// States returns the state of every camera of the default manager.
+func States() map[Type]State {
+	return defaultManager.States()
+}
+
+// SubscribeStates subscribes to the camera state changes of the default
+// manager.
+func SubscribeStates(size int) (<-chan StateChange, func()) {
+	return defaultManager.SubscribeStates(size)
+}
+
+// PairStats returns the stereo pairing counters of the default manager.
+func PairStats() PairingStats {
+	return defaultManager.PairStats()
+}
+
+// SetPairTolerance sets the stereo pairing tolerance of the default
+// manager.
+func SetPairTolerance(tolerance time.Duration) {
+	defaultManager.SetPairTolerance(tolerance)
+}
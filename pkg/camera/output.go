@@ -1,12 +1,14 @@
 package camera
 
 import (
-	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"image"
-	"image/color"
-	"image/png"
+	"io/fs"
 	"log/slog"
+	"path/filepath"
+	"sync/atomic"
 	"time"
 
 	"github.com/conneroisu/steroscopic-hardware/pkg/despair"
@@ -16,6 +18,10 @@ import (
 // DefaultNumWorkers is the default number of worker goroutines for disparity calculations.
 const DefaultNumWorkers = 32
 
+// CalibrationFile is the name of the stereo calibration file in the home
+// directory that output cameras rectify frames with.
+const CalibrationFile = "calibration.json"
+
 // OutputCamera processes left and right camera images to generate a depth map using
 // a concurrent sum of absolute differences (SAD) algorithm. It is used for stereo vision output.
 type OutputCamera struct {
@@ -23,15 +29,56 @@ type OutputCamera struct {
 	inputCh  chan<- despair.InputChunk  // Channel for input image chunks
 	outputCh <-chan despair.OutputChunk // Channel for processed output chunks
 	logger   *slog.Logger               // Logger for output camera events
+
+	params    atomic.Pointer[despair.Parameters] // Parameters of the next frame
+	rectifier atomic.Pointer[despair.Rectifier]  // Rectification of the next frame, if any
+	lastFrame atomic.Pointer[Frame]              // Last computed frame, if any
+	pairs     <-chan StereoPair                  // Synchronized stereo pairs to compute
+	timings   broadcaster[FrameTiming]           // Frame timing subscribers
+}
+
+// Frame is a disparity frame computed by an output camera.
+type Frame struct {
+	// Left is the left image the disparity was computed from, rectified
+	// when the camera has a rectifier.
+	Left *image.Gray
+	// Result is the computed disparity.
+	Result despair.Result
+	// Rectifier is the rectifier the frame was rectified with, or nil.
+	Rectifier *despair.Rectifier
+}
+
+// PointCloud triangulates the frame's disparity into a point cloud, using
+// the geometry of the rectified cameras. With intensity set, the points are
+// colored with the left image.
+func (f *Frame) PointCloud(intensity bool) (*despair.PointCloud, error) {
+	if f.Rectifier == nil {
+		return nil, errors.New("frame is not rectified, a calibration is required for metric depth")
+	}
+	var left *image.Gray
+	if intensity {
+		left = f.Left
+	}
+
+	return despair.NewPointCloud(f.Result.Raw, left, f.Rectifier.Intrinsics(), f.Rectifier.Baseline())
 }
 
 // NewOutputCamera creates a new output camera for disparity mapping. It initializes
-// the disparity processing pipeline and returns the OutputCamera instance.
+// the disparity processing pipeline with a copy of the default parameters and
+// returns the OutputCamera instance.
 func NewOutputCamera(ctx context.Context) *OutputCamera {
 	oc := &OutputCamera{
 		BaseCamera: NewBaseCamera(ctx, OutputCameraType),
 		logger:     slog.Default().WithGroup("output-camera"),
 	}
+	oc.SetParams(*despair.DefaultParams())
+
+	// Rectify frames with the stored calibration, if there is one
+	rectifier, err := LoadRectifier()
+	if err != nil {
+		oc.logger.Error("failed to load calibration", "err", err)
+	}
+	oc.SetRectifier(rectifier)
 
 	// Initialize the disparity processing pipeline
 	oc.inputCh, oc.outputCh = despair.SetupConcurrentSAD(DefaultNumWorkers)
@@ -69,7 +116,7 @@ func (oc *OutputCamera) Stream(ctx context.Context, outCh ImageChannel) {
 			}
 
 			// Generate depth map from input channels
-			img, err := oc.processDepthMap()
+			img, err := oc.processDepthMap(ctx)
 			if err != nil {
 				oc.logger.Error("error processing depth map", "err", err)
 				time.Sleep(100 * time.Millisecond)
@@ -84,14 +131,6 @@ func (oc *OutputCamera) Stream(ctx context.Context, outCh ImageChannel) {
 				continue
 			}
 
-			// save to $HOME/output.png
-			err = homedir.SaveImage("output.png", img)
-			if err != nil {
-				oc.logger.Error("error saving image", "err", err)
-				time.Sleep(100 * time.Millisecond)
-
-				continue
-			}
 			// Send processed image to output channel
 			select {
 			case outCh <- img:
@@ -124,49 +163,51 @@ func (oc *OutputCamera) Stream(ctx context.Context, outCh ImageChannel) {
 	}
 }
 
-// processDepthMap generates a depth map from left and right camera images. It divides the
-// images into chunks for parallel processing and assembles the resulting disparity map.
-func (oc *OutputCamera) processDepthMap() (*image.Gray, error) {
-	// Try to receive images from both channels
-	var leftImg, rightImg *image.Gray
-
-	// Get Image fron $HOME/left.png
-	leftImgBytes, err := homedir.ReadFile("left.png")
-	if err != nil {
-		return nil, err
+// pairWait bounds how long the output camera waits for a stereo pair
+// before checking whether it was paused or closed.
+const pairWait = time.Second
+
+// processDepthMap generates a depth map from the next synchronized stereo
+// pair. It divides the images into chunks for parallel processing and
+// assembles the resulting disparity map. It returns nil when no pair
+// arrived in time.
+func (oc *OutputCamera) processDepthMap(ctx context.Context) (*image.Gray, error) {
+	if oc.pairs == nil {
+		return nil, errors.New("output camera is not attached to a camera manager")
 	}
-	leftImgFull, err := png.Decode(bytes.NewReader(leftImgBytes))
-	if err != nil {
-		return nil, err
-	}
-	leftImg = image.NewGray(leftImgFull.Bounds())
-	for x := leftImgFull.Bounds().Min.X; x < leftImgFull.Bounds().Max.X; x++ {
-		for y := leftImgFull.Bounds().Min.Y; y < leftImgFull.Bounds().Max.Y; y++ {
-			leftImg.Set(x, y, color.GrayModel.Convert(leftImgFull.At(x, y)))
-		}
-	}
-	// Get Image fron $HOME/right.png
-	rightImgBytes, err := homedir.ReadFile("right.png")
-	if err != nil {
-		return nil, err
-	}
-	rightImgFull, err := png.Decode(bytes.NewReader(rightImgBytes))
-	if err != nil {
-		return nil, err
-	}
-	rightImg = image.NewGray(rightImgFull.Bounds())
-	for x := rightImgFull.Bounds().Min.X; x < rightImgFull.Bounds().Max.X; x++ {
-		for y := rightImgFull.Bounds().Min.Y; y < rightImgFull.Bounds().Max.Y; y++ {
-			rightImg.Set(x, y, color.GrayModel.Convert(rightImgFull.At(x, y)))
-		}
+	var pair StereoPair
+	select {
+	case pair = <-oc.pairs:
+	case <-ctx.Done():
+		return nil, nil
+	case <-oc.Context().Done():
+		return nil, nil
+	case <-time.After(pairWait):
+		return nil, nil
 	}
+	leftImg, rightImg := pair.Left.Image, pair.Right.Image
+	var err error
 
 	// Process images if both are available
 	if leftImg != nil && rightImg != nil {
+		if leftImg.Rect.Size() != rightImg.Rect.Size() {
+			return nil, fmt.Errorf("stereo pair sizes differ: %v and %v",
+				leftImg.Rect.Size(), rightImg.Rect.Size())
+		}
 		startTime := time.Now()
 
-		// Get current parameters
-		params := despair.DefaultParams()
+		// Rectify the pair so matching can search along image rows
+		rectifier := oc.rectifier.Load()
+		if rectifier != nil {
+			leftImg, rightImg, err = rectifier.Rectify(leftImg, rightImg)
+			if err != nil {
+				return nil, fmt.Errorf("failed to rectify images: %w", err)
+			}
+		}
+		rectifyTime := time.Since(startTime)
+
+		// Snapshot the parameters so the whole frame uses the same settings
+		params := oc.Params()
 
 		// Divide image into chunks for parallel processing
 		chunkSize := max(1, leftImg.Rect.Dy()/(DefaultNumWorkers*4))
@@ -183,25 +224,39 @@ func (oc *OutputCamera) processDepthMap() (*image.Gray, error) {
 					leftImg.Rect.Max.X,
 					min(y+chunkSize, leftImg.Rect.Max.Y),
 				),
+				Params: &params,
 			}
 		}
 
 		// Assemble the resulting disparity map
-		disparityMap := despair.AssembleDisparityMap(oc.outputCh, leftImg.Rect, numChunks)
+		result := despair.Assemble(oc.outputCh, leftImg.Rect, numChunks)
+		disparityMap := result.Disparity
 
-		// Save to $HOME/output.png
-		err := homedir.SaveImage("output.png", disparityMap)
-		if err != nil {
-			slog.Error("could not save output image", "err", err)
-
-			return nil, err
-		}
+		oc.lastFrame.Store(&Frame{
+			Left:      leftImg,
+			Result:    result,
+			Rectifier: rectifier,
+		})
 
 		elapsedTime := time.Since(startTime)
+		oc.timings.publish(FrameTiming{
+			Time:    time.Now(),
+			Rectify: rectifyTime,
+			Match:   elapsedTime - rectifyTime,
+			Total:   elapsedTime,
+			Skew:    pair.Skew(),
+		})
 		oc.logger.Info("depth map generated",
 			"elapsed", elapsedTime,
 			"blockSize", params.BlockSize,
-			"maxDisparity", params.MaxDisparity)
+			"maxDisparity", params.MaxDisparity,
+			"lrCheck", params.LRCheck,
+			"subpixel", params.Subpixel,
+			"cost", params.Cost,
+			"algorithm", params.Algorithm,
+			"skew", pair.Skew(),
+			"leftSeq", pair.Left.Seq,
+			"rightSeq", pair.Right.Seq)
 
 		return disparityMap, nil
 	}
@@ -209,6 +264,102 @@ func (oc *OutputCamera) processDepthMap() (*image.Gray, error) {
 	return nil, nil
 }
 
+// LatestPair returns the most recent synchronized stereo pair of the
+// default manager's input cameras.
+func LatestPair() (*image.Gray, *image.Gray, error) {
+	pair, ok := defaultManager.LatestPair()
+	if !ok {
+		return nil, nil, errors.New("no synchronized stereo pair captured yet")
+	}
+
+	return pair.Left.Image, pair.Right.Image, nil
+}
+
+// setPairs sets the channel the output camera receives stereo pairs from.
+func (oc *OutputCamera) setPairs(pairs <-chan StereoPair) {
+	oc.pairs = pairs
+}
+
+// LastFrame returns the last frame the output camera computed, or nil
+// before the first frame.
+func (oc *OutputCamera) LastFrame() *Frame {
+	return oc.lastFrame.Load()
+}
+
+// SubscribeTimings returns a channel the timings of the frames the output
+// camera computes are sent on and a function that ends the subscription. At
+// most size timings are queued; the oldest are dropped when the queue is
+// full.
+func (oc *OutputCamera) SubscribeTimings(size int) (<-chan FrameTiming, func()) {
+	return oc.timings.subscribe(size)
+}
+
+// Params returns the parameters the output camera computes frames with.
+func (oc *OutputCamera) Params() despair.Parameters {
+	return *oc.params.Load()
+}
+
+// SetParams sets the parameters of the output camera. Frames already being
+// processed keep their parameters, the change applies from the next frame.
+func (oc *OutputCamera) SetParams(params despair.Parameters) {
+	oc.params.Store(&params)
+}
+
+// Rectifier returns the rectifier applied to frames before matching, or nil
+// when frames are matched as captured.
+func (oc *OutputCamera) Rectifier() *despair.Rectifier {
+	return oc.rectifier.Load()
+}
+
+// SetRectifier sets the rectifier applied to frames before matching. A nil
+// rectifier matches frames as captured. The change applies from the next
+// frame.
+func (oc *OutputCamera) SetRectifier(rectifier *despair.Rectifier) {
+	oc.rectifier.Store(rectifier)
+}
+
+// LoadRectifier loads the calibration stored in the home directory and
+// precomputes its rectification. It returns nil without an error when no
+// calibration is stored.
+func LoadRectifier() (*despair.Rectifier, error) {
+	dir, err := homedir.Dir()
+	if err != nil {
+		return nil, err
+	}
+	cal, err := despair.LoadCalibration(filepath.Join(dir, CalibrationFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return despair.NewRectifier(cal)
+}
+
+// ApplyCalibration stores a calibration in the home directory, where
+// LoadRectifier finds it on the next start, and rectifies the frames of the
+// current output camera with it.
+func ApplyCalibration(cal despair.Calibration) error {
+	rectifier, err := despair.NewRectifier(cal)
+	if err != nil {
+		return err
+	}
+	dir, err := homedir.Dir()
+	if err != nil {
+		return err
+	}
+	err = despair.SaveCalibration(filepath.Join(dir, CalibrationFile), cal)
+	if err != nil {
+		return err
+	}
+	if output, ok := GetCamera(OutputCameraType).(*OutputCamera); ok {
+		output.SetRectifier(rectifier)
+	}
+
+	return nil
+}
+
 // Close releases all resources used by the output camera and stops the processing pipeline.
 func (oc *OutputCamera) Close() error {
 	oc.logger.Info("closing output camera")
@@ -0,0 +1,182 @@
+package camera
+
+import (
+	"image"
+	"sync"
+	"time"
+)
+
+// DefaultPairTolerance is the default maximum difference between the
+// capture times of the left and right frames of a stereo pair.
+const DefaultPairTolerance = 100 * time.Millisecond
+
+// maxPendingFrames bounds the frames of one camera waiting for a frame of
+// the other camera to be paired with.
+const maxPendingFrames = 4
+
+// Capture is a frame captured by an input camera.
+type Capture struct {
+	Camera Type
+	Image  *image.Gray
+	// Time is the capture time of the frame.
+	Time time.Time
+	// Seq numbers the frames of each camera from 1.
+	Seq uint64
+}
+
+// StereoPair is a left and a right frame captured at about the same time.
+type StereoPair struct {
+	Left, Right Capture
+}
+
+// Skew returns the difference between the capture times of the frames.
+func (p StereoPair) Skew() time.Duration {
+	return p.Left.Time.Sub(p.Right.Time).Abs()
+}
+
+// PairingStats counts the outcomes of stereo pairing.
+type PairingStats struct {
+	// Pairs is the number of stereo pairs formed.
+	Pairs uint64 `json:"pairs"`
+	// Dropped is the number of frames discarded unpaired because a newer
+	// frame of the same camera superseded them.
+	Dropped uint64 `json:"dropped"`
+	// Mismatched is the number of frames discarded because the other camera
+	// captured no frame within the tolerance of them.
+	Mismatched uint64 `json:"mismatched"`
+	// Stale is the number of pairs replaced by a newer pair before the
+	// output camera took them.
+	Stale uint64 `json:"stale"`
+	// LastSkew is the capture time difference of the last pair.
+	LastSkew time.Duration `json:"lastSkew"`
+}
+
+// Pairer matches the frames of the left and right cameras by capture time
+// and hands out the pairs whose capture times are within a tolerance.
+//
+// Frames of each camera must be pushed in capture order. Only the newest
+// pair is kept for the consumer, so a slow consumer always computes the
+// most recent coherent pair rather than falling behind.
+//
+// It is safe for concurrent use.
+type Pairer struct {
+	mu        sync.Mutex
+	tolerance time.Duration
+	pending   map[Type][]Capture
+	latest    *StereoPair
+	stats     PairingStats
+	out       chan StereoPair
+}
+
+// NewPairer creates a pairer that pairs frames captured at most tolerance
+// apart.
+func NewPairer(tolerance time.Duration) *Pairer {
+	return &Pairer{
+		tolerance: tolerance,
+		pending:   map[Type][]Capture{},
+		out:       make(chan StereoPair, 1),
+	}
+}
+
+// Pairs returns the channel stereo pairs are delivered on.
+func (p *Pairer) Pairs() <-chan StereoPair {
+	return p.out
+}
+
+// Latest returns the most recent stereo pair, if any.
+func (p *Pairer) Latest() (StereoPair, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.latest == nil {
+		return StereoPair{}, false
+	}
+
+	return *p.latest, true
+}
+
+// Stats returns the pairing counters.
+func (p *Pairer) Stats() PairingStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.stats
+}
+
+// Tolerance returns the maximum capture time difference of a pair.
+func (p *Pairer) Tolerance() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.tolerance
+}
+
+// SetTolerance sets the maximum capture time difference of a pair.
+func (p *Pairer) SetTolerance(tolerance time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.tolerance = tolerance
+}
+
+// Push adds a frame of the left or right camera, pairing it with the
+// closest pending frame of the other camera when one is within the
+// tolerance.
+func (p *Pairer) Push(c Capture) {
+	other := LeftCameraType
+	if c.Camera == LeftCameraType {
+		other = RightCameraType
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Frames of the other camera captured too long before this one can not
+	// be paired with it or any later frame.
+	candidates := p.pending[other]
+	expired := 0
+	for expired < len(candidates) && c.Time.Sub(candidates[expired].Time) > p.tolerance {
+		expired++
+	}
+	p.stats.Mismatched += uint64(expired)
+	candidates = candidates[expired:]
+
+	best := -1
+	for i, candidate := range candidates {
+		skew := c.Time.Sub(candidate.Time).Abs()
+		if skew <= p.tolerance && (best < 0 || skew < c.Time.Sub(candidates[best].Time).Abs()) {
+			best = i
+		}
+	}
+	if best < 0 {
+		p.pending[other] = candidates
+		own := append(p.pending[c.Camera], c)
+		if len(own) > maxPendingFrames {
+			p.stats.Dropped += uint64(len(own) - maxPendingFrames)
+			own = own[len(own)-maxPendingFrames:]
+		}
+		p.pending[c.Camera] = own
+
+		return
+	}
+
+	// Pairs keep capture order, so older frames of both cameras can no
+	// longer be paired.
+	p.stats.Dropped += uint64(best + len(p.pending[c.Camera]))
+	p.pending[other] = candidates[best+1:]
+	p.pending[c.Camera] = nil
+
+	pair := StereoPair{Left: c, Right: candidates[best]}
+	if c.Camera == RightCameraType {
+		pair.Left, pair.Right = pair.Right, pair.Left
+	}
+	p.stats.Pairs++
+	p.stats.LastSkew = pair.Skew()
+	p.latest = &pair
+
+	// Replace a pair the consumer has not taken yet
+	select {
+	case <-p.out:
+		p.stats.Stale++
+	default:
+	}
+	p.out <- pair
+}
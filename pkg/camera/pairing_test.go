@@ -0,0 +1,65 @@
+package camera
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPairerMatchesByCaptureTime(t *testing.T) {
+	start := time.Unix(0, 0)
+	at := func(typ Type, ms int) Capture {
+		return Capture{Camera: typ, Time: start.Add(time.Duration(ms) * time.Millisecond), Seq: uint64(ms)}
+	}
+	p := NewPairer(10 * time.Millisecond)
+
+	// The right frame at 30ms pairs with the closest left frame, the one at
+	// 25ms, dropping the older left frame at 20ms.
+	p.Push(at(LeftCameraType, 20))
+	p.Push(at(LeftCameraType, 25))
+	p.Push(at(RightCameraType, 30))
+	pair := <-p.Pairs()
+	if pair.Left.Seq != 25 || pair.Right.Seq != 30 || pair.Skew() != 5*time.Millisecond {
+		t.Errorf("pair = %d/%d with skew %v, want 25/30 with skew 5ms", pair.Left.Seq, pair.Right.Seq, pair.Skew())
+	}
+
+	// A right frame 50ms before the next left frame is never paired.
+	p.Push(at(RightCameraType, 40))
+	p.Push(at(LeftCameraType, 90))
+	p.Push(at(RightCameraType, 95))
+	pair = <-p.Pairs()
+	if pair.Left.Seq != 90 || pair.Right.Seq != 95 {
+		t.Errorf("pair = %d/%d, want 90/95", pair.Left.Seq, pair.Right.Seq)
+	}
+
+	// An untaken pair is replaced by the newer one.
+	p.Push(at(LeftCameraType, 100))
+	p.Push(at(RightCameraType, 101))
+	p.Push(at(RightCameraType, 110))
+	p.Push(at(LeftCameraType, 112))
+	pair = <-p.Pairs()
+	if pair.Left.Seq != 112 || pair.Right.Seq != 110 {
+		t.Errorf("pair = %d/%d, want 112/110", pair.Left.Seq, pair.Right.Seq)
+	}
+	if latest, ok := p.Latest(); !ok || latest != pair {
+		t.Errorf("Latest() = %+v, %v, want the last pair", latest, ok)
+	}
+
+	want := PairingStats{Pairs: 4, Dropped: 1, Mismatched: 1, Stale: 1, LastSkew: 2 * time.Millisecond}
+	if got := p.Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestPairerBoundsPendingFrames(t *testing.T) {
+	p := NewPairer(time.Millisecond)
+	start := time.Unix(0, 0)
+	for i := range maxPendingFrames + 3 {
+		p.Push(Capture{Camera: LeftCameraType, Time: start.Add(time.Duration(i) * time.Second)})
+	}
+	if got := p.Stats().Dropped; got != 3 {
+		t.Errorf("Dropped = %d, want 3", got)
+	}
+	if _, ok := p.Latest(); ok {
+		t.Error("Latest() returned a pair without right frames")
+	}
+}
@@ -0,0 +1,109 @@
+package camera
+
+import (
+	"context"
+	"image"
+	"image/png"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/conneroisu/steroscopic-hardware/pkg/homedir"
+)
+
+// PersistOptions configures the persistence of frames to the home
+// directory.
+type PersistOptions struct {
+	// Dir is the directory frames are saved to instead of the home
+	// directory.
+	Dir string
+
+	// Archive additionally keeps a timestamped copy of every input camera
+	// frame, named stero-image-<type>-<timestamp>.png.
+	Archive bool
+}
+
+// Persist saves the frames published on the bus of m to the home
+// directory, or opts.Dir, until ctx is done.
+//
+// The last frame of each camera is written to $HOME/<type>.png. Along with
+// output frames, the raw disparity and validity mask of the output camera
+// of m are written to $HOME/output-raw.png and $HOME/output-valid.png.
+// Frames that arrive while a previous one is still being encoded replace
+// each other, so persistence never slows down the cameras.
+func Persist(ctx context.Context, m Manager, opts PersistOptions) {
+	logger := slog.Default().WithGroup("persist")
+	sub := m.Bus().Subscribe(3)
+	defer sub.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case c, ok := <-sub.C():
+			if !ok {
+				return
+			}
+			err := opts.save(string(c.Camera)+".png", c.Image)
+			if err != nil {
+				logger.Error("failed to save frame", "camera", c.Camera, "err", err)
+			}
+
+			switch c.Camera {
+			case OutputCameraType:
+				persistOutputFrame(logger, m.GetCamera(OutputCameraType), opts)
+			case LeftCameraType, RightCameraType:
+				if !opts.Archive {
+					continue
+				}
+				name := "stero-image-" + string(c.Camera) + "-" + c.Time.Format("2006-01-02-15-04-05") + ".png"
+				err = opts.save(name, c.Image)
+				if err != nil {
+					logger.Error("failed to archive frame", "camera", c.Camera, "err", err)
+				}
+			}
+		}
+	}
+}
+
+// persistOutputFrame saves the raw disparity and validity mask of the last
+// frame of an output camera.
+func persistOutputFrame(logger *slog.Logger, cam Camera, opts PersistOptions) {
+	output, ok := cam.(*OutputCamera)
+	if !ok {
+		return
+	}
+	frame := output.LastFrame()
+	if frame == nil {
+		return
+	}
+	err := opts.save("output-raw.png", frame.Result.Raw)
+	if err != nil {
+		logger.Error("failed to save raw disparity", "err", err)
+	}
+	if frame.Result.Valid != nil {
+		err = opts.save("output-valid.png", frame.Result.Valid)
+		if err != nil {
+			logger.Error("failed to save validity mask", "err", err)
+		}
+	}
+}
+
+// save writes an image as a PNG file to the directory of the options.
+func (opts PersistOptions) save(name string, img image.Image) error {
+	if opts.Dir == "" {
+		return homedir.SaveImage(name, img)
+	}
+	f, err := os.Create(filepath.Join(opts.Dir, name))
+	if err != nil {
+		return err
+	}
+	err = png.Encode(f, img)
+	if err != nil {
+		f.Close()
+
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,124 @@
+package camera
+
+import (
+	"cmp"
+	"encoding/binary"
+	"fmt"
+	"image"
+)
+
+// validateFormat reports whether frames of a pixel format and size can be
+// decoded.
+func validateFormat(f PixelFormat, width, height int) error {
+	if f.BytesPerPixel() == 0 {
+		return fmt.Errorf("unknown pixel format %d", f)
+	}
+	if f == PixelBayerRGGB8 && (width%2 != 0 || height%2 != 0) {
+		return fmt.Errorf("%s frames must have an even size, got %dx%d", f, width, height)
+	}
+
+	return nil
+}
+
+// decodePixels decodes the pixels of a frame of a pixel format into a gray
+// image. 16 bit samples of depth significant bits, 16 when zero, are
+// reduced to their 8 most significant bits and Bayer mosaics are
+// demosaiced.
+func decodePixels(f PixelFormat, depth, width, height int, pix []byte) (*image.Gray, error) {
+	err := validateFormat(f, width, height)
+	if err != nil {
+		return nil, err
+	}
+	if len(pix) != width*height*f.BytesPerPixel() {
+		return nil, fmt.Errorf("%d bytes of pixels do not make a %dx%d %s frame", len(pix), width, height, f)
+	}
+	shift := cmp.Or(depth, 16) - 8
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	switch f {
+	case PixelGray8:
+		copy(img.Pix, pix)
+	case PixelGray16LE:
+		for i := range img.Pix {
+			img.Pix[i] = byte(min(binary.LittleEndian.Uint16(pix[2*i:])>>shift, 0xff))
+		}
+	case PixelGray16BE:
+		for i := range img.Pix {
+			img.Pix[i] = byte(min(binary.BigEndian.Uint16(pix[2*i:])>>shift, 0xff))
+		}
+	case PixelBayerRGGB8:
+		demosaicRGGB(img, pix)
+	}
+
+	return img, nil
+}
+
+// encodePixels encodes the pixels of a gray frame in a pixel format, the
+// inverse of decodePixels. A Bayer mosaic of a gray frame has the gray
+// level at every site.
+func encodePixels(f PixelFormat, depth int, gray []byte) []byte {
+	shift := 16 - cmp.Or(depth, 16)
+	switch f {
+	case PixelGray16LE:
+		pix := make([]byte, 0, 2*len(gray))
+		for _, v := range gray {
+			pix = binary.LittleEndian.AppendUint16(pix, uint16(v)*0x101>>shift)
+		}
+
+		return pix
+	case PixelGray16BE:
+		pix := make([]byte, 0, 2*len(gray))
+		for _, v := range gray {
+			pix = binary.BigEndian.AppendUint16(pix, uint16(v)*0x101>>shift)
+		}
+
+		return pix
+	default:
+		return gray
+	}
+}
+
+// demosaicRGGB interpolates the colors missing at each site of an RGGB
+// Bayer mosaic from its neighbours, bilinearly, and stores the luma of the
+// color in img. The mosaic is mirrored at its edges.
+func demosaicRGGB(img *image.Gray, mosaic []byte) {
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	at := func(x, y int) uint32 {
+		x, y = mirror(x, width), mirror(y, height)
+
+		return uint32(mosaic[y*width+x])
+	}
+	for y := range height { // y := 0; y < height; y++
+		for x := range width { // x := 0; x < width; x++
+			cross := (at(x-1, y) + at(x+1, y) + at(x, y-1) + at(x, y+1)) / 4
+			diagonal := (at(x-1, y-1) + at(x+1, y-1) + at(x-1, y+1) + at(x+1, y+1)) / 4
+			across := (at(x-1, y) + at(x+1, y)) / 2
+			along := (at(x, y-1) + at(x, y+1)) / 2
+
+			var r, g, b uint32
+			switch {
+			case y%2 == 0 && x%2 == 0: // Red
+				r, g, b = at(x, y), cross, diagonal
+			case y%2 == 1 && x%2 == 1: // Blue
+				r, g, b = diagonal, cross, at(x, y)
+			case y%2 == 0: // Green on a red row
+				r, g, b = across, at(x, y), along
+			default: // Green on a blue row
+				r, g, b = along, at(x, y), across
+			}
+			img.Pix[y*img.Stride+x] = uint8((19595*r + 38470*g + 7471*b + 1<<15) >> 16)
+		}
+	}
+}
+
+// mirror reflects a coordinate beyond the edges of a row or column of size
+// n back into it, keeping its parity and so its color in a Bayer mosaic.
+func mirror(i, n int) int {
+	switch {
+	case i < 0:
+		return -i
+	case i >= n:
+		return 2*(n-1) - i
+	default:
+		return i
+	}
+}
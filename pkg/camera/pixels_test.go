@@ -0,0 +1,100 @@
+package camera
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecodePixels(t *testing.T) {
+	tests := []struct {
+		name   string
+		format PixelFormat
+		depth  int
+		pix    []byte
+		want   []byte
+	}{
+		{"gray8", PixelGray8, 0, []byte{1, 2, 3, 4}, []byte{1, 2, 3, 4}},
+		{"gray16le", PixelGray16LE, 0, []byte{0xff, 0x01, 0x00, 0x80, 0x40, 0xff, 0x00, 0x00}, []byte{1, 0x80, 0xff, 0}},
+		{"gray16be", PixelGray16BE, 0, []byte{0x01, 0xff, 0x80, 0x00, 0xff, 0x40, 0x00, 0x00}, []byte{1, 0x80, 0xff, 0}},
+		// 10 bit samples in the least significant bits, the last one out of
+		// range
+		{"gray16le 10 bit", PixelGray16LE, 10, []byte{0xff, 0x03, 0x04, 0x02, 0x03, 0x00, 0x00, 0x04}, []byte{0xff, 0x81, 0, 0xff}},
+		{"gray16be 10 bit", PixelGray16BE, 10, []byte{0x03, 0xff, 0x02, 0x04, 0x00, 0x03, 0x04, 0x00}, []byte{0xff, 0x81, 0, 0xff}},
+		// A mosaic of one color demosaics to the luma of the color
+		{"bayer", PixelBayerRGGB8, 0, []byte{200, 100, 100, 50}, []byte{124, 124, 124, 124}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := decodePixels(tt.format, tt.depth, 2, 2, tt.pix)
+			if err != nil {
+				t.Fatalf("decodePixels() error = %v", err)
+			}
+			if !bytes.Equal(img.Pix, tt.want) {
+				t.Errorf("decodePixels() = %v, want %v", img.Pix, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodePixelsRoundTrip(t *testing.T) {
+	img := testImage(8, 6, 0)
+	for _, f := range []PixelFormat{PixelGray8, PixelGray16LE, PixelGray16BE} {
+		for _, depth := range []int{0, 10, 12} {
+			got, err := decodePixels(f, depth, 8, 6, encodePixels(f, depth, img.Pix))
+			if err != nil {
+				t.Fatalf("%s, depth %d: decodePixels() error = %v", f, depth, err)
+			}
+			if !bytes.Equal(got.Pix, img.Pix) {
+				t.Errorf("%s, depth %d: decoded pixels differ", f, depth)
+			}
+		}
+	}
+}
+
+func TestDemosaicRGGB(t *testing.T) {
+	// A vertical edge between black and white columns
+	mosaic := make([]byte, 8*4)
+	for y := range 4 {
+		for x := 4; x < 8; x++ {
+			mosaic[y*8+x] = 255
+		}
+	}
+	img, err := decodePixels(PixelBayerRGGB8, 0, 8, 4, mosaic)
+	if err != nil {
+		t.Fatalf("decodePixels() error = %v", err)
+	}
+	for y := range 4 {
+		row := img.Pix[y*8 : (y+1)*8]
+		if row[0] != 0 || row[7] != 255 || row[2] != 0 || row[5] != 255 {
+			t.Errorf("row %d = %v, want black left and white right of the edge", y, row)
+		}
+	}
+}
+
+func TestConfigValidateFrame(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		valid  bool
+	}{
+		{"defaults", Config{}, true},
+		{"size", Config{Width: 640, Height: 480}, true},
+		{"format", Config{Format: PixelGray16LE}, true},
+		{"width only", Config{Width: 640}, false},
+		{"too large", Config{Width: MaxFrameDimension + 1, Height: 480}, false},
+		{"unknown format", Config{Format: 9}, false},
+		{"bayer", Config{Width: 128, Height: 128, Format: PixelBayerRGGB8}, true},
+		{"odd bayer", Config{Width: 127, Height: 128, Format: PixelBayerRGGB8}, false},
+		{"bit depth", Config{Format: PixelGray16LE, BitDepth: 10}, true},
+		{"bit depth too small", Config{Format: PixelGray16LE, BitDepth: 7}, false},
+		{"bit depth too large", Config{Format: PixelGray16BE, BitDepth: 17}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.ValidateFrame()
+			if (err == nil) != tt.valid {
+				t.Errorf("ValidateFrame() error = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
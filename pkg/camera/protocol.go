@@ -0,0 +1,342 @@
+package camera
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"sync"
+)
+
+// Protocol selects how a serial camera delimits frames on the line.
+type Protocol string
+
+const (
+	// ProtocolLegacy reads frames of the configured size and pixel format
+	// after the camera acknowledges the start sequence: 8-bit gray, 16-bit
+	// gray little or big endian with a bit depth, or 8-bit Bayer RGGB. It is
+	// the protocol of existing firmware and the default.
+	ProtocolLegacy Protocol = "legacy"
+	// ProtocolFramed reads frames with a header and a CRC32 trailer, see
+	// FrameHeader, and resynchronizes on corrupted or lost bytes.
+	ProtocolFramed Protocol = "framed"
+)
+
+// ParseProtocol parses a serial protocol name, treating the empty string as
+// ProtocolLegacy.
+func ParseProtocol(s string) (Protocol, error) {
+	switch p := Protocol(s); p {
+	case "", ProtocolLegacy:
+		return ProtocolLegacy, nil
+	case ProtocolFramed:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown serial protocol: %q", s)
+	}
+}
+
+// PixelFormat identifies the pixel encoding of a frame's payload.
+type PixelFormat uint8
+
+const (
+	// PixelGray8 is one byte of gray level per pixel, row by row.
+	PixelGray8 PixelFormat = 1
+	// PixelGray16LE is a little-endian 16 bit gray level per pixel. Sensors
+	// of fewer bits send their samples in the most significant bits, or in
+	// the least significant bits when Config.BitDepth says how many.
+	PixelGray16LE PixelFormat = 2
+	// PixelGray16BE is a big-endian 16 bit gray level per pixel. Sensors of
+	// fewer bits send their samples in the most significant bits, or in the
+	// least significant bits when Config.BitDepth says how many.
+	PixelGray16BE PixelFormat = 3
+	// PixelBayerRGGB8 is one byte per pixel of a Bayer mosaic whose even rows
+	// alternate red and green and odd rows green and blue, starting with
+	// red. Frames are demosaiced to gray, so their width and height must be
+	// even.
+	PixelBayerRGGB8 PixelFormat = 4
+)
+
+// String returns the name of the pixel format.
+func (f PixelFormat) String() string {
+	switch f {
+	case PixelGray8:
+		return "gray8"
+	case PixelGray16LE:
+		return "gray16le"
+	case PixelGray16BE:
+		return "gray16be"
+	case PixelBayerRGGB8:
+		return "bayer-rggb8"
+	default:
+		return fmt.Sprintf("PixelFormat(%d)", uint8(f))
+	}
+}
+
+// ParsePixelFormat parses a pixel format name, as returned by String.
+func ParsePixelFormat(s string) (PixelFormat, error) {
+	for _, f := range []PixelFormat{PixelGray8, PixelGray16LE, PixelGray16BE, PixelBayerRGGB8} {
+		if s == f.String() {
+			return f, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown pixel format: %q", s)
+}
+
+// BytesPerPixel returns the payload bytes of a pixel, or 0 for unknown
+// formats.
+func (f PixelFormat) BytesPerPixel() int {
+	switch f {
+	case PixelGray8, PixelBayerRGGB8:
+		return 1
+	case PixelGray16LE, PixelGray16BE:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// FrameMagic starts the header of every frame of the framed protocol.
+var FrameMagic = [4]byte{'S', 'T', 'F', 'R'}
+
+const (
+	// FrameHeaderSize is the size of an encoded FrameHeader.
+	FrameHeaderSize = 18
+	// frameTrailerSize is the size of the CRC32 trailer.
+	frameTrailerSize = 4
+	// MaxFrameDimension bounds the width and height of a frame, so that a
+	// corrupted header that still has the magic does not allocate huge
+	// payloads.
+	MaxFrameDimension = 8192
+)
+
+// FrameHeader is the header of a frame of the framed protocol.
+//
+// The payload holds the pixels compressed with the header's compression;
+// uncompressed frames carry exactly width*height*bytes-per-pixel bytes.
+//
+// On the line a frame is the header, the payload and a trailer, with all
+// integers little-endian:
+//
+//	offset  size  field
+//	0       4     magic "STFR"
+//	4       4     frame number
+//	8       2     width
+//	10      2     height
+//	12      1     pixel format
+//	13      1     compression of the payload, see Compression
+//	14      4     payload length
+//	18      n     payload
+//	18+n    4     CRC32 (IEEE) of header and payload
+type FrameHeader struct {
+	Seq    uint32
+	Width  int
+	Height int
+	Format PixelFormat
+	// Compression is the compression of the payload.
+	Compression Compression
+	// Length is the length of the payload on the line.
+	Length int
+}
+
+// Size returns the size of the frame's uncompressed pixels.
+func (h FrameHeader) Size() int {
+	return h.Width * h.Height * h.Format.BytesPerPixel()
+}
+
+// validate reports whether the header describes a frame that can be
+// decoded.
+func (h FrameHeader) validate() error {
+	if h.Width <= 0 || h.Height <= 0 || h.Width > MaxFrameDimension || h.Height > MaxFrameDimension {
+		return fmt.Errorf("invalid frame size %dx%d", h.Width, h.Height)
+	}
+	err := validateFormat(h.Format, h.Width, h.Height)
+	if err != nil {
+		return err
+	}
+	bpp := h.Format.BytesPerPixel()
+	err = h.Compression.Validate()
+	if err != nil {
+		return err
+	}
+	size := h.Width * h.Height * bpp
+	if h.Compression == CompressionNone && h.Length != size ||
+		h.Compression != CompressionNone && h.Length > maxCompressedLength(size) {
+		return fmt.Errorf("payload length %d does not match a %dx%d frame", h.Length, h.Width, h.Height)
+	}
+
+	return nil
+}
+
+// AppendFrame appends the encoding of a frame to buf. The header's length
+// is taken from the payload, which must already be compressed with the
+// header's compression.
+func AppendFrame(buf []byte, h FrameHeader, payload []byte) []byte {
+	start := len(buf)
+	buf = append(buf, FrameMagic[:]...)
+	buf = binary.LittleEndian.AppendUint32(buf, h.Seq)
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(h.Width))
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(h.Height))
+	buf = append(buf, byte(h.Format), byte(h.Compression))
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(payload)))
+	buf = append(buf, payload...)
+
+	return binary.LittleEndian.AppendUint32(buf, crc32.ChecksumIEEE(buf[start:]))
+}
+
+// FrameReaderStats counts the outcomes of reading framed frames.
+type FrameReaderStats struct {
+	// Frames is the number of frames read.
+	Frames uint64 `json:"frames"`
+	// Resyncs is the number of times the reader searched for the next
+	// frame after an invalid header or checksum.
+	Resyncs uint64 `json:"resyncs"`
+	// ChecksumErrors is the number of frames discarded for a bad CRC32.
+	ChecksumErrors uint64 `json:"checksumErrors"`
+	// SkippedBytes is the number of bytes discarded while searching for a
+	// frame.
+	SkippedBytes uint64 `json:"skippedBytes"`
+	// Lost is the number of frames missing from the frame numbers.
+	Lost uint64 `json:"lost"`
+}
+
+// readChunk is the number of bytes a FrameReader reads at once.
+const readChunk = 32 * 1024
+
+// FrameReader reads frames of the framed protocol.
+//
+// Bytes that do not start a frame with a valid header and checksum are
+// skipped: after a corrupted frame the reader searches for the next magic
+// from the byte after the corrupted frame's magic, so a frame that starts
+// inside a truncated one is still found.
+type FrameReader struct {
+	r       io.Reader
+	buf     []byte
+	mu      sync.Mutex // Guards stats against Stats
+	stats   FrameReaderStats
+	lastSeq uint32
+	started bool
+}
+
+// NewFrameReader creates a reader of the frames of r.
+func NewFrameReader(r io.Reader) *FrameReader {
+	return &FrameReader{r: r}
+}
+
+// Stats returns the counters of the frames read so far. It may be called
+// while another goroutine reads frames.
+func (fr *FrameReader) Stats() FrameReaderStats {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+
+	return fr.stats
+}
+
+// count updates the counters of the frames read.
+func (fr *FrameReader) count(update func(stats *FrameReaderStats)) {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	update(&fr.stats)
+}
+
+// ReadFrame reads the next valid frame, returning its header and a copy of
+// its payload. It only returns the errors of the underlying reader.
+func (fr *FrameReader) ReadFrame() (FrameHeader, []byte, error) {
+	syncing := false
+	resync := func(skip int) {
+		fr.count(func(stats *FrameReaderStats) {
+			if !syncing {
+				syncing = true
+				stats.Resyncs++
+			}
+			stats.SkippedBytes += uint64(skip)
+		})
+		fr.buf = fr.buf[skip:]
+	}
+
+	for {
+		err := fr.fill(len(FrameMagic))
+		if err != nil {
+			return FrameHeader{}, nil, err
+		}
+		i := bytes.Index(fr.buf, FrameMagic[:])
+		if i < 0 {
+			// Keep what may be the start of a magic split across reads
+			resync(len(fr.buf) - len(FrameMagic) + 1)
+			err = fr.fill(len(fr.buf) + 1)
+			if err != nil {
+				return FrameHeader{}, nil, err
+			}
+
+			continue
+		}
+		if i > 0 {
+			resync(i)
+		}
+
+		err = fr.fill(FrameHeaderSize)
+		if err != nil {
+			return FrameHeader{}, nil, err
+		}
+		h := FrameHeader{
+			Seq:         binary.LittleEndian.Uint32(fr.buf[4:]),
+			Width:       int(binary.LittleEndian.Uint16(fr.buf[8:])),
+			Height:      int(binary.LittleEndian.Uint16(fr.buf[10:])),
+			Format:      PixelFormat(fr.buf[12]),
+			Compression: Compression(fr.buf[13]),
+			Length:      int(binary.LittleEndian.Uint32(fr.buf[14:])),
+		}
+		if h.validate() != nil {
+			resync(1)
+
+			continue
+		}
+
+		size := FrameHeaderSize + h.Length + frameTrailerSize
+		err = fr.fill(size)
+		if err != nil {
+			return FrameHeader{}, nil, err
+		}
+		sum := binary.LittleEndian.Uint32(fr.buf[size-frameTrailerSize:])
+		if crc32.ChecksumIEEE(fr.buf[:size-frameTrailerSize]) != sum {
+			fr.count(func(stats *FrameReaderStats) { stats.ChecksumErrors++ })
+			resync(1)
+
+			continue
+		}
+
+		payload := make([]byte, h.Length)
+		copy(payload, fr.buf[FrameHeaderSize:])
+		fr.buf = fr.buf[size:]
+		fr.count(func(stats *FrameReaderStats) {
+			stats.Frames++
+			// Frame numbers going back mean the camera restarted
+			if gap := h.Seq - fr.lastSeq; fr.started && gap > 1 && gap < 1<<16 {
+				stats.Lost += uint64(gap - 1)
+			}
+		})
+		fr.started, fr.lastSeq = true, h.Seq
+
+		return h, payload, nil
+	}
+}
+
+// fill reads until at least n bytes are buffered.
+func (fr *FrameReader) fill(n int) error {
+	for len(fr.buf) < n {
+		if cap(fr.buf)-len(fr.buf) < readChunk {
+			// Move the buffered bytes to the front of a buffer with room
+			buf := make([]byte, len(fr.buf), max(2*cap(fr.buf), len(fr.buf)+readChunk, n))
+			copy(buf, fr.buf)
+			fr.buf = buf
+		}
+		m, err := fr.r.Read(fr.buf[len(fr.buf):cap(fr.buf)])
+		fr.buf = fr.buf[:len(fr.buf)+m]
+		if err != nil && len(fr.buf) < n {
+			return err
+		}
+	}
+
+	return nil
+}
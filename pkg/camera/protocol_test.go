@@ -0,0 +1,103 @@
+package camera
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// testFrames encodes count 4x2 frames numbered from 1, whose pixels are
+// their frame number.
+func testFrames(count int) [][]byte {
+	frames := make([][]byte, count)
+	for i := range frames {
+		seq := uint32(i + 1)
+		payload := bytes.Repeat([]byte{byte(seq)}, 8)
+		frames[i] = AppendFrame(nil, FrameHeader{Seq: seq, Width: 4, Height: 2, Format: PixelGray8}, payload)
+	}
+
+	return frames
+}
+
+// readSeqs reads frames until the end of the stream and returns their
+// numbers, checking their payloads.
+func readSeqs(t *testing.T, fr *FrameReader) []uint32 {
+	t.Helper()
+	var seqs []uint32
+	for {
+		h, payload, err := fr.ReadFrame()
+		if errors.Is(err, io.EOF) {
+			return seqs
+		}
+		if err != nil {
+			t.Fatalf("ReadFrame() error = %v", err)
+		}
+		if h.Width != 4 || h.Height != 2 || !bytes.Equal(payload, bytes.Repeat([]byte{byte(h.Seq)}, 8)) {
+			t.Errorf("frame %d has header %+v and payload %v", h.Seq, h, payload)
+		}
+		seqs = append(seqs, h.Seq)
+	}
+}
+
+func TestFrameReaderRoundTrip(t *testing.T) {
+	frames := testFrames(3)
+	// One byte at a time splits every field across reads
+	fr := NewFrameReader(&oneByteReader{r: bytes.NewReader(bytes.Join(frames, nil))})
+	if got := readSeqs(t, fr); len(got) != 3 || got[0] != 1 || got[2] != 3 {
+		t.Errorf("read frames %v, want [1 2 3]", got)
+	}
+	if stats := fr.Stats(); stats.Frames != 3 || stats.Resyncs != 0 || stats.Lost != 0 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+}
+
+func TestFrameReaderResync(t *testing.T) {
+	frames := testFrames(5)
+	// Garbage before the first frame
+	stream := []byte{0xff, 0xd8, 'S', 'T', 0x00}
+	stream = append(stream, frames[0]...)
+	// A flipped payload bit in frame 2
+	corrupted := bytes.Clone(frames[1])
+	corrupted[FrameHeaderSize+3] ^= 0x10
+	stream = append(stream, corrupted...)
+	// A dropped byte in frame 3 makes it swallow the start of frame 4
+	stream = append(stream, frames[2][:20]...)
+	stream = append(stream, frames[2][21:]...)
+	stream = append(stream, frames[3]...)
+	stream = append(stream, frames[4]...)
+
+	fr := NewFrameReader(bytes.NewReader(stream))
+	got := readSeqs(t, fr)
+	if len(got) != 3 || got[0] != 1 || got[1] != 4 || got[2] != 5 {
+		t.Errorf("read frames %v, want [1 4 5]", got)
+	}
+	stats := fr.Stats()
+	if stats.ChecksumErrors != 2 || stats.Lost != 2 || stats.Resyncs == 0 || stats.SkippedBytes == 0 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+}
+
+func TestFrameReaderRejectsInconsistentHeader(t *testing.T) {
+	frame := testFrames(1)[0]
+	// A length that does not match the frame size
+	bad := bytes.Clone(frame)
+	bad[14] = 9
+	fr := NewFrameReader(bytes.NewReader(append(bad, frame...)))
+	if got := readSeqs(t, fr); len(got) != 1 {
+		t.Errorf("read frames %v, want [1]", got)
+	}
+}
+
+// oneByteReader reads at most one byte at a time, like a slow serial line.
+type oneByteReader struct {
+	r io.Reader
+}
+
+func (r *oneByteReader) Read(p []byte) (int, error) {
+	if len(p) > 1 {
+		p = p[:1]
+	}
+
+	return r.r.Read(p)
+}
@@ -0,0 +1,80 @@
+package camera
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"golang.org/x/sys/unix"
+)
+
+// openPTY opens a new pseudo-terminal in raw mode and returns its master
+// and the name of its slave device.
+//
+// The master keeps a descriptor of the slave open, so that reads of the
+// master do not fail while no camera has the device open; the descriptor is
+// closed with the master.
+func openPTY() (io.ReadWriteCloser, string, error) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		return nil, "", err
+	}
+	fd := int(master.Fd())
+	err = unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0)
+	if err != nil {
+		master.Close()
+
+		return nil, "", fmt.Errorf("failed to unlock pseudo-terminal: %w", err)
+	}
+	n, err := unix.IoctlGetUint32(fd, unix.TIOCGPTN)
+	if err != nil {
+		master.Close()
+
+		return nil, "", fmt.Errorf("failed to get pseudo-terminal number: %w", err)
+	}
+	name := fmt.Sprintf("/dev/pts/%d", n)
+
+	slave, err := unix.Open(name, unix.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+
+		return nil, "", fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	termios, err := unix.IoctlGetTermios(slave, unix.TCGETS)
+	if err == nil {
+		// Raw mode, as serial.Open sets it
+		termios.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP |
+			unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+		termios.Oflag &^= unix.OPOST
+		termios.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+		termios.Cflag &^= unix.CSIZE | unix.PARENB
+		termios.Cflag |= unix.CS8
+		err = unix.IoctlSetTermios(slave, unix.TCSETS, termios)
+	}
+	if err != nil {
+		unix.Close(slave)
+		master.Close()
+
+		return nil, "", fmt.Errorf("failed to set %s to raw mode: %w", name, err)
+	}
+
+	return &ptyMaster{File: master, slave: slave}, name, nil
+}
+
+// ptyMaster is the master of a pseudo-terminal holding a descriptor of its
+// slave.
+type ptyMaster struct {
+	*os.File
+	slave int
+	once  sync.Once
+}
+
+// Close closes the master and the slave descriptor. Closing again is a
+// no-op, as the slave's descriptor number may have been reused since.
+func (m *ptyMaster) Close() error {
+	err := m.File.Close()
+	m.once.Do(func() { unix.Close(m.slave) })
+
+	return err
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package camera
+
+import (
+	"errors"
+	"io"
+)
+
+// openPTY opens a new pseudo-terminal, which is only supported on Linux.
+func openPTY() (io.ReadWriteCloser, string, error) {
+	return nil, "", errors.New("pseudo-terminals are only supported on Linux")
+}
@@ -0,0 +1,197 @@
+package camera
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRig is the ID of the rig of the package-level camera
+	// functions. It always exists and cannot be removed.
+	DefaultRig = "default"
+
+	// MaxRigs is the maximum number of rigs, the default rig included.
+	MaxRigs = 16
+)
+
+var (
+	// ErrRigNotFound is returned for rigs that do not exist.
+	ErrRigNotFound = errors.New("rig not found")
+	// ErrRigExists is returned when creating a rig whose ID is taken.
+	ErrRigExists = errors.New("rig already exists")
+)
+
+// rigIDPattern matches the IDs of rigs, which are used in URL paths.
+var rigIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// ValidateRigID reports whether a rig ID is valid: up to 32 lower case
+// letters, digits, dashes and underscores, starting with a letter or
+// digit.
+func ValidateRigID(id string) error {
+	if !rigIDPattern.MatchString(id) {
+		return fmt.Errorf("invalid rig id %q", id)
+	}
+
+	return nil
+}
+
+// Rig is a named stereo rig: a Manager of its own left, right and output
+// cameras, with the output camera's parameters and pipeline.
+type Rig struct {
+	Manager
+
+	id      string
+	created time.Time
+	ctx     context.Context    // Done when the rig is removed
+	cancel  context.CancelFunc // Removes the rig's cameras from ctx
+	close   func() error       // Closes the cameras and stops pairing
+}
+
+// ID returns the ID of the rig.
+func (r *Rig) ID() string {
+	return r.id
+}
+
+// Created returns the time the rig was created.
+func (r *Rig) Created() time.Time {
+	return r.created
+}
+
+// Done returns a channel that is closed when the rig is removed, so that
+// its streams and subscribers can end.
+func (r *Rig) Done() <-chan struct{} {
+	return r.ctx.Done()
+}
+
+// rigs is a registry of rigs keyed by ID.
+type rigs struct {
+	mu   sync.RWMutex
+	rigs map[string]*Rig
+}
+
+// newRigs creates a registry whose default rig is m.
+func newRigs(m Manager) *rigs {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &rigs{rigs: map[string]*Rig{
+		DefaultRig: {
+			Manager: m,
+			id:      DefaultRig,
+			created: time.Now(),
+			ctx:     ctx,
+			cancel:  cancel,
+		},
+	}}
+}
+
+// create creates a rig with an output camera and no input cameras. Its
+// cameras stream until ctx is done or the rig is removed.
+func (rs *rigs) create(ctx context.Context, id string) (*Rig, error) {
+	err := ValidateRigID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if _, ok := rs.rigs[id]; ok {
+		return nil, fmt.Errorf("%w: %s", ErrRigExists, id)
+	}
+	if len(rs.rigs) >= MaxRigs {
+		return nil, fmt.Errorf("cannot create more than %d rigs", MaxRigs)
+	}
+
+	m := newManager()
+	rigCtx, cancel := context.WithCancel(ctx)
+	rig := &Rig{
+		Manager: m,
+		id:      id,
+		created: time.Now(),
+		ctx:     rigCtx,
+		cancel:  cancel,
+		close:   m.close,
+	}
+	err = m.SetCamera(rigCtx, OutputCameraType, NewOutputCamera(rigCtx))
+	if err != nil {
+		cancel()
+		_ = m.close()
+
+		return nil, fmt.Errorf("failed to create output camera of rig %s: %w", id, err)
+	}
+	rs.rigs[id] = rig
+
+	return rig, nil
+}
+
+// get returns a rig by ID.
+func (rs *rigs) get(id string) (*Rig, bool) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	rig, ok := rs.rigs[id]
+
+	return rig, ok
+}
+
+// list returns the rigs ordered by ID.
+func (rs *rigs) list() []*Rig {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	list := make([]*Rig, 0, len(rs.rigs))
+	for _, rig := range rs.rigs {
+		list = append(list, rig)
+	}
+	slices.SortFunc(list, func(a, b *Rig) int {
+		return strings.Compare(a.id, b.id)
+	})
+
+	return list
+}
+
+// remove closes the cameras of a rig and removes it.
+func (rs *rigs) remove(id string) error {
+	if id == DefaultRig {
+		return errors.New("the default rig cannot be removed")
+	}
+
+	rs.mu.Lock()
+	rig, ok := rs.rigs[id]
+	delete(rs.rigs, id)
+	rs.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrRigNotFound, id)
+	}
+	rig.cancel()
+
+	return rig.close()
+}
+
+// Global rig registry, whose default rig is the default manager.
+var defaultRigs = newRigs(defaultManager)
+
+// CreateRig creates a rig with an output camera computing disparity with
+// the default parameters. Its input cameras are set as with the default
+// manager, and its cameras stream until ctx is done or it is removed.
+func CreateRig(ctx context.Context, id string) (*Rig, error) {
+	return defaultRigs.create(ctx, id)
+}
+
+// GetRig returns a rig by ID. The DefaultRig is the default manager.
+func GetRig(id string) (*Rig, bool) {
+	return defaultRigs.get(id)
+}
+
+// Rigs returns the rigs ordered by ID.
+func Rigs() []*Rig {
+	return defaultRigs.list()
+}
+
+// RemoveRig closes the cameras of a rig and removes it.
+func RemoveRig(id string) error {
+	return defaultRigs.remove(id)
+}
@@ -0,0 +1,126 @@
+package camera
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestValidateRigID(t *testing.T) {
+	for _, id := range []string{"a", "bench-2", "rig_1", "0"} {
+		if err := ValidateRigID(id); err != nil {
+			t.Errorf("ValidateRigID(%q) error = %v", id, err)
+		}
+	}
+	for _, id := range []string{"", "-a", "A", "a/b", "a b", "abcdefghijklmnopqrstuvwxyz0123456"} {
+		if err := ValidateRigID(id); err == nil {
+			t.Errorf("ValidateRigID(%q) accepted an invalid id", id)
+		}
+	}
+}
+
+func TestRigsCreateAndRemove(t *testing.T) {
+	rs := newRigs(NewManager())
+	rig, err := rs.create(t.Context(), "bench")
+	if err != nil {
+		t.Fatalf("create() error = %v", err)
+	}
+	if _, ok := rig.GetCamera(OutputCameraType).(*OutputCamera); !ok {
+		t.Error("rig created without an output camera")
+	}
+	if _, err := rs.create(t.Context(), "bench"); !errors.Is(err, ErrRigExists) {
+		t.Errorf("create() of a taken id error = %v, want %v", err, ErrRigExists)
+	}
+	if _, err := rs.create(t.Context(), "Bench!"); err == nil {
+		t.Error("create() accepted an invalid id")
+	}
+	ids := func() []string {
+		var ids []string
+		for _, rig := range rs.list() {
+			ids = append(ids, rig.ID())
+		}
+
+		return ids
+	}
+	if got, want := ids(), []string{"bench", DefaultRig}; !slices.Equal(got, want) {
+		t.Errorf("list() = %v, want %v", got, want)
+	}
+
+	left := NewStaticCamera(t.Context(), "../../testdata/L_00001.png", LeftCameraType)
+	err = rig.SetCamera(t.Context(), LeftCameraType, left)
+	if err != nil {
+		t.Fatalf("SetCamera() error = %v", err)
+	}
+	err = rs.remove("bench")
+	if err != nil {
+		t.Fatalf("remove() error = %v", err)
+	}
+	select {
+	case <-rig.Done():
+	case <-time.After(time.Second):
+		t.Error("removed rig is not done")
+	}
+	if left.Context().Err() == nil {
+		t.Error("camera of a removed rig was not closed")
+	}
+	if _, ok := rs.get("bench"); ok {
+		t.Error("removed rig still exists")
+	}
+	if err := rs.remove("bench"); !errors.Is(err, ErrRigNotFound) {
+		t.Errorf("remove() of a removed rig error = %v, want %v", err, ErrRigNotFound)
+	}
+	if err := rs.remove(DefaultRig); err == nil {
+		t.Error("remove() removed the default rig")
+	}
+}
+
+func TestRigsAreIsolated(t *testing.T) {
+	rs := newRigs(NewManager())
+	a, err := rs.create(t.Context(), "a")
+	if err != nil {
+		t.Fatalf("create() error = %v", err)
+	}
+	b, err := rs.create(t.Context(), "b")
+	if err != nil {
+		t.Fatalf("create() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = rs.remove("a")
+		_ = rs.remove("b")
+	})
+
+	a.Bus().Publish(LeftCameraType, testImage(4, 4, 0))
+	if _, ok := b.Bus().Latest(LeftCameraType); ok {
+		t.Error("frame of one rig reached the other")
+	}
+	output, ok := a.GetCamera(OutputCameraType).(*OutputCamera)
+	if !ok {
+		t.Fatal("rig created without an output camera")
+	}
+	params := output.Params()
+	params.BlockSize += 2
+	output.SetParams(params)
+	other := b.GetCamera(OutputCameraType).(*OutputCamera).Params()
+	if other.BlockSize == params.BlockSize {
+		t.Error("parameters of one rig changed the other")
+	}
+}
+
+func TestRigsLimit(t *testing.T) {
+	rs := newRigs(NewManager())
+	t.Cleanup(func() {
+		for _, rig := range rs.list() {
+			_ = rs.remove(rig.ID())
+		}
+	})
+	for i := range MaxRigs - 1 {
+		_, err := rs.create(t.Context(), string(rune('a'+i)))
+		if err != nil {
+			t.Fatalf("create() error = %v", err)
+		}
+	}
+	if _, err := rs.create(t.Context(), "extra"); err == nil {
+		t.Errorf("create() exceeded %d rigs", MaxRigs)
+	}
+}
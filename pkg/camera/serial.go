@@ -1,16 +1,18 @@
 package camera
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"image"
-	"image/color"
 	"log/slog"
+	"slices"
+	"sync"
 	"time"
 
-	"github.com/conneroisu/steroscopic-hardware/pkg/homedir"
 	"go.bug.st/serial"
+	"go.bug.st/serial/enumerator"
 )
 
 var (
@@ -20,146 +22,259 @@ var (
 	// DefaultEndSeq is the default end marker for image data.
 	DefaultEndSeq = []byte{0xff, 0xd9}
 
-	// DefaultImageWidth is the default expected image width in pixels.
+	// DefaultImageWidth is the width in pixels of the frames of the legacy
+	// protocol when the configuration has none.
 	DefaultImageWidth = 1920
 
-	// DefaultImageHeight is the default expected image height in pixels.
+	// DefaultImageHeight is the height in pixels of the frames of the legacy
+	// protocol when the configuration has none.
 	DefaultImageHeight = 1080
 )
 
 // SerialCamera represents a camera connected via serial port. It handles communication
 // with hardware cameras over a serial interface, including image acquisition and streaming.
+//
+// A serial camera supervises its port: when the port is lost, for example
+// because its USB adapter was unplugged, it closes it, finds the adapter
+// again by its USB identity and reopens it with backoff, reporting its
+// state to the manager along the way.
 type SerialCamera struct {
 	*BaseCamera
-	port        serial.Port  // Serial port interface
-	startSeq    []byte       // Start sequence for image data
-	endSeq      []byte       // End sequence for image data
-	imageWidth  int          // Expected image width in pixels
-	imageHeight int          // Expected image height in pixels
-	logger      *slog.Logger // Logger for serial camera events
-	onClose     func()       // onClose function for closing the camera
-	cameraType  Type         // Type of camera
+	port        serial.Port   // Serial port interface
+	portMu      sync.Mutex    // Guards port, started and frames against Close
+	started     bool          // Whether the camera acknowledged the start sequence
+	startSeq    []byte        // Start sequence for image data
+	endSeq      []byte        // End sequence for image data
+	imageWidth  int           // Expected image width in pixels
+	imageHeight int           // Expected image height in pixels
+	format      PixelFormat   // Expected pixel format of the frames
+	bitDepth    int           // Significant bits of 16 bit samples, 16 when zero
+	logger      *slog.Logger  // Logger for serial camera events
+	cameraType  Type          // Type of camera
+	protocol    Protocol      // Framing of the frames on the line
+	frames      *FrameReader  // Reader of framed frames
+	compression Compression   // Compression acknowledged by the camera
+	mode        *serial.Mode  // Mode the port is opened with
+	open        portOpener    // Opens serial ports
+	list        portLister    // Lists serial ports
+	report      func(State)   // Reports the camera's state to its manager
+	backoff     time.Duration // Delay before the first reconnection attempt
 }
 
-// NewSerialCamera creates a new SerialCamera instance for the given type, port, baud rate,
-// and compression setting. It opens the serial port and prepares the camera for streaming.
-func NewSerialCamera(ctx context.Context, typ Type, portName string, baudRate int, compression int) (*SerialCamera, error) {
-	base := NewBaseCamera(ctx, typ)
+// errFrameMismatch is returned when the camera sends frames of another size
+// or pixel format than configured.
+var errFrameMismatch = errors.New("frame does not match the configuration")
 
-	// Configure the camera
-	base.SetConfig(Config{
-		Port:        portName,
-		BaudRate:    baudRate,
-		Compression: compression,
-	})
+// portOpener opens a serial port, as serial.Open.
+type portOpener func(name string, mode *serial.Mode) (serial.Port, error)
+
+// portLister lists the serial ports, as ListPorts.
+type portLister func() ([]*enumerator.PortDetails, error)
+
+// NewSerialCamera creates a new SerialCamera instance for the given type and
+// configuration. It opens the serial port and prepares the camera for streaming.
+//
+// The USB identity of the port, its vendor and product IDs and serial
+// number, is looked up unless the configuration has one, so that the port
+// can be found again after it is lost.
+func NewSerialCamera(ctx context.Context, typ Type, config Config) (*SerialCamera, error) {
+	return newSerialCamera(ctx, typ, config, serial.Open, ListPorts)
+}
+
+// newSerialCamera creates a serial camera that opens and lists ports with
+// the given functions.
+func newSerialCamera(
+	ctx context.Context,
+	typ Type,
+	config Config,
+	open portOpener,
+	list portLister,
+) (*SerialCamera, error) {
+	protocol, err := ParseProtocol(string(config.Protocol))
+	if err != nil {
+		return nil, err
+	}
+	config.Protocol = protocol
+	err = config.Compression.Validate()
+	if err != nil {
+		return nil, err
+	}
+	err = config.ValidateFrame()
+	if err != nil {
+		return nil, err
+	}
+	base := NewBaseCamera(ctx, typ)
 
 	sc := &SerialCamera{
 		BaseCamera:  &base,
 		startSeq:    DefaultStartSeq,
 		endSeq:      DefaultEndSeq,
-		imageWidth:  DefaultImageWidth,
-		imageHeight: DefaultImageHeight,
+		imageWidth:  cmp.Or(config.Width, DefaultImageWidth),
+		imageHeight: cmp.Or(config.Height, DefaultImageHeight),
+		format:      cmp.Or(config.Format, PixelGray8),
+		bitDepth:    config.BitDepth,
 		logger:      slog.Default().WithGroup(fmt.Sprintf("serial-camera-%s", typ)),
 		cameraType:  typ,
+		protocol:    protocol,
+		// Configure serial port
+		mode: &serial.Mode{
+			BaudRate: config.BaudRate,
+			DataBits: 8,
+			Parity:   serial.NoParity,
+			StopBits: serial.OneStopBit,
+		},
+		open:    open,
+		list:    list,
+		report:  func(State) {},
+		backoff: reconnectInitialBackoff,
 	}
 
-	// Configure serial port
-	mode := &serial.Mode{
-		BaudRate: baudRate,
-		DataBits: 8,
-		Parity:   serial.NoParity,
-		StopBits: serial.OneStopBit,
+	// Remember the USB identity of the port to find it again
+	if config.VID == "" && config.PID == "" && config.SerialNumber == "" {
+		ports, err := list()
+		if err != nil {
+			sc.logger.Warn("failed to list serial ports", "err", err)
+		}
+		if i := slices.IndexFunc(ports, func(p *enumerator.PortDetails) bool {
+			return p.Name == config.Port && p.IsUSB
+		}); i >= 0 {
+			config.VID, config.PID, config.SerialNumber = ports[i].VID, ports[i].PID, ports[i].SerialNumber
+		}
 	}
 
-	sc.logger.Info("opening serial port", "port", portName, "baudRate", baudRate)
-	port, err := serial.Open(portName, mode)
+	// Configure the camera
+	base.SetConfig(config)
+
+	sc.logger.Info("opening serial port",
+		"port", config.Port,
+		"baudRate", config.BaudRate,
+		"protocol", protocol,
+		"compression", config.Compression,
+		"width", config.Width,
+		"height", config.Height,
+		"format", config.Format,
+		"bitDepth", config.BitDepth,
+		"vid", config.VID,
+		"pid", config.PID,
+		"serialNumber", config.SerialNumber)
+	err = sc.openPort(config.Port)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open serial port %s: %w", portName, err)
+		return nil, err
 	}
 
-	sc.port = port
+	return sc, nil
+}
+
+// openPort opens the named serial port and makes it the camera's port.
+func (sc *SerialCamera) openPort(name string) error {
+	port, err := sc.open(name, sc.mode)
+	if err != nil {
+		return fmt.Errorf("failed to open serial port %s: %w", name, err)
+	}
 
 	// Set read timeout
-	err = sc.port.SetReadTimeout(serial.NoTimeout)
+	err = port.SetReadTimeout(serial.NoTimeout)
 	if err != nil {
-		sc.port.Close()
+		port.Close()
 
-		return nil, fmt.Errorf("failed to set read timeout: %w", err)
+		return fmt.Errorf("failed to set read timeout: %w", err)
 	}
 
-	return sc, nil
+	sc.portMu.Lock()
+	defer sc.portMu.Unlock()
+	if sc.Context().Err() != nil {
+		port.Close()
+
+		return errors.New("camera closed")
+	}
+	sc.port = port
+	sc.started = false
+	sc.frames = NewFrameReader(port)
+
+	return nil
+}
+
+// setStateFunc sets the function the camera reports its state with.
+func (sc *SerialCamera) setStateFunc(report func(State)) {
+	sc.report = report
 }
 
 // Stream reads images from the camera and sends them to the provided channel. It manages
 // the streaming lifecycle, error handling, and reconnection logic.
-func (sc *SerialCamera) Stream(ctx context.Context, _ ImageChannel) {
+func (sc *SerialCamera) Stream(ctx context.Context, outCh ImageChannel) {
 	sc.logger.Debug("SerialCamera.Stream started")
 	defer sc.logger.Debug("SerialCamera.Stream completed")
 
-	// Create error channel for internal communication
-	errChan := make(chan error, 1)
-
-	// Start the initial stream
-	readFn, err := sc.initializeStream(ctx, errChan)
-	if err != nil {
-		sc.logger.Error("failed to initialize image stream", "err", err)
-
-		return
-	}
-
-	// Launch the reading goroutine
-	go readFn()
-
-	// Monitor for errors and cancellation
 	for {
-		select {
-		case <-ctx.Done():
+		err := sc.initializeStream()
+		if err == nil {
+			sc.report(StateConnected)
+			err = sc.readFrames(ctx, outCh)
+		}
+		if ctx.Err() != nil || sc.Context().Err() != nil {
 			sc.logger.Debug("context canceled, stopping stream")
 
 			return
-		case <-sc.Context().Done():
-			sc.logger.Debug("camera context canceled, stopping stream")
+		}
+
+		if errors.Is(err, errFrameMismatch) {
+			// Reconnecting does not change what the camera sends
+			sc.logger.Error("camera does not match its configuration", "err", err)
+			sc.report(StateFailed)
+
+			return
+		}
+
+		sc.logger.Error("serial port lost", "err", err)
+		if !sc.reconnect(ctx) {
+			if ctx.Err() == nil && sc.Context().Err() == nil {
+				sc.logger.Error("giving up reconnecting", "attempts", maxReconnectAttempts)
+				sc.report(StateFailed)
+			}
 
 			return
-		case err := <-errChan:
-			sc.logger.Error("error in image stream", "err", err)
-			// Could implement reconnection logic here if needed
 		}
 	}
 }
 
-// initializeStream sets up the serial connection and prepares for streaming. It returns a
-// function that continuously reads frames and sends them to the image channel.
-func (sc *SerialCamera) initializeStream(ctx context.Context, errChan chan error) (func(), error) {
+// initializeStream sends the start sequence, requesting the configured
+// compression, and reads the camera's acknowledgement.
+func (sc *SerialCamera) initializeStream() error {
 	var tries = 0
 
 	for {
 		sc.logger.Debug("initializing camera stream")
 
-		// Send the start sequence to request an image
-		_, err := sc.port.Write(sc.startSeq)
+		// Send the start sequence to request an image, followed by the
+		// requested compression if any
+		requested := sc.Config().Compression
+		request := sc.startSeq
+		if requested != CompressionNone {
+			request = append(slices.Clone(sc.startSeq), byte(requested))
+		}
+		_, err := sc.port.Write(request)
 		if err != nil {
-			return nil, fmt.Errorf("failed to send start sequence: %w", err)
+			return fmt.Errorf("failed to send start sequence: %w", err)
 		}
 
 		// Read acknowledgement byte
 		ackBuffer := make([]byte, 1)
 		length, err := sc.port.Read(ackBuffer)
 		if err != nil {
-			return nil, fmt.Errorf("failed to read acknowledgement: %w", err)
+			return fmt.Errorf("failed to read acknowledgement: %w", err)
 		}
 
 		if length != 1 {
 			// If we didn't receive exactly one byte, try again
 			tries++
 			if tries > 4 {
-				return nil, fmt.Errorf("camera not responding properly after %d attempts", tries)
+				return fmt.Errorf("camera not responding properly after %d attempts", tries)
 			}
 
 			// Send end sequence to reset the camera
 			_, err := sc.port.Write(sc.endSeq)
 			if err != nil {
-				return nil, fmt.Errorf("failed to send end sequence: %w", err)
+				return fmt.Errorf("failed to send end sequence: %w", err)
 			}
 
 			time.Sleep(100 * time.Millisecond)
@@ -167,76 +282,157 @@ func (sc *SerialCamera) initializeStream(ctx context.Context, errChan chan error
 			continue
 		}
 
-		// Set up the cleanup function
-		sc.onClose = func() {
-			sc.logger.Debug("sending end sequence to camera")
-			_, err := sc.port.Write(sc.endSeq)
+		sc.compression = negotiatedCompression(requested, ackBuffer[0])
+		if sc.compression != requested {
+			sc.logger.Warn("camera does not support compression, reading raw frames",
+				"requested", requested, "ack", ackBuffer[0])
+		}
+
+		// The end sequence is sent when the camera is closed
+		sc.portMu.Lock()
+		sc.started = true
+		sc.portMu.Unlock()
+
+		return nil
+	}
+}
+
+// readFrames continuously reads frames and sends them to the image
+// channel until ctx ends or the port is lost, which it returns the error
+// of. Read errors make the camera degraded; a closed port or maxReadErrors
+// consecutive errors mean the port is lost.
+func (sc *SerialCamera) readFrames(ctx context.Context, outCh ImageChannel) error {
+	// Backoff parameters
+	initialBackoff := 10 * time.Millisecond
+	maxBackoff := 1 * time.Second
+	backoff := initialBackoff
+	consecutiveFailures := 0
+	readErrors := 0
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-sc.Context().Done():
+			return sc.Context().Err()
+		default:
+			if sc.IsPaused() {
+				time.Sleep(100 * time.Millisecond)
+
+				continue
+			}
+
+			img, err := sc.readFrame()
 			if err != nil {
-				sc.logger.Error("failed to send end sequence", "err", err)
+				readErrors++
+				if portLost(err) || errors.Is(err, errFrameMismatch) || readErrors >= maxReadErrors {
+					return err
+				}
+				sc.logger.Error("error in image stream", "err", err, "consecutiveErrors", readErrors)
+				sc.report(StateDegraded)
+				time.Sleep(500 * time.Millisecond) // Delay before retry
+
+				continue
 			}
-		}
+			readErrors = 0
 
-		// Return the reading function
-		return func() {
-			// Backoff parameters
-			initialBackoff := 10 * time.Millisecond
-			maxBackoff := 1 * time.Second
-			backoff := initialBackoff
-			consecutiveFailures := 0
-
-			for {
-				select {
-				case <-ctx.Done():
-					return
-				case <-sc.Context().Done():
-					return
-				default:
-					if sc.IsPaused() {
-						time.Sleep(100 * time.Millisecond)
-
-						continue
-					}
-
-					_, err := sc.readFrame()
-					if err != nil {
-						errChan <- err
-						time.Sleep(500 * time.Millisecond) // Delay before retry
-
-						continue
-					}
-
-					select {
-					case <-ctx.Done():
-						return
-					case <-sc.Context().Done():
-						return
-					default:
-						// Channel is full, apply backoff
-						consecutiveFailures++
-
-						// Only log at certain thresholds to prevent log spam
-						if consecutiveFailures == 1 || consecutiveFailures%10 == 0 {
-							sc.logger.Debug("output channel full, applying backoff",
-								"consecutiveFailures", consecutiveFailures,
-								"currentBackoff", backoff)
-						}
-
-						// Apply backoff delay
-						time.Sleep(backoff)
-
-						// Exponential backoff with a maximum cap
-						backoff = min(time.Duration(float64(backoff)*1.5), maxBackoff)
-					}
+			select {
+			case outCh <- img:
+				// Reset backoff on success
+				backoff = initialBackoff
+				consecutiveFailures = 0
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-sc.Context().Done():
+				return sc.Context().Err()
+			default:
+				// Channel is full, apply backoff
+				consecutiveFailures++
+
+				// Only log at certain thresholds to prevent log spam
+				if consecutiveFailures == 1 || consecutiveFailures%10 == 0 {
+					sc.logger.Debug("output channel full, applying backoff",
+						"consecutiveFailures", consecutiveFailures,
+						"currentBackoff", backoff)
 				}
+
+				// Apply backoff delay
+				time.Sleep(backoff)
+
+				// Exponential backoff with a maximum cap
+				backoff = min(time.Duration(float64(backoff)*1.5), maxBackoff)
 			}
-		}, nil
+		}
 	}
 }
 
-// readFrame reads a single image frame from the serial port, converts it to grayscale,
-// and returns it as an image.Gray. It handles timeouts and progress reporting.
+// readFrame reads a single image frame from the serial port in the camera's
+// protocol.
 func (sc *SerialCamera) readFrame() (*image.Gray, error) {
+	if sc.protocol == ProtocolFramed {
+		return sc.readFramedFrame()
+	}
+
+	return sc.readLegacyFrame()
+}
+
+// readFramedFrame reads the next frame of the framed protocol, skipping
+// corrupted frames.
+func (sc *SerialCamera) readFramedFrame() (*image.Gray, error) {
+	frames := sc.frameReader()
+	before := frames.Stats()
+	h, payload, err := frames.ReadFrame()
+	if err != nil {
+		return nil, fmt.Errorf("error reading from serial port: %w", err)
+	}
+	stats := frames.Stats()
+	if stats.Resyncs != before.Resyncs || stats.Lost != before.Lost {
+		sc.logger.Warn("resynchronized serial stream",
+			"seq", h.Seq,
+			"skippedBytes", stats.SkippedBytes-before.SkippedBytes,
+			"checksumErrors", stats.ChecksumErrors-before.ChecksumErrors,
+			"lost", stats.Lost-before.Lost)
+	}
+
+	config := sc.Config()
+	if config.Width != 0 && (h.Width != config.Width || h.Height != config.Height) {
+		return nil, fmt.Errorf("frame %d is %dx%d, configured for %dx%d: %w",
+			h.Seq, h.Width, h.Height, config.Width, config.Height, errFrameMismatch)
+	}
+	if config.Format != 0 && h.Format != config.Format {
+		return nil, fmt.Errorf("frame %d is %s, configured for %s: %w",
+			h.Seq, h.Format, config.Format, errFrameMismatch)
+	}
+	pix, err := decompress(h.Compression, payload, h.Size())
+	if err != nil {
+		return nil, fmt.Errorf("frame %d: %w", h.Seq, err)
+	}
+
+	return decodePixels(h.Format, config.BitDepth, h.Width, h.Height, pix)
+}
+
+// FrameStats returns the counters of the frames read with the framed
+// protocol.
+func (sc *SerialCamera) FrameStats() FrameReaderStats {
+	return sc.frameReader().Stats()
+}
+
+// frameReader returns the reader of framed frames of the current port.
+func (sc *SerialCamera) frameReader() *FrameReader {
+	sc.portMu.Lock()
+	defer sc.portMu.Unlock()
+
+	return sc.frames
+}
+
+// readLegacyFrame reads a single image frame of the configured size and
+// pixel format from the serial port, converts it to grayscale, and returns
+// it as an image.Gray. It handles timeouts and progress reporting.
+func (sc *SerialCamera) readLegacyFrame() (*image.Gray, error) {
 	sc.logger.Debug("reading image frame")
+	if sc.compression != CompressionNone {
+		return sc.readCompressedFrame()
+	}
 
 	// Use a timeout for the read operation
 	readCtx, cancel := context.WithTimeout(sc.Context(), 4*time.Minute)
@@ -244,7 +440,7 @@ func (sc *SerialCamera) readFrame() (*image.Gray, error) {
 
 	// Buffer to store image data
 	var buffer []byte
-	expectedLength := sc.imageWidth * sc.imageHeight
+	expectedLength := sc.imageWidth * sc.imageHeight * sc.format.BytesPerPixel()
 
 	// Monitor the read progress
 	progressDone := make(chan struct{})
@@ -271,7 +467,8 @@ func (sc *SerialCamera) readFrame() (*image.Gray, error) {
 
 	// Read the image data in chunks
 	for len(buffer) < expectedLength {
-		chunk := make([]byte, 1024)
+		// Leave the bytes of the next frame on the line
+		chunk := make([]byte, min(1024, expectedLength-len(buffer)))
 		n, err := sc.port.Read(chunk)
 		if err != nil {
 			close(progressDone)
@@ -298,31 +495,20 @@ func (sc *SerialCamera) readFrame() (*image.Gray, error) {
 	sc.logger.Info("image data read complete", "size", len(buffer))
 
 	// Create grayscale image from the buffer
-	img := image.NewGray(image.Rect(0, 0, sc.imageWidth, sc.imageHeight))
-
-	// Copy buffer data to image
-	for y := range sc.imageHeight {
-		for x := range sc.imageWidth {
-			i := y*sc.imageWidth + x
-			if i < len(buffer) {
-				img.SetGray(x, y, color.Gray{Y: buffer[i]})
-			}
-		}
-	}
-
-	// Save to $HOME/{type}.png
-	err := homedir.SaveImage(string(sc.cameraType)+".png", img)
-	if err != nil {
-		return nil, err
-	}
+	return decodePixels(sc.format, sc.bitDepth, sc.imageWidth, sc.imageHeight, buffer)
+}
 
-	// Save a copy of the image for debugging
-	err = homedir.SaveImage("stero-image-"+string(sc.cType)+"-"+time.Now().Format("2006-01-02-15-04-05")+".png", img)
+// readCompressedFrame reads a single compressed image frame of the
+// configured size from the serial port, decompressing it on the fly.
+func (sc *SerialCamera) readCompressedFrame() (*image.Gray, error) {
+	start := time.Now()
+	pix, err := readCompressed(sc.port, sc.compression, sc.imageWidth*sc.imageHeight*sc.format.BytesPerPixel())
 	if err != nil {
-		sc.logger.Error("failed to save debug image", "err", err)
+		return nil, fmt.Errorf("error reading compressed frame from serial port: %w", err)
 	}
+	sc.logger.Debug("compressed image data read complete", "size", len(pix), "elapsed", time.Since(start))
 
-	return img, nil
+	return decodePixels(sc.format, sc.bitDepth, sc.imageWidth, sc.imageHeight, pix)
 }
 
 // Close releases all resources used by the camera, including closing the serial port and
@@ -333,15 +519,21 @@ func (sc *SerialCamera) Close() error {
 	// Cancel the context to stop all operations
 	sc.Cancel()
 
-	// Execute onClose handler if set
-	if sc.onClose != nil {
-		sc.onClose()
+	sc.portMu.Lock()
+	defer sc.portMu.Unlock()
+	if sc.port == nil {
+		return nil
 	}
 
-	// Close the serial port
-	if sc.port != nil {
-		return sc.port.Close()
+	// Tell a started camera to stop sending
+	if sc.started {
+		sc.logger.Debug("sending end sequence to camera")
+		_, err := sc.port.Write(sc.endSeq)
+		if err != nil {
+			sc.logger.Error("failed to send end sequence", "err", err)
+		}
 	}
 
-	return nil
+	// Close the serial port
+	return sc.port.Close()
 }
@@ -0,0 +1,518 @@
+package camera
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/conneroisu/steroscopic-hardware/pkg/despair"
+	"go.bug.st/serial"
+	"go.bug.st/serial/enumerator"
+)
+
+// defaultSimulatorInterval is the time between the frames of a simulator,
+// the frame rate of a StaticCamera.
+const defaultSimulatorInterval = 100 * time.Millisecond
+
+// SimulatorDir is the directory where simulators serving pseudo-terminals
+// link them, so that ListPorts finds them.
+var SimulatorDir = filepath.Join(os.TempDir(), "steroscopic-simulators")
+
+// SimulatorOptions configures a Simulator.
+type SimulatorOptions struct {
+	// Protocol is the framing of the frames sent, ProtocolLegacy when empty.
+	Protocol Protocol
+	// Compressions are the compressions the simulated firmware acknowledges.
+	// It acknowledges any other requested compression as legacy firmware
+	// does and sends raw frames.
+	Compressions []Compression
+	// Width and Height are the size of the frames of the legacy protocol,
+	// DefaultImageWidth and DefaultImageHeight when zero. Frames of another
+	// size are cropped or padded with black.
+	Width, Height int
+	// Format is the pixel format of the frames, PixelGray8 when zero.
+	Format PixelFormat
+	// BitDepth is the number of significant bits of 16 bit samples, which
+	// are then sent in the least significant bits. Samples fill all 16 bits
+	// when it is zero.
+	BitDepth int
+	// Interval is the time between frames, 100ms when zero.
+	Interval time.Duration
+	// Latency delays the acknowledgement and every frame.
+	Latency time.Duration
+	// DropRate is the probability of each byte sent after the
+	// acknowledgement to be dropped.
+	DropRate float64
+	// CorruptRate is the probability of each byte sent after the
+	// acknowledgement to be corrupted.
+	CorruptRate float64
+	// Seed seeds the faults, so that a connection with the same seed drops
+	// and corrupts the same bytes.
+	Seed uint64
+}
+
+// Simulator simulates the firmware of a serial camera, so that a
+// SerialCamera can be tested without the hardware. It waits for the start
+// sequence, acknowledges it, possibly with a compression, and streams its
+// frames in turn until it receives the end sequence.
+type Simulator struct {
+	frames []*image.Gray
+	opts   SimulatorOptions
+	logger *slog.Logger
+
+	mu       sync.Mutex
+	payloads map[payloadKey][]byte // Encoded frames by frame and compression
+}
+
+// payloadKey identifies the encoding of a frame.
+type payloadKey struct {
+	frame       int
+	compression Compression
+}
+
+// NewSimulator creates a simulator streaming frames.
+func NewSimulator(frames []*image.Gray, opts SimulatorOptions) (*Simulator, error) {
+	if len(frames) == 0 {
+		return nil, errors.New("simulator has no frames")
+	}
+	protocol, err := ParseProtocol(string(opts.Protocol))
+	if err != nil {
+		return nil, err
+	}
+	opts.Protocol = protocol
+	for _, c := range opts.Compressions {
+		err = c.Validate()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if opts.DropRate < 0 || opts.DropRate > 1 || opts.CorruptRate < 0 || opts.CorruptRate > 1 {
+		return nil, fmt.Errorf("fault rates must be within [0, 1], got drop %v and corrupt %v",
+			opts.DropRate, opts.CorruptRate)
+	}
+	if opts.Width == 0 && opts.Height == 0 {
+		opts.Width, opts.Height = DefaultImageWidth, DefaultImageHeight
+	}
+	opts.Format = cmp.Or(opts.Format, PixelGray8)
+	err = Config{Width: opts.Width, Height: opts.Height, Format: opts.Format, BitDepth: opts.BitDepth}.ValidateFrame()
+	if err != nil {
+		return nil, err
+	}
+	if protocol == ProtocolFramed {
+		// Framed frames keep their size
+		for _, frame := range frames {
+			b := frame.Bounds()
+			err = Config{Width: b.Dx(), Height: b.Dy(), Format: opts.Format}.ValidateFrame()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = defaultSimulatorInterval
+	}
+
+	return &Simulator{
+		frames:   frames,
+		opts:     opts,
+		logger:   slog.Default().WithGroup("serial-simulator"),
+		payloads: map[payloadKey][]byte{},
+	}, nil
+}
+
+// LoadSimulator creates a simulator streaming the PNG images at paths.
+func LoadSimulator(paths []string, opts SimulatorOptions) (*Simulator, error) {
+	frames := make([]*image.Gray, 0, len(paths))
+	for _, path := range paths {
+		img, err := despair.LoadPNG(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", path, err)
+		}
+		frames = append(frames, img)
+	}
+
+	return NewSimulator(frames, opts)
+}
+
+// request is a command a simulator reads from the line.
+type request struct {
+	stop        bool
+	compression Compression
+}
+
+// Serve speaks the camera protocol on rw until ctx ends or reading rw
+// fails. It returns nil when ctx ends or rw reaches its end.
+//
+// A start sequence is expected to arrive in one read along with the
+// requested compression, as a SerialCamera writes them in one write.
+func (s *Simulator) Serve(ctx context.Context, rw io.ReadWriter) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	requests := make(chan request)
+	readErr := make(chan error, 1)
+	go func() {
+		readErr <- s.readRequests(ctx, rw, requests)
+	}()
+
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+	rng := newFaultRand(s.opts.Seed)
+	var (
+		streaming   bool
+		compression Compression
+		seq         uint32
+		frame       int
+	)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-readErr:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err
+		case req := <-requests:
+			if req.stop {
+				s.logger.Debug("end sequence received")
+				streaming = false
+
+				continue
+			}
+			if !sleepCtx(ctx, s.opts.Latency) {
+				return nil
+			}
+			ack := ackRaw
+			compression = CompressionNone
+			if req.compression != CompressionNone && slices.Contains(s.opts.Compressions, req.compression) {
+				ack = ackCompressed | byte(req.compression)
+				compression = req.compression
+			}
+			s.logger.Debug("start sequence received", "requested", req.compression, "compression", compression)
+			_, err := rw.Write([]byte{ack})
+			if err != nil {
+				return fmt.Errorf("failed to write acknowledgement: %w", err)
+			}
+			streaming = true
+		case <-ticker.C:
+			if !streaming {
+				continue
+			}
+			if !sleepCtx(ctx, s.opts.Latency) {
+				return nil
+			}
+			seq++
+			line, err := s.encode(frame, seq, compression)
+			frame = (frame + 1) % len(s.frames)
+			if err != nil {
+				s.logger.Warn("skipping frame", "seq", seq, "err", err)
+
+				continue
+			}
+			_, err = rw.Write(s.inject(rng, line))
+			if err != nil {
+				return fmt.Errorf("failed to write frame %d: %w", seq, err)
+			}
+		}
+	}
+}
+
+// readRequests reads start and end sequences from r and sends them on
+// requests.
+func (s *Simulator) readRequests(ctx context.Context, r io.Reader, requests chan<- request) error {
+	buf := make([]byte, 64)
+	for {
+		n, err := r.Read(buf)
+		if err != nil {
+			return err
+		}
+		chunk := buf[:n]
+		var reqs []request
+		if start, ok := bytes.CutPrefix(chunk, DefaultStartSeq); ok {
+			req := request{}
+			if len(start) > 0 {
+				req.compression = Compression(start[0])
+			}
+			reqs = append(reqs, req)
+		}
+		if bytes.Contains(chunk, DefaultEndSeq) {
+			reqs = append(reqs, request{stop: true})
+		}
+		for _, req := range reqs {
+			select {
+			case requests <- req:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+}
+
+// encode returns the bytes on the line of a frame.
+func (s *Simulator) encode(frame int, seq uint32, c Compression) ([]byte, error) {
+	payload, err := s.payload(frame, c)
+	if s.opts.Protocol == ProtocolLegacy {
+		return payload, err
+	}
+	if err != nil {
+		// The header tells the camera the frame is not compressed
+		s.logger.Debug("sending frame uncompressed", "seq", seq, "err", err)
+		c = CompressionNone
+		payload, _ = s.payload(frame, c)
+	}
+	b := s.frames[frame].Bounds()
+	h := FrameHeader{Seq: seq, Width: b.Dx(), Height: b.Dy(), Format: s.opts.Format, Compression: c}
+
+	return AppendFrame(nil, h, payload), nil
+}
+
+// payload returns the pixels of a frame, compressed with c and framed as
+// the protocol wants them, encoding them on first use.
+func (s *Simulator) payload(frame int, c Compression) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := payloadKey{frame: frame, compression: c}
+	if payload, ok := s.payloads[key]; ok {
+		return payload, nil
+	}
+
+	img := s.frames[frame]
+	var pix []byte
+	if s.opts.Protocol == ProtocolLegacy {
+		pix = fitPixels(img, s.opts.Width, s.opts.Height)
+	} else {
+		pix = fitPixels(img, img.Rect.Dx(), img.Rect.Dy())
+	}
+	pix = encodePixels(s.opts.Format, s.opts.BitDepth, pix)
+	var (
+		payload []byte
+		err     error
+	)
+	switch {
+	case c == CompressionNone:
+		payload = pix
+	case s.opts.Protocol == ProtocolLegacy:
+		payload, err = AppendCompressed(nil, c, pix)
+	default:
+		payload, err = Compress(c, pix)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to compress frame %d: %w", frame, err)
+	}
+	s.payloads[key] = payload
+
+	return payload, nil
+}
+
+// fitPixels returns the pixels of img cropped or padded with black to a
+// width and height.
+func fitPixels(img *image.Gray, width, height int) []byte {
+	pix := make([]byte, width*height)
+	b := img.Bounds()
+	w := min(width, b.Dx())
+	for y := range min(height, b.Dy()) { // y := 0; y < min(height, b.Dy()); y++
+		start := img.PixOffset(b.Min.X, b.Min.Y+y)
+		copy(pix[y*width:], img.Pix[start:start+w])
+	}
+
+	return pix
+}
+
+// newFaultRand returns the source of the faults of a connection.
+func newFaultRand(seed uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(seed, seed))
+}
+
+// inject drops and corrupts the bytes of line with the configured rates.
+func (s *Simulator) inject(rng *rand.Rand, line []byte) []byte {
+	if s.opts.DropRate == 0 && s.opts.CorruptRate == 0 {
+		return line
+	}
+	out := make([]byte, 0, len(line))
+	for _, b := range line {
+		if rng.Float64() < s.opts.DropRate {
+			continue
+		}
+		if rng.Float64() < s.opts.CorruptRate {
+			b ^= byte(1 + rng.IntN(255))
+		}
+		out = append(out, b)
+	}
+
+	return out
+}
+
+// sleepCtx sleeps for d, reporting false if ctx ends first.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// Port returns an in-memory serial port served by the simulator until ctx
+// ends or the port is closed.
+func (s *Simulator) Port(ctx context.Context) serial.Port {
+	host, device := net.Pipe()
+	go func() {
+		defer device.Close()
+		err := s.Serve(ctx, device)
+		if err != nil && !errors.Is(err, io.ErrClosedPipe) {
+			s.logger.Error("simulator stopped", "err", err)
+		}
+	}()
+
+	return &simulatedPort{Conn: host}
+}
+
+// ServePTY serves the simulator on a new pseudo-terminal until ctx ends,
+// calling ready with the name of its device, which a SerialCamera opens as
+// any serial port. When name is not empty, the device is linked as name in
+// SimulatorDir while it is served, so that ListPorts lists it.
+func (s *Simulator) ServePTY(ctx context.Context, name string, ready func(device string)) error {
+	master, device, err := openPTY()
+	if err != nil {
+		return fmt.Errorf("failed to open pseudo-terminal: %w", err)
+	}
+	defer master.Close()
+	go func() {
+		// Closing the terminal ends the reads of Serve
+		<-ctx.Done()
+		master.Close()
+	}()
+
+	if name != "" {
+		err = os.MkdirAll(SimulatorDir, 0o755)
+		if err != nil {
+			return fmt.Errorf("failed to create simulator directory: %w", err)
+		}
+		link := filepath.Join(SimulatorDir, name)
+		_ = os.Remove(link)
+		err = os.Symlink(device, link)
+		if err != nil {
+			return fmt.Errorf("failed to link simulator: %w", err)
+		}
+		defer func(target string) {
+			// Another simulator may have taken the name since
+			if current, _ := os.Readlink(link); current == target {
+				os.Remove(link)
+			}
+		}(device)
+		device = link
+	}
+	ready(device)
+
+	err = s.Serve(ctx, master)
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	return err
+}
+
+// ListPorts lists the serial ports, as enumerator.GetDetailedPortsList,
+// followed by the pseudo-terminals of the simulators linked in
+// SimulatorDir.
+func ListPorts() ([]*enumerator.PortDetails, error) {
+	ports, err := enumerator.GetDetailedPortsList()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(SimulatorDir)
+	if err != nil {
+		// No simulator ever ran
+		return ports, nil
+	}
+	for _, entry := range entries {
+		link := filepath.Join(SimulatorDir, entry.Name())
+		_, err := os.Stat(link)
+		if err != nil {
+			// The simulator stopped without removing its link
+			continue
+		}
+		ports = append(ports, &enumerator.PortDetails{Name: link, Product: "simulator"})
+	}
+
+	return ports, nil
+}
+
+// simulatedPort is the in-memory serial port of a simulator. Its settings
+// are accepted and ignored, except for the read timeout.
+type simulatedPort struct {
+	net.Conn
+	timeout atomic.Int64
+}
+
+// Read implements serial.Port, returning no bytes when the read timeout
+// expires as serial ports do.
+func (p *simulatedPort) Read(b []byte) (int, error) {
+	var deadline time.Time
+	if timeout := time.Duration(p.timeout.Load()); timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	err := p.SetReadDeadline(deadline)
+	if err != nil {
+		return 0, err
+	}
+	n, err := p.Conn.Read(b)
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return n, nil
+	}
+
+	return n, err
+}
+
+// SetReadTimeout implements serial.Port.
+func (p *simulatedPort) SetReadTimeout(t time.Duration) error {
+	p.timeout.Store(int64(t))
+
+	return nil
+}
+
+// SetMode implements serial.Port.
+func (p *simulatedPort) SetMode(*serial.Mode) error { return nil }
+
+// Drain implements serial.Port.
+func (p *simulatedPort) Drain() error { return nil }
+
+// ResetInputBuffer implements serial.Port.
+func (p *simulatedPort) ResetInputBuffer() error { return nil }
+
+// ResetOutputBuffer implements serial.Port.
+func (p *simulatedPort) ResetOutputBuffer() error { return nil }
+
+// SetDTR implements serial.Port.
+func (p *simulatedPort) SetDTR(bool) error { return nil }
+
+// SetRTS implements serial.Port.
+func (p *simulatedPort) SetRTS(bool) error { return nil }
+
+// GetModemStatusBits implements serial.Port.
+func (p *simulatedPort) GetModemStatusBits() (*serial.ModemStatusBits, error) {
+	return &serial.ModemStatusBits{CTS: true, DSR: true}, nil
+}
+
+// Break implements serial.Port.
+func (p *simulatedPort) Break(time.Duration) error { return nil }
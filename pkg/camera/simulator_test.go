@@ -0,0 +1,287 @@
+package camera
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"go.bug.st/serial"
+	"go.bug.st/serial/enumerator"
+)
+
+// testImage returns a gradient image, which compresses well.
+func testImage(width, height int, shift byte) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = byte(i/64) + shift
+	}
+
+	return img
+}
+
+// simulatedCamera returns a serial camera reading the in-memory port of a
+// simulator.
+func simulatedCamera(ctx context.Context, t *testing.T, sim *Simulator, config Config) *SerialCamera {
+	t.Helper()
+	open := func(string, *serial.Mode) (serial.Port, error) { return sim.Port(ctx), nil }
+	list := func() ([]*enumerator.PortDetails, error) { return nil, nil }
+	config.Port = "simulator"
+	sc, err := newSerialCamera(ctx, LeftCameraType, config, open, list)
+	if err != nil {
+		t.Fatalf("newSerialCamera() error = %v", err)
+	}
+	t.Cleanup(func() { sc.Close() })
+
+	return sc
+}
+
+// receive returns the next n frames of a camera.
+func receive(ctx context.Context, t *testing.T, sc *SerialCamera, n int) []*image.Gray {
+	t.Helper()
+	frames := make(ImageChannel, n)
+	go sc.Stream(ctx, frames)
+	var got []*image.Gray
+	for range n {
+		select {
+		case img := <-frames:
+			got = append(got, img)
+		case <-ctx.Done():
+			t.Fatalf("received %d frames, want %d", len(got), n)
+		}
+	}
+
+	return got
+}
+
+func TestSimulatorStreams(t *testing.T) {
+	frames := []*image.Gray{testImage(64, 48, 0), testImage(64, 48, 100)}
+	tests := []struct {
+		name         string
+		protocol     Protocol
+		format       PixelFormat
+		depth        int
+		compressions []Compression
+		requested    Compression
+		want         Compression
+	}{
+		{"legacy", ProtocolLegacy, PixelGray8, 0, nil, CompressionNone, CompressionNone},
+		{"legacy lzma", ProtocolLegacy, PixelGray8, 0, []Compression{CompressionLZMA}, CompressionLZMA, CompressionLZMA},
+		{"legacy firmware", ProtocolLegacy, PixelGray8, 0, nil, CompressionLZMA, CompressionNone},
+		{"legacy gray16", ProtocolLegacy, PixelGray16LE, 0, []Compression{CompressionLZMA}, CompressionLZMA, CompressionLZMA},
+		{"framed", ProtocolFramed, PixelGray8, 0, nil, CompressionNone, CompressionNone},
+		{"framed range", ProtocolFramed, PixelGray8, 0, []Compression{CompressionRange}, CompressionRange, CompressionRange},
+		{"framed gray16", ProtocolFramed, PixelGray16BE, 0, nil, CompressionNone, CompressionNone},
+		{"legacy 10 bit", ProtocolLegacy, PixelGray16LE, 10, nil, CompressionNone, CompressionNone},
+		{"framed 12 bit", ProtocolFramed, PixelGray16BE, 12, []Compression{CompressionLZMA}, CompressionLZMA, CompressionLZMA},
+		{"other compression", ProtocolFramed, PixelGray8, 0, []Compression{CompressionRange}, CompressionLZMA, CompressionNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim, err := NewSimulator(frames, SimulatorOptions{
+				Protocol:     tt.protocol,
+				Compressions: tt.compressions,
+				Width:        64,
+				Height:       48,
+				Format:       tt.format,
+				BitDepth:     tt.depth,
+				Interval:     time.Millisecond,
+			})
+			if err != nil {
+				t.Fatalf("NewSimulator() error = %v", err)
+			}
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+			sc := simulatedCamera(ctx, t, sim, Config{
+				Protocol:    tt.protocol,
+				Compression: tt.requested,
+				Width:       64,
+				Height:      48,
+				Format:      tt.format,
+				BitDepth:    tt.depth,
+			})
+
+			got := receive(ctx, t, sc, 3)
+			if sc.compression != tt.want {
+				t.Errorf("negotiated compression = %v, want %v", sc.compression, tt.want)
+			}
+			for i, img := range got {
+				if !bytes.Equal(img.Pix, frames[i%2].Pix) {
+					t.Errorf("frame %d differs from the simulated frame", i)
+				}
+			}
+		})
+	}
+}
+
+// A camera fails when the frames it is sent do not match its configuration.
+func TestSimulatorFrameMismatch(t *testing.T) {
+	sim, err := NewSimulator([]*image.Gray{testImage(64, 48, 0)}, SimulatorOptions{
+		Protocol: ProtocolFramed,
+		Format:   PixelGray16LE,
+		Interval: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewSimulator() error = %v", err)
+	}
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{"size", Config{Protocol: ProtocolFramed, Width: 640, Height: 480}},
+		{"format", Config{Protocol: ProtocolFramed, Format: PixelGray8}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+			defer cancel()
+			sc := simulatedCamera(ctx, t, sim, tt.config)
+			var last State
+			sc.setStateFunc(func(s State) { last = s })
+			sc.Stream(ctx, make(ImageChannel, 1))
+			if last != StateFailed {
+				t.Errorf("last reported state = %v, want %v", last, StateFailed)
+			}
+		})
+	}
+}
+
+func TestSimulatorFitsLegacyFrames(t *testing.T) {
+	img := testImage(4, 3, 1)
+	got := fitPixels(img, 3, 4)
+	want := []byte{1, 1, 1, 1, 1, 1, 1, 1, 1, 0, 0, 0}
+	if !bytes.Equal(got, want) {
+		t.Errorf("fitPixels() = %v, want %v", got, want)
+	}
+}
+
+// A framed camera resynchronizes on the frames the simulator corrupts.
+func TestSimulatorFaults(t *testing.T) {
+	frame := testImage(64, 48, 0)
+	sim, err := NewSimulator([]*image.Gray{frame}, SimulatorOptions{
+		Protocol:    ProtocolFramed,
+		Interval:    time.Millisecond,
+		Latency:     time.Millisecond,
+		DropRate:    1e-4,
+		CorruptRate: 1e-4,
+	})
+	if err != nil {
+		t.Fatalf("NewSimulator() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+	defer cancel()
+	sc := simulatedCamera(ctx, t, sim, Config{Protocol: ProtocolFramed})
+
+	// The stats are read while the camera streams, as the API reads them
+	stop, polled := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(polled)
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(time.Millisecond):
+				sc.FrameStats()
+			}
+		}
+	}()
+	for i, img := range receive(ctx, t, sc, 20) {
+		if !bytes.Equal(img.Pix, frame.Pix) {
+			t.Errorf("frame %d differs from the simulated frame", i)
+		}
+	}
+	close(stop)
+	<-polled
+	stats := sc.FrameStats()
+	if stats.ChecksumErrors+stats.Resyncs == 0 {
+		t.Errorf("FrameStats() = %+v, want corrupted frames", stats)
+	}
+}
+
+func TestSimulatorInjectIsSeeded(t *testing.T) {
+	line := testPixels(4096)
+	opts := SimulatorOptions{DropRate: 0.01, CorruptRate: 0.01, Seed: 7}
+	var got [2][]byte
+	for i := range got {
+		sim, err := NewSimulator([]*image.Gray{testImage(1, 1, 0)}, opts)
+		if err != nil {
+			t.Fatalf("NewSimulator() error = %v", err)
+		}
+		got[i] = sim.inject(newFaultRand(opts.Seed), line)
+	}
+	if !bytes.Equal(got[0], got[1]) {
+		t.Error("faults differ with the same seed")
+	}
+	if len(got[0]) >= len(line) || bytes.Equal(got[0], line[:len(got[0])]) {
+		t.Error("no bytes were dropped and corrupted")
+	}
+}
+
+// A serial camera opens the pseudo-terminal of a simulator as any port.
+func TestSimulatorPTY(t *testing.T) {
+	if _, err := os.Stat("/dev/ptmx"); err != nil {
+		t.Skip("no pseudo-terminals:", err)
+	}
+	dir := SimulatorDir
+	SimulatorDir = t.TempDir()
+	t.Cleanup(func() { SimulatorDir = dir })
+	frame := testImage(32, 24, 0)
+	sim, err := NewSimulator([]*image.Gray{frame}, SimulatorOptions{
+		Protocol:     ProtocolFramed,
+		Compressions: []Compression{CompressionLZMA},
+		Interval:     time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewSimulator() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	devices := make(chan string, 1)
+	served := make(chan error, 1)
+	go func() {
+		served <- sim.ServePTY(ctx, "left", func(device string) { devices <- device })
+	}()
+	var device string
+	select {
+	case device = <-devices:
+	case err := <-served:
+		t.Skip("no pseudo-terminals:", err)
+	}
+	if want := filepath.Join(SimulatorDir, "left"); device != want {
+		t.Errorf("simulator served on %s, want %s", device, want)
+	}
+	ports, err := ListPorts()
+	if err == nil && !slices.ContainsFunc(ports, func(p *enumerator.PortDetails) bool {
+		return p.Name == device
+	}) {
+		t.Errorf("ListPorts() does not list the simulator")
+	}
+
+	sc, err := NewSerialCamera(ctx, LeftCameraType, Config{
+		Port:        device,
+		BaudRate:    115200,
+		Protocol:    ProtocolFramed,
+		Compression: CompressionLZMA,
+	})
+	if err != nil {
+		t.Fatalf("NewSerialCamera() error = %v", err)
+	}
+	defer sc.Close()
+	got := receive(ctx, t, sc, 2)
+	if !bytes.Equal(got[1].Pix, frame.Pix) {
+		t.Error("frame differs from the simulated frame")
+	}
+
+	cancel()
+	err = <-served
+	if err != nil {
+		t.Errorf("ServePTY() error = %v", err)
+	}
+	if _, err := os.Lstat(device); !os.IsNotExist(err) {
+		t.Errorf("simulator link left behind: %v", err)
+	}
+}
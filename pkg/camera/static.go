@@ -10,8 +10,6 @@ import (
 	"os"
 	"path/filepath"
 	"time"
-
-	"github.com/conneroisu/steroscopic-hardware/pkg/homedir"
 )
 
 // StaticCamera represents a camera that loads images from files. It is useful for testing
@@ -32,9 +30,14 @@ func NewStaticCamera(ctx context.Context, path string, typ Type) *StaticCamera {
 	}
 }
 
+// Path returns the path of the image the camera streams.
+func (sc *StaticCamera) Path() string {
+	return sc.path
+}
+
 // Stream continuously reads the static image and sends it to the output channel at a fixed
 // interval. Useful for simulating a live camera feed.
-func (sc *StaticCamera) Stream(ctx context.Context, _ ImageChannel) {
+func (sc *StaticCamera) Stream(ctx context.Context, outCh ImageChannel) {
 	sc.logger.Info("starting static camera stream", "path", sc.path)
 	defer sc.logger.Info("static camera stream stopped")
 
@@ -60,7 +63,7 @@ func (sc *StaticCamera) Stream(ctx context.Context, _ ImageChannel) {
 			}
 
 			// Load image file
-			_, err := sc.loadImage()
+			img, err := sc.loadImage()
 			if err != nil {
 				select {
 				case errChan <- err:
@@ -70,6 +73,17 @@ func (sc *StaticCamera) Stream(ctx context.Context, _ ImageChannel) {
 
 				continue
 			}
+
+			// Hand the frame to the manager for stereo pairing
+			if outCh != nil {
+				select {
+				case outCh <- img:
+				case <-ctx.Done():
+					return
+				case <-sc.Context().Done():
+					return
+				}
+			}
 		}
 	}
 }
@@ -125,7 +139,7 @@ func (sc *StaticCamera) loadImage() (*image.Gray, error) {
 					// Use integer arithmetic
 					grayPix[rowStart+x-bounds.Min.X] = uint8((19595*uint32(r) +
 						38470*uint32(g) +
-						7471*uint32(b) + 1<<15) >> 24)
+						7471*uint32(b) + 1<<15) >> 16)
 				}
 			}
 		default:
@@ -139,11 +153,6 @@ func (sc *StaticCamera) loadImage() (*image.Gray, error) {
 				}
 			}
 		}
-		// save to $HOME/{type}.png
-		err = homedir.SaveImage(string(sc.cType)+".png", grayImg)
-		if err != nil {
-			return nil, err
-		}
 	case ".jpg", ".jpeg":
 		// Open the file
 		file, err = os.Open(sc.path)
@@ -166,12 +175,6 @@ func (sc *StaticCamera) loadImage() (*image.Gray, error) {
 				grayImg.Set(x, y, color.GrayModel.Convert(img.At(x, y)))
 			}
 		}
-
-		// Save to $HOME/{type}.jpg
-		err := homedir.SaveImage(string(sc.cType)+".png", grayImg)
-		if err != nil {
-			return nil, err
-		}
 	default:
 		return nil, fmt.Errorf("unsupported image format: %s", ext)
 	}
@@ -0,0 +1,148 @@
+package camera
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go.bug.st/serial"
+	"go.bug.st/serial/enumerator"
+)
+
+const (
+	// reconnectInitialBackoff is the delay before the first attempt to
+	// reopen a lost port.
+	reconnectInitialBackoff = 500 * time.Millisecond
+	// reconnectMaxBackoff caps the delay between attempts to reopen a lost
+	// port.
+	reconnectMaxBackoff = 30 * time.Second
+	// maxReconnectAttempts is the number of failed attempts to reopen a
+	// lost port after which a serial camera gives up.
+	maxReconnectAttempts = 20
+	// maxReadErrors is the number of consecutive failed frame reads after
+	// which a port that is still open is considered lost.
+	maxReadErrors = 5
+)
+
+// portLost reports whether a read error means the port is gone, as when
+// its USB adapter is unplugged: serial ports report reads of a disconnected
+// port as a *serial.PortError of code serial.PortClosed.
+func portLost(err error) bool {
+	var portErr interface{ Code() serial.PortErrorCode }
+
+	return errors.As(err, &portErr) && portErr.Code() == serial.PortClosed
+}
+
+// findPort returns the name of the port of a camera among the listed
+// ports. A port with the camera's serial number, or its vendor and product
+// IDs when it has none, is the camera's port; without a serial number a
+// port of another name is only taken when it is the only match, since two
+// adapters of the same model are otherwise indistinguishable. Ports without
+// a USB identity are only found by name.
+func findPort(ports []*enumerator.PortDetails, config Config) (string, bool) {
+	if config.VID == "" && config.PID == "" && config.SerialNumber == "" {
+		i := slices.IndexFunc(ports, func(p *enumerator.PortDetails) bool {
+			return p.Name == config.Port
+		})
+		if i < 0 {
+			return "", false
+		}
+
+		return config.Port, true
+	}
+
+	var matches []string
+	for _, p := range ports {
+		if !p.IsUSB ||
+			!strings.EqualFold(p.VID, config.VID) ||
+			!strings.EqualFold(p.PID, config.PID) ||
+			p.SerialNumber != config.SerialNumber {
+			continue
+		}
+		if p.Name == config.Port {
+			return p.Name, true
+		}
+		matches = append(matches, p.Name)
+	}
+	if len(matches) == 1 || len(matches) > 0 && config.SerialNumber != "" {
+		return matches[0], true
+	}
+
+	return "", false
+}
+
+// reconnect closes the lost port and reopens the camera's port, found again
+// by its USB identity, with exponential backoff. It reports whether the
+// port was reopened; it gives up after maxReconnectAttempts or when ctx or
+// the camera's context ends.
+func (sc *SerialCamera) reconnect(ctx context.Context) bool {
+	sc.report(StateReconnecting)
+	sc.closePort()
+
+	backoff := sc.backoff
+	for attempt := 1; attempt <= maxReconnectAttempts; attempt++ {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-sc.Context().Done():
+			return false
+		case <-time.After(backoff):
+		}
+
+		err := sc.reopen()
+		if err == nil {
+			sc.logger.Info("serial port reconnected", "port", sc.Config().Port, "attempt", attempt)
+
+			return true
+		}
+		sc.logger.Warn("failed to reconnect serial port",
+			"attempt", attempt,
+			"err", err,
+			"retryIn", backoff)
+		backoff = min(2*backoff, reconnectMaxBackoff)
+	}
+
+	return false
+}
+
+// reopen finds the camera's port and opens it, following it to a new name.
+func (sc *SerialCamera) reopen() error {
+	ports, err := sc.list()
+	if err != nil {
+		return fmt.Errorf("failed to list serial ports: %w", err)
+	}
+	config := sc.Config()
+	name, ok := findPort(ports, config)
+	if !ok {
+		return fmt.Errorf("serial port %s not found", config.Port)
+	}
+	err = sc.openPort(name)
+	if err != nil {
+		return err
+	}
+	if name != config.Port {
+		sc.logger.Info("serial port renamed", "from", config.Port, "to", name)
+		config.Port = name
+		sc.SetConfig(config)
+	}
+
+	return nil
+}
+
+// closePort closes the camera's port, if open.
+func (sc *SerialCamera) closePort() {
+	sc.portMu.Lock()
+	defer sc.portMu.Unlock()
+	if sc.port == nil {
+		return
+	}
+	err := sc.port.Close()
+	if err != nil {
+		sc.logger.Debug("failed to close lost serial port", "err", err)
+	}
+	sc.port = nil
+	sc.started = false
+}
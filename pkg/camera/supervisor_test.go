@@ -0,0 +1,231 @@
+package camera
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"go.bug.st/serial"
+	"go.bug.st/serial/enumerator"
+)
+
+// closedError is the error reads of a disconnected port fail with.
+type closedError struct{}
+
+func (closedError) Error() string              { return "port has been closed" }
+func (closedError) Code() serial.PortErrorCode { return serial.PortClosed }
+
+// fakePort is a serial port that acknowledges the start sequence and then
+// sends frames until it runs out, after which it is unplugged or, when
+// hold is set, blocks until closed.
+type fakePort struct {
+	serial.Port
+	mu     sync.Mutex
+	data   bytes.Buffer
+	frames [][]byte
+	hold   bool
+	closed chan struct{}
+}
+
+func newFakePort(hold bool, frames ...[]byte) *fakePort {
+	return &fakePort{frames: frames, hold: hold, closed: make(chan struct{})}
+}
+
+func (p *fakePort) SetReadTimeout(time.Duration) error { return nil }
+
+func (p *fakePort) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if bytes.HasPrefix(b, DefaultStartSeq) {
+		p.data.WriteByte(ackRaw)
+		for _, f := range p.frames {
+			p.data.Write(f)
+		}
+	}
+
+	return len(b), nil
+}
+
+func (p *fakePort) Read(b []byte) (int, error) {
+	p.mu.Lock()
+	if p.data.Len() > 0 {
+		defer p.mu.Unlock()
+
+		return p.data.Read(b)
+	}
+	p.mu.Unlock()
+	if !p.hold {
+		return 0, closedError{}
+	}
+	<-p.closed
+
+	return 0, closedError{}
+}
+
+func (p *fakePort) Close() error {
+	select {
+	case <-p.closed:
+	default:
+		close(p.closed)
+	}
+
+	return nil
+}
+
+func testFrame(seq uint32) []byte {
+	h := FrameHeader{Seq: seq, Width: 4, Height: 2, Format: PixelGray8}
+
+	return AppendFrame(nil, h, make([]byte, 8))
+}
+
+func usbPort(name, serialNumber string) *enumerator.PortDetails {
+	return &enumerator.PortDetails{Name: name, IsUSB: true, VID: "0403", PID: "6001", SerialNumber: serialNumber}
+}
+
+func TestFindPort(t *testing.T) {
+	usb := Config{Port: "/dev/ttyUSB0", VID: "0403", PID: "6001"}
+	withSerial := usb
+	withSerial.SerialNumber = "B"
+	tests := []struct {
+		name   string
+		ports  []*enumerator.PortDetails
+		config Config
+		want   string
+		found  bool
+	}{
+		{"same name", []*enumerator.PortDetails{usbPort("/dev/ttyUSB1", ""), usbPort("/dev/ttyUSB0", "")}, usb, "/dev/ttyUSB0", true},
+		{"renamed", []*enumerator.PortDetails{usbPort("/dev/ttyUSB1", "")}, usb, "/dev/ttyUSB1", true},
+		{"ambiguous", []*enumerator.PortDetails{usbPort("/dev/ttyUSB1", ""), usbPort("/dev/ttyUSB2", "")}, usb, "", false},
+		{"serial number", []*enumerator.PortDetails{usbPort("/dev/ttyUSB0", "A"), usbPort("/dev/ttyUSB2", "B")}, withSerial, "/dev/ttyUSB2", true},
+		{"unplugged", nil, usb, "", false},
+		{"not usb", []*enumerator.PortDetails{{Name: "/dev/ttyS0"}}, Config{Port: "/dev/ttyS0"}, "/dev/ttyS0", true},
+		{"not usb renamed", []*enumerator.PortDetails{{Name: "/dev/ttyS1"}}, Config{Port: "/dev/ttyS0"}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := findPort(tt.ports, tt.config)
+			if got != tt.want || found != tt.found {
+				t.Errorf("findPort() = %q, %v, want %q, %v", got, found, tt.want, tt.found)
+			}
+		})
+	}
+}
+
+func TestPortLost(t *testing.T) {
+	if !portLost(closedError{}) {
+		t.Error("portLost() = false for a closed port")
+	}
+	if portLost(io.ErrUnexpectedEOF) {
+		t.Error("portLost() = true for a short read")
+	}
+}
+
+// An unplugged camera is found again under its new name and streams again.
+func TestSerialCameraReconnects(t *testing.T) {
+	first := newFakePort(false, testFrame(1))
+	second := newFakePort(true, testFrame(2))
+	var mu sync.Mutex
+	ports := []*enumerator.PortDetails{usbPort("/dev/ttyUSB0", "")}
+	open := func(name string, _ *serial.Mode) (serial.Port, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch name {
+		case "/dev/ttyUSB0":
+			// The port is gone once opened, plugged back in as ttyUSB1
+			ports = []*enumerator.PortDetails{usbPort("/dev/ttyUSB1", "")}
+
+			return first, nil
+		case "/dev/ttyUSB1":
+			return second, nil
+		}
+
+		return nil, errors.New("no such port")
+	}
+	list := func() ([]*enumerator.PortDetails, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return ports, nil
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	config := Config{Port: "/dev/ttyUSB0", BaudRate: 115200, Protocol: ProtocolFramed}
+	sc, err := newSerialCamera(ctx, LeftCameraType, config, open, list)
+	if err != nil {
+		t.Fatalf("newSerialCamera() error = %v", err)
+	}
+	if got := sc.Config(); got.VID != "0403" || got.PID != "6001" {
+		t.Errorf("camera USB identity = %s:%s, want 0403:6001", got.VID, got.PID)
+	}
+	sc.backoff = time.Millisecond
+	states := make(chan State, 16)
+	sc.setStateFunc(func(s State) { states <- s })
+
+	frames := make(ImageChannel, 4)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sc.Stream(ctx, frames)
+	}()
+
+	for range 2 {
+		select {
+		case <-frames:
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for frames")
+		}
+	}
+	if got := sc.Config().Port; got != "/dev/ttyUSB1" {
+		t.Errorf("camera port = %s, want /dev/ttyUSB1", got)
+	}
+	err = sc.Close()
+	if err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	<-done
+
+	close(states)
+	var got []State
+	for s := range states {
+		got = append(got, s)
+	}
+	want := []State{StateConnected, StateReconnecting, StateConnected}
+	if !slices.Equal(got, want) {
+		t.Errorf("reported states %v, want %v", got, want)
+	}
+}
+
+// A camera whose port does not come back gives up.
+func TestSerialCameraGivesUp(t *testing.T) {
+	port := newFakePort(false)
+	listed := true
+	open := func(string, *serial.Mode) (serial.Port, error) { return port, nil }
+	list := func() ([]*enumerator.PortDetails, error) {
+		if listed {
+			listed = false
+
+			return []*enumerator.PortDetails{usbPort("/dev/ttyUSB0", "")}, nil
+		}
+
+		return nil, nil
+	}
+
+	sc, err := newSerialCamera(t.Context(), LeftCameraType, Config{Port: "/dev/ttyUSB0"}, open, list)
+	if err != nil {
+		t.Fatalf("newSerialCamera() error = %v", err)
+	}
+	defer sc.Close()
+	sc.backoff = time.Nanosecond
+	var last State
+	sc.setStateFunc(func(s State) { last = s })
+	sc.Stream(t.Context(), make(ImageChannel, 1))
+	if last != StateFailed {
+		t.Errorf("last reported state = %v, want %v", last, StateFailed)
+	}
+}
@@ -2,6 +2,7 @@ package camera
 
 import (
 	"context"
+	"fmt"
 	"image"
 )
 
@@ -22,9 +23,50 @@ const (
 
 // Config represents all configurable camera parameters, such as serial port, baud rate, and compression.
 type Config struct {
-	Port        string // Serial port name or identifier
-	BaudRate    int    // Baud rate for serial communication
-	Compression int    // Compression level or mode
+	Port     string `json:"port"`     // Serial port name or identifier
+	BaudRate int    `json:"baudRate"` // Baud rate for serial communication
+	// Compression is the compression to request from the camera; the camera
+	// may fall back to CompressionNone.
+	Compression Compression `json:"compression"`
+	// Protocol is the framing of serial frames, ProtocolLegacy when empty.
+	Protocol Protocol `json:"protocol,omitempty"`
+	// VID, PID and SerialNumber are the USB identity of the serial port,
+	// used to find the port again when it is lost. They are looked up from
+	// the port when empty.
+	VID          string `json:"vid,omitempty"`
+	PID          string `json:"pid,omitempty"`
+	SerialNumber string `json:"serialNumber,omitempty"`
+	// Width and Height are the size of the frames. The legacy protocol
+	// reads frames of DefaultImageWidth by DefaultImageHeight when they are
+	// zero; the framed protocol rejects frames of another size unless they
+	// are zero.
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+	// Format is the pixel format of the frames. The legacy protocol reads
+	// PixelGray8 when it is zero; the framed protocol rejects frames of
+	// another format unless it is zero.
+	Format PixelFormat `json:"format,omitempty"`
+	// BitDepth is the number of significant bits of 16 bit samples, for
+	// sensors that send them in the least significant bits, as many 10 and
+	// 12 bit sensors do. Samples fill all 16 bits when it is zero.
+	BitDepth int `json:"bitDepth,omitempty"`
+}
+
+// ValidateFrame reports whether the frame size and pixel format of the
+// configuration can be read.
+func (c Config) ValidateFrame() error {
+	if (c.Width != 0 || c.Height != 0) &&
+		(c.Width <= 0 || c.Height <= 0 || c.Width > MaxFrameDimension || c.Height > MaxFrameDimension) {
+		return fmt.Errorf("invalid frame size %dx%d", c.Width, c.Height)
+	}
+	if c.BitDepth != 0 && (c.BitDepth < 8 || c.BitDepth > 16) {
+		return fmt.Errorf("invalid bit depth %d, want 8 to 16", c.BitDepth)
+	}
+	if c.Format == 0 {
+		return nil
+	}
+
+	return validateFormat(c.Format, c.Width, c.Height)
 }
 
 // Camera defines the interface that all camera types must implement. It abstracts streaming,
@@ -34,8 +76,8 @@ type Camera interface {
 	Stream(ctx context.Context, outCh ImageChannel)
 	// Close releases all resources and stops any ongoing streaming.
 	Close() error
-	// Config returns the current configuration of the camera.
-	Config() *Config
+	// Config returns a copy of the current configuration of the camera.
+	Config() Config
 	// Pause temporarily stops streaming.
 	Pause()
 	// Resume restarts streaming after being paused.
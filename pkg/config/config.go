@@ -0,0 +1,357 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/conneroisu/steroscopic-hardware/pkg/camera"
+	"github.com/conneroisu/steroscopic-hardware/pkg/despair"
+	"github.com/conneroisu/steroscopic-hardware/pkg/homedir"
+)
+
+const (
+	// FileName is the name of the configuration file in the home directory.
+	FileName = "steroscopic.yaml"
+
+	// DefaultProfile is the name of the profile used when neither the
+	// command line nor the file chooses one.
+	DefaultProfile = "default"
+
+	// DefaultAddress is the address the server listens on when the profile
+	// sets none.
+	DefaultAddress = "0.0.0.0:8080"
+)
+
+// File is the content of a configuration file.
+type File struct {
+	// Profile is the name of the profile used when the command line
+	// chooses none.
+	Profile string `json:"profile,omitempty"`
+	// Profiles are the profiles by name.
+	Profiles map[string]Profile `json:"profiles,omitempty"`
+}
+
+// Profile describes the server and the cameras and parameters of its rigs.
+// The cameras and parameters of the default rig are the fields of its Rig.
+type Profile struct {
+	// Address is the host:port the server listens on, DefaultAddress when
+	// empty.
+	Address string `json:"address,omitempty"`
+	// Output is the directory the frames are saved to, the home directory
+	// when empty. A leading ~ is the home directory.
+	Output string `json:"output,omitempty"`
+
+	Rig
+
+	// Rigs are the named rigs created at startup, by ID.
+	Rigs map[string]Rig `json:"rigs,omitempty"`
+}
+
+// Rig describes the input cameras and disparity parameters of a rig.
+type Rig struct {
+	// Left and Right are the input cameras. A rig without one has no such
+	// camera.
+	Left  *Camera `json:"left,omitempty"`
+	Right *Camera `json:"right,omitempty"`
+	// Params are the parameters of the output camera. Parameters left out
+	// keep their defaults.
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// Camera describes an input camera: a static image or a serial camera.
+type Camera struct {
+	// Static is the path of the image of a static camera.
+	Static string `json:"static,omitempty"`
+	// Serial is the configuration of a serial camera.
+	Serial *camera.Config `json:"serial,omitempty"`
+}
+
+// Default returns the profile used without a configuration file: static
+// cameras of the test images and the default parameters.
+func Default() Profile {
+	return Profile{
+		Address: DefaultAddress,
+		Rig: Rig{
+			Left:  &Camera{Static: "./testdata/L_00001.png"},
+			Right: &Camera{Static: "./testdata/R_00001.png"},
+		},
+	}
+}
+
+// DefaultPath returns the path of the configuration file in the home
+// directory.
+func DefaultPath() (string, error) {
+	dir, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, FileName), nil
+}
+
+// Parameters returns the parameters of the rig on top of defaults. An
+// algorithm no matcher is registered for is an error.
+func (r Rig) Parameters(defaults despair.Parameters) (despair.Parameters, error) {
+	params := defaults
+	if len(r.Params) == 0 {
+		return params, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(r.Params))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(&params)
+	if err != nil {
+		return params, fmt.Errorf("invalid params: %w", err)
+	}
+	// An unknown algorithm would silently compute block matching
+	_, err = despair.ParseAlgorithm(string(params.Algorithm))
+	if err != nil {
+		return params, fmt.Errorf("invalid params: %w", err)
+	}
+
+	return params, nil
+}
+
+// SetParameters sets the parameters of the rig.
+func (r *Rig) SetParameters(params despair.Parameters) error {
+	var err error
+	r.Params, err = json.Marshal(params)
+
+	return err
+}
+
+// validate reports whether the rig describes every camera as either a
+// static or a serial camera.
+func (r Rig) validate() error {
+	for name, cam := range map[string]*Camera{"left": r.Left, "right": r.Right} {
+		if cam == nil {
+			continue
+		}
+		if (cam.Static == "") == (cam.Serial == nil) {
+			return fmt.Errorf("%s camera must be either static or serial", name)
+		}
+		if cam.Serial == nil {
+			continue
+		}
+		if cam.Serial.Port == "" || cam.Serial.BaudRate <= 0 {
+			return fmt.Errorf("%s camera needs a port and a positive baud rate", name)
+		}
+		err := cam.Serial.Compression.Validate()
+		if err != nil {
+			return fmt.Errorf("%s camera: %w", name, err)
+		}
+		err = cam.Serial.ValidateFrame()
+		if err != nil {
+			return fmt.Errorf("%s camera: %w", name, err)
+		}
+	}
+	_, err := r.Parameters(*despair.DefaultParams())
+
+	return err
+}
+
+// validate reports whether the profile and its rigs are valid.
+func (p Profile) validate() error {
+	err := p.Rig.validate()
+	if err != nil {
+		return err
+	}
+	for _, id := range slices.Sorted(maps.Keys(p.Rigs)) {
+		if id == camera.DefaultRig {
+			return fmt.Errorf("rig %s is described by the fields of the profile", id)
+		}
+		err = camera.ValidateRigID(id)
+		if err != nil {
+			return err
+		}
+		err = p.Rigs[id].validate()
+		if err != nil {
+			return fmt.Errorf("rig %s: %w", id, err)
+		}
+	}
+
+	return nil
+}
+
+// Parse parses a YAML configuration file.
+//
+// The YAML is converted to JSON before decoding, so that the keys are
+// those of the JSON API, as in "blockSize" and "baudRate".
+func Parse(data []byte) (*File, error) {
+	var doc any
+	err := yaml.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, err
+	}
+	data, err = json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	f := &File{}
+	if doc == nil {
+		return f, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	err = dec.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range slices.Sorted(maps.Keys(f.Profiles)) {
+		err = f.Profiles[name].validate()
+		if err != nil {
+			return nil, fmt.Errorf("profile %s: %w", name, err)
+		}
+	}
+
+	return f, nil
+}
+
+// Marshal formats the file as YAML.
+func (f *File) Marshal() ([]byte, error) {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+	var doc any
+	err = json.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	err = enc.Encode(doc)
+	if err != nil {
+		return nil, err
+	}
+	err = enc.Close()
+
+	return buf.Bytes(), err
+}
+
+// Load reads a configuration file. A file that does not exist is empty.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &File{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	f, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return f, nil
+}
+
+// Save writes the file to path, replacing it at once so that readers never
+// see a partial file.
+func (f *File) Save(path string) error {
+	data, err := f.Marshal()
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if err != nil {
+		tmp.Close()
+
+		return err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Select returns the profile of the given name, or of the file's profile
+// when name is empty. A file without profiles has the Default profile.
+func (f *File) Select(name string) (string, Profile, error) {
+	if name == "" {
+		name = f.Profile
+	}
+	if len(f.Profiles) == 0 && (name == "" || name == DefaultProfile) {
+		return DefaultProfile, Default(), nil
+	}
+	if name == "" {
+		name = DefaultProfile
+	}
+	p, ok := f.Profiles[name]
+	if !ok {
+		return name, Profile{}, fmt.Errorf("no profile %s", name)
+	}
+
+	return name, p, nil
+}
+
+// Current is the profile the server runs with and the file it is saved to.
+type Current struct {
+	// Path is the path of the configuration file.
+	Path string
+	// Name is the name of the profile.
+	Name string
+	// Profile is the profile as loaded.
+	Profile Profile
+}
+
+// saveMu serializes the saves of profiles, which rewrite the whole file.
+var saveMu sync.Mutex
+
+// Open loads the profile of the given name from a configuration file, or
+// its default profile when name is empty.
+func Open(path, name string) (*Current, error) {
+	f, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	name, p, err := f.Select(name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return &Current{Path: path, Name: name, Profile: p}, nil
+}
+
+// Save stores a profile under the current profile's name, keeping the
+// other profiles of the file.
+func (c *Current) Save(p Profile) error {
+	err := p.validate()
+	if err != nil {
+		return err
+	}
+
+	saveMu.Lock()
+	defer saveMu.Unlock()
+	f, err := Load(c.Path)
+	if err != nil {
+		return err
+	}
+	if f.Profiles == nil {
+		f.Profiles = map[string]Profile{}
+	}
+	f.Profiles[c.Name] = p
+	if f.Profile == "" {
+		f.Profile = c.Name
+	}
+
+	return f.Save(c.Path)
+}
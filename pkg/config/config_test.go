@@ -0,0 +1,166 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/conneroisu/steroscopic-hardware/pkg/camera"
+	"github.com/conneroisu/steroscopic-hardware/pkg/despair"
+)
+
+const testFile = `
+profile: bench
+profiles:
+  bench:
+    address: 127.0.0.1:9090
+    output: ~/captures
+    left:
+      serial: {port: /dev/ttyUSB0, baudRate: 115200, compression: 1, protocol: framed}
+    right:
+      static: ./testdata/R_00001.png
+    params: {blockSize: 15, algorithm: sgm}
+    rigs:
+      second:
+        left: {static: ./testdata/L_00002.png}
+  quiet: {}
+`
+
+func TestParse(t *testing.T) {
+	f, err := Parse([]byte(testFile))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	name, p, err := f.Select("")
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	if name != "bench" || p.Address != "127.0.0.1:9090" || p.Output != "~/captures" {
+		t.Errorf("Select() = %s, %+v", name, p)
+	}
+	want := camera.Config{
+		Port:        "/dev/ttyUSB0",
+		BaudRate:    115200,
+		Compression: camera.CompressionLZMA,
+		Protocol:    camera.ProtocolFramed,
+	}
+	if p.Left == nil || p.Left.Serial == nil || *p.Left.Serial != want {
+		t.Errorf("left camera = %+v, want serial %+v", p.Left, want)
+	}
+	if p.Right == nil || p.Right.Static != "./testdata/R_00001.png" {
+		t.Errorf("right camera = %+v", p.Right)
+	}
+	if p.Rigs["second"].Left.Static != "./testdata/L_00002.png" {
+		t.Errorf("rigs = %+v", p.Rigs)
+	}
+
+	defaults := *despair.DefaultParams()
+	params, err := p.Parameters(defaults)
+	if err != nil {
+		t.Fatalf("Parameters() error = %v", err)
+	}
+	wantParams := defaults
+	wantParams.BlockSize = 15
+	wantParams.Algorithm = despair.AlgorithmSGM
+	if params != wantParams {
+		t.Errorf("Parameters() = %+v, want %+v", params, wantParams)
+	}
+
+	if _, _, err := f.Select("missing"); err == nil {
+		t.Error("Select() of a missing profile succeeded")
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+	}{
+		{"unknown key", "profiles: {a: {adress: x}}"},
+		{"static and serial", "profiles: {a: {left: {static: a.png, serial: {port: p, baudRate: 1}}}}"},
+		{"neither", "profiles: {a: {left: {}}}"},
+		{"no baud rate", "profiles: {a: {left: {serial: {port: p}}}}"},
+		{"compression", "profiles: {a: {left: {serial: {port: p, baudRate: 1, compression: 9}}}}"},
+		{"params", "profiles: {a: {params: {blockSise: 3}}}"},
+		{"algorithm", "profiles: {a: {params: {algorithm: sgn}}}"},
+		{"rig algorithm", "profiles: {a: {rigs: {second: {params: {algorithm: hardware}}}}}"},
+		{"rig id", "profiles: {a: {rigs: {Bad!: {}}}}"},
+		{"default rig", "profiles: {a: {rigs: {default: {}}}}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.yaml)); err == nil {
+				t.Errorf("Parse(%q) succeeded", tt.yaml)
+			}
+		})
+	}
+}
+
+func TestSelectDefault(t *testing.T) {
+	name, p, err := (&File{}).Select("")
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	if name != DefaultProfile || !reflect.DeepEqual(p, Default()) {
+		t.Errorf("Select() = %s, %+v, want the default profile", name, p)
+	}
+}
+
+// Saving a profile keeps the other profiles, and the saved file loads
+// back the same.
+func TestCurrentSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	err := os.WriteFile(path, []byte(testFile), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := Open(path, "quiet")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	p := Default()
+	err = p.SetParameters(despair.Parameters{BlockSize: 7, MaxDisparity: 32, Paths: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = current.Save(p)
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	f, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if f.Profile != "bench" || len(f.Profiles) != 2 {
+		t.Errorf("saved file has profile %q of %d profiles, want bench of 2", f.Profile, len(f.Profiles))
+	}
+	saved := f.Profiles["quiet"]
+	params, err := saved.Parameters(despair.Parameters{})
+	if err != nil || params.BlockSize != 7 || params.Paths != 4 {
+		t.Errorf("saved parameters = %+v, %v", params, err)
+	}
+	if saved.Left.Static != p.Left.Static || saved.Address != p.Address {
+		t.Errorf("saved profile = %+v, want %+v", saved, p)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "blockSize: 7") {
+		t.Errorf("saved file does not use the keys of the JSON API:\n%s", data)
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	f, err := Load(filepath.Join(t.TempDir(), FileName))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(f.Profiles) != 0 {
+		t.Errorf("Load() = %+v, want an empty file", f)
+	}
+}
@@ -0,0 +1,26 @@
+// Package config reads and writes the configuration file of the web UI,
+// $HOME/steroscopic.yaml unless another is given.
+//
+// The file holds named profiles, each describing the address the server
+// listens on, the directory frames are saved to and the rigs: the default
+// rig's static or serial cameras and disparity parameters, and those of
+// named rigs created at startup. Keys are those of the JSON API:
+//
+//	profile: bench
+//	profiles:
+//	  bench:
+//	    address: 0.0.0.0:8080
+//	    output: ~/captures
+//	    left:
+//	      serial: {port: /dev/ttyUSB0, baudRate: 115200, compression: 1}
+//	    right:
+//	      static: ./testdata/R_00001.png
+//	    params: {blockSize: 15, maxDisparity: 64}
+//	    rigs:
+//	      second:
+//	        left: {static: ./testdata/L_00002.png}
+//	        right: {static: ./testdata/R_00002.png}
+//
+// Without a file, the server runs the Default profile. Current.Save writes
+// the live state back as a profile, keeping the file's other profiles.
+package config
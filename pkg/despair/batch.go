@@ -0,0 +1,403 @@
+package despair
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ManifestFile is the name of the manifest of a batch in its output
+	// directory.
+	ManifestFile = "manifest.json"
+
+	// DefaultLeftPattern and DefaultRightPattern name the images of a
+	// stereo pair as the camera capture writes them, L_<name>.png and
+	// R_<name>.png.
+	DefaultLeftPattern  = "L_*.png"
+	DefaultRightPattern = "R_*.png"
+
+	// DefaultBatchWorkers is the number of pairs a batch processes at once
+	// when its options set none. Each pair is matched on every CPU, so more
+	// workers mostly overlap the loading and saving of images.
+	DefaultBatchWorkers = 2
+)
+
+var (
+	// ErrManifestParams is returned when a batch is resumed in an output
+	// directory holding the results of other parameters.
+	ErrManifestParams = errors.New("output directory holds results of other parameters")
+	// ErrManifestCalibration is returned when a batch is resumed in an
+	// output directory holding the results of pairs rectified otherwise.
+	ErrManifestCalibration = errors.New("output directory holds results of another rectification")
+)
+
+// StereoPair is a stereo pair of image files.
+type StereoPair struct {
+	// Name is the part of the file names matched by the patterns' *.
+	Name string `json:"name"`
+	// Left and Right are the paths of the images.
+	Left  string `json:"left"`
+	Right string `json:"right"`
+}
+
+// FindStereoPairs returns the stereo pairs in dir, sorted by name.
+//
+// The patterns hold a single * standing for the name of a pair: with
+// DefaultLeftPattern and DefaultRightPattern, L_0001.png is paired with
+// R_0001.png. They are relative to dir and may name subdirectories, as
+// left/*.png and right/*.png do. Left images without a matching right image
+// are ignored.
+func FindStereoPairs(dir, leftPattern, rightPattern string) ([]StereoPair, error) {
+	leftPattern, rightPattern = filepath.Clean(leftPattern), filepath.Clean(rightPattern)
+	leftPrefix, leftSuffix, ok := strings.Cut(leftPattern, "*")
+	if !ok || strings.Contains(leftSuffix, "*") {
+		return nil, fmt.Errorf("pattern %q must hold a single *", leftPattern)
+	}
+	rightPrefix, rightSuffix, ok := strings.Cut(rightPattern, "*")
+	if !ok || strings.Contains(rightSuffix, "*") {
+		return nil, fmt.Errorf("pattern %q must hold a single *", rightPattern)
+	}
+	lefts, err := filepath.Glob(filepath.Join(dir, leftPattern))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stereo pairs: %w", err)
+	}
+
+	pairs := make([]StereoPair, 0, len(lefts))
+	for _, left := range lefts {
+		rel, err := filepath.Rel(dir, left)
+		if err != nil || len(rel) < len(leftPrefix)+len(leftSuffix) {
+			continue
+		}
+		name := rel[len(leftPrefix) : len(rel)-len(leftSuffix)]
+		right := filepath.Join(dir, rightPrefix+name+rightSuffix)
+		if _, err := os.Stat(right); err != nil {
+			continue
+		}
+		pairs = append(pairs, StereoPair{Name: name, Left: left, Right: right})
+	}
+	slices.SortFunc(pairs, func(a, b StereoPair) int { return strings.Compare(a.Name, b.Name) })
+
+	return pairs, nil
+}
+
+// BatchOptions configures RunBatch.
+type BatchOptions struct {
+	// Out is the directory the disparity maps and the manifest are written
+	// to.
+	Out string
+	// Params are the parameters every pair is matched with.
+	Params Parameters
+	// Rectifier rectifies the pairs before matching, unless nil.
+	Rectifier *Rectifier
+	// Workers is the number of pairs processed at once, and so loaded in
+	// memory, DefaultBatchWorkers when zero. It must not be negative.
+	Workers int
+	// Progress, unless nil, is called once the pairs already processed are
+	// skipped and after every pair. Calls do not overlap.
+	Progress func(BatchProgress)
+}
+
+// BatchProgress is the progress of a batch.
+type BatchProgress struct {
+	// Total is the number of pairs of the batch.
+	Total int `json:"total"`
+	// Done is the number of pairs processed, Skipped and Failed included.
+	Done int `json:"done"`
+	// Skipped is the number of pairs processed by a previous run.
+	Skipped int `json:"skipped"`
+	// Failed is the number of pairs that failed.
+	Failed int `json:"failed"`
+	// Last is the pair processed last, if any.
+	Last *ManifestEntry `json:"last,omitempty"`
+}
+
+// Manifest records the parameters and results of a batch.
+type Manifest struct {
+	// Params are the parameters the pairs were matched with.
+	Params Parameters `json:"params"`
+	// Calibration is the calibration the pairs were rectified with, nil
+	// when they were not.
+	Calibration *Calibration `json:"calibration,omitempty"`
+	// Started is when the first run of the batch started.
+	Started time.Time `json:"started"`
+	// Updated is when the last pair was processed.
+	Updated time.Time `json:"updated"`
+	// Entries are the processed pairs, sorted by name.
+	Entries []ManifestEntry `json:"entries"`
+}
+
+// ManifestEntry records a processed pair.
+type ManifestEntry struct {
+	StereoPair
+	// Disparity, Raw and Valid are the names of the normalized and raw
+	// disparity maps and of the validity mask in the output directory.
+	Disparity string `json:"disparity,omitempty"`
+	Raw       string `json:"raw,omitempty"`
+	Valid     string `json:"valid,omitempty"`
+	// Duration is the time taken to match the pair.
+	Duration time.Duration `json:"duration"`
+	// Stats are the statistics of the disparity map.
+	Stats DisparityStats `json:"stats"`
+	// Error is why the pair failed, if it did.
+	Error string `json:"error,omitempty"`
+}
+
+// DisparityStats are statistics of a disparity map, in pixels.
+type DisparityStats struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	// ValidRatio is the fraction of pixels of the validity mask.
+	ValidRatio float64 `json:"validRatio"`
+	// Min, Max and Mean are those of the valid raw disparities.
+	Min  float64 `json:"min"`
+	Max  float64 `json:"max"`
+	Mean float64 `json:"mean"`
+}
+
+// Stats returns the statistics of the result.
+func (r Result) Stats() DisparityStats {
+	size := r.Raw.Rect.Size()
+	stats := DisparityStats{Width: size.X, Height: size.Y}
+	var sum, count int
+	lo, hi := 0, 0
+	for y := range size.Y {
+		raw := r.Raw.Pix[y*r.Raw.Stride:]
+		valid := r.Valid.Pix[y*r.Valid.Stride:]
+		for x := range size.X {
+			if valid[x] == 0 {
+				continue
+			}
+			d := int(raw[2*x])<<8 | int(raw[2*x+1])
+			if count == 0 || d < lo {
+				lo = d
+			}
+			if count == 0 || d > hi {
+				hi = d
+			}
+			sum += d
+			count++
+		}
+	}
+	if count == 0 {
+		return stats
+	}
+	stats.ValidRatio = float64(count) / float64(size.X*size.Y)
+	stats.Min = float64(lo) / DisparityScale
+	stats.Max = float64(hi) / DisparityScale
+	stats.Mean = float64(sum) / float64(count) / DisparityScale
+
+	return stats
+}
+
+// LoadManifest reads the manifest of a batch.
+func LoadManifest(filename string) (*Manifest, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	err = json.Unmarshal(data, &m)
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", filename, err)
+	}
+
+	return &m, nil
+}
+
+// save writes the manifest to filename, replacing it at once so that an
+// interrupted batch leaves a complete manifest.
+func (m *Manifest) save(filename string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filename + ".tmp"
+	err = os.WriteFile(tmp, data, 0o644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, filename)
+}
+
+// RunBatch computes the disparity maps of pairs into the output directory
+// and records them in its manifest, which it returns.
+//
+// A batch is resumable: the pairs the manifest of the output directory
+// records as processed, whose disparity maps still exist, are skipped, and
+// failed pairs are retried. The manifest must have been written with the
+// same parameters, or ErrManifestParams is returned, and with pairs
+// rectified with the same calibration, or ErrManifestCalibration is.
+//
+// When ctx ends, no new pair is started and the pairs being matched are
+// recorded before RunBatch returns the context's error.
+func RunBatch(ctx context.Context, pairs []StereoPair, opts BatchOptions) (*Manifest, error) {
+	if opts.Workers < 0 {
+		return nil, fmt.Errorf("invalid number of batch workers %d", opts.Workers)
+	}
+	err := os.MkdirAll(opts.Out, 0o755)
+	if err != nil {
+		return nil, err
+	}
+	var calibration *Calibration
+	if opts.Rectifier != nil {
+		cal := opts.Rectifier.Calibration()
+		calibration = &cal
+	}
+	manifestPath := filepath.Join(opts.Out, ManifestFile)
+	manifest, err := LoadManifest(manifestPath)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		manifest = &Manifest{Params: opts.Params, Calibration: calibration, Started: time.Now()}
+	case err != nil:
+		return nil, err
+	case manifest.Params != opts.Params:
+		return nil, fmt.Errorf("%s: %w", opts.Out, ErrManifestParams)
+	case (manifest.Calibration == nil) != (calibration == nil) ||
+		calibration != nil && *manifest.Calibration != *calibration:
+		return nil, fmt.Errorf("%s: %w", opts.Out, ErrManifestCalibration)
+	}
+
+	entries := map[string]ManifestEntry{}
+	for _, e := range manifest.Entries {
+		entries[e.Name] = e
+	}
+	progress := BatchProgress{Total: len(pairs)}
+	var todo []StereoPair
+	for _, pair := range pairs {
+		if e, ok := entries[pair.Name]; ok && e.Error == "" && exists(filepath.Join(opts.Out, e.Disparity)) {
+			progress.Done++
+			progress.Skipped++
+
+			continue
+		}
+		todo = append(todo, pair)
+	}
+	report := opts.Progress
+	if report == nil {
+		report = func(BatchProgress) {}
+	}
+	report(progress)
+
+	var (
+		mu      sync.Mutex
+		saveErr error
+		wg      sync.WaitGroup
+		queue   = make(chan StereoPair)
+	)
+	for range cmp.Or(opts.Workers, DefaultBatchWorkers) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for pair := range queue {
+				entry := processPair(pair, opts)
+
+				mu.Lock()
+				entries[entry.Name] = entry
+				manifest.Entries = slices.SortedFunc(maps.Values(entries), func(a, b ManifestEntry) int {
+					return strings.Compare(a.Name, b.Name)
+				})
+				manifest.Updated = time.Now()
+				saveErr = cmp.Or(saveErr, manifest.save(manifestPath))
+				progress.Done++
+				if entry.Error != "" {
+					progress.Failed++
+				}
+				progress.Last = &entry
+				report(progress)
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, pair := range todo {
+		select {
+		case <-ctx.Done():
+			break feed
+		case queue <- pair:
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	if saveErr != nil {
+		return manifest, fmt.Errorf("failed to save manifest: %w", saveErr)
+	}
+
+	return manifest, ctx.Err()
+}
+
+// processPair matches a pair and writes its disparity maps, recording a
+// failure in the returned entry.
+func processPair(pair StereoPair, opts BatchOptions) ManifestEntry {
+	entry := ManifestEntry{StereoPair: pair}
+	fail := func(err error) ManifestEntry {
+		entry.Error = err.Error()
+
+		return entry
+	}
+
+	left, err := LoadPNG(pair.Left)
+	if err != nil {
+		return fail(err)
+	}
+	right, err := LoadPNG(pair.Right)
+	if err != nil {
+		return fail(err)
+	}
+	if left.Rect.Size() != right.Rect.Size() {
+		return fail(fmt.Errorf("left image is %v but right image is %v", left.Rect.Size(), right.Rect.Size()))
+	}
+	if opts.Rectifier != nil {
+		left, right, err = opts.Rectifier.Rectify(left, right)
+		if err != nil {
+			return fail(err)
+		}
+	}
+
+	start := time.Now()
+	res := Run(left, right, opts.Params)
+	entry.Duration = time.Since(start)
+	entry.Stats = res.Stats()
+
+	outputs := []struct {
+		name *string
+		file string
+		img  image.Image
+	}{
+		{&entry.Raw, "D16_" + pair.Name + ".png", res.Raw},
+		{&entry.Valid, "V_" + pair.Name + ".png", res.Valid},
+		// The normalized map is written last, as its presence marks the
+		// pair as processed
+		{&entry.Disparity, "D_" + pair.Name + ".png", res.Disparity},
+	}
+	for _, o := range outputs {
+		err = SavePNG(filepath.Join(opts.Out, o.file), o.img)
+		if err != nil {
+			return fail(err)
+		}
+		*o.name = o.file
+	}
+
+	return entry
+}
+
+// exists reports whether a file exists.
+func exists(filename string) bool {
+	_, err := os.Stat(filename)
+
+	return err == nil
+}
@@ -0,0 +1,198 @@
+package despair
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestFindStereoPairs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"left-2.png", "right-2.png", "left-1.png", "right-1.png", "left-3.png", "other.png"} {
+		err := os.WriteFile(filepath.Join(dir, name), nil, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pairs, err := FindStereoPairs(dir, "left-*.png", "right-*.png")
+	if err != nil {
+		t.Fatalf("FindStereoPairs() error = %v", err)
+	}
+	want := []StereoPair{
+		{Name: "1", Left: filepath.Join(dir, "left-1.png"), Right: filepath.Join(dir, "right-1.png")},
+		{Name: "2", Left: filepath.Join(dir, "left-2.png"), Right: filepath.Join(dir, "right-2.png")},
+	}
+	if !slices.Equal(pairs, want) {
+		t.Errorf("FindStereoPairs() = %+v, want %+v", pairs, want)
+	}
+
+	// Patterns naming subdirectories
+	for _, name := range []string{"left/a.png", "right/a.png", "left/b.png"} {
+		err := os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(filepath.Join(dir, name), nil, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	pairs, err = FindStereoPairs(dir, "left/*.png", "right/*.png")
+	if err != nil {
+		t.Fatalf("FindStereoPairs() error = %v", err)
+	}
+	want = []StereoPair{
+		{Name: "a", Left: filepath.Join(dir, "left", "a.png"), Right: filepath.Join(dir, "right", "a.png")},
+	}
+	if !slices.Equal(pairs, want) {
+		t.Errorf("FindStereoPairs() = %+v, want %+v", pairs, want)
+	}
+
+	// The default patterns pair the images the camera capture writes
+	pairs, err = FindStereoPairs(filepath.Join("..", "..", "testdata"), DefaultLeftPattern, DefaultRightPattern)
+	if err != nil {
+		t.Fatalf("FindStereoPairs() error = %v", err)
+	}
+	var names []string
+	for _, p := range pairs {
+		names = append(names, p.Name)
+		if filepath.Base(p.Right) != "R_"+p.Name+".png" {
+			t.Errorf("pair %s has right image %s", p.Name, p.Right)
+		}
+	}
+	if want := []string{"00001", "00002", "00335", "01000"}; !slices.Equal(names, want) {
+		t.Errorf("FindStereoPairs() names = %v, want %v", names, want)
+	}
+
+	if _, err := FindStereoPairs(dir, "left.png", "right-*.png"); err == nil {
+		t.Error("FindStereoPairs() of a pattern without * succeeded")
+	}
+}
+
+// A batch skips the pairs its manifest records, retries the missing ones
+// and refuses to mix parameters or rectifications in its output directory.
+func TestRunBatchResumes(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	for _, name := range []string{"a", "b", "c"} {
+		left, right := newStereoPair(t, 48, 24, 4)
+		for path, img := range map[string]*image.Gray{"L_" + name + ".png": left, "R_" + name + ".png": right} {
+			err := SavePNG(filepath.Join(dir, path), img)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	pairs, err := FindStereoPairs(dir, DefaultLeftPattern, DefaultRightPattern)
+	if err != nil || len(pairs) != 3 {
+		t.Fatalf("FindStereoPairs() = %d pairs, %v", len(pairs), err)
+	}
+	params := Parameters{BlockSize: 5, MaxDisparity: 16, Paths: 8}
+
+	var last BatchProgress
+	opts := BatchOptions{Out: out, Params: params, Progress: func(p BatchProgress) { last = p }}
+	manifest, err := RunBatch(context.Background(), pairs, opts)
+	if err != nil {
+		t.Fatalf("RunBatch() error = %v", err)
+	}
+	if last.Done != 3 || last.Skipped != 0 || last.Failed != 0 {
+		t.Errorf("progress = %+v, want 3 done", last)
+	}
+	if len(manifest.Entries) != 3 || manifest.Entries[0].Name != "a" {
+		t.Fatalf("manifest entries = %+v", manifest.Entries)
+	}
+	if stats := manifest.Entries[0].Stats; stats.Width != 48 || stats.ValidRatio != 1 || stats.Max < 4 {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	err = os.Remove(filepath.Join(out, manifest.Entries[1].Disparity))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = RunBatch(context.Background(), pairs, opts)
+	if err != nil {
+		t.Fatalf("resumed RunBatch() error = %v", err)
+	}
+	if last.Done != 3 || last.Skipped != 2 || last.Last == nil || last.Last.Name != "b" {
+		t.Errorf("resumed progress = %+v, want b redone", last)
+	}
+	saved, err := LoadManifest(filepath.Join(out, ManifestFile))
+	if err != nil || len(saved.Entries) != 3 || saved.Params != params {
+		t.Errorf("LoadManifest() = %+v, %v", saved, err)
+	}
+
+	opts.Params.BlockSize = 7
+	if _, err := RunBatch(context.Background(), pairs, opts); !errors.Is(err, ErrManifestParams) {
+		t.Errorf("RunBatch() with other parameters error = %v, want %v", err, ErrManifestParams)
+	}
+	opts.Params = params
+
+	model := CameraModel{Fx: 50, Fy: 50, Cx: 24, Cy: 12}
+	cal := Calibration{
+		Width:  48,
+		Height: 24,
+		Left:   model,
+		Right:  model,
+		R:      [9]float64{1, 0, 0, 0, 1, 0, 0, 0, 1},
+		T:      [3]float64{-0.1, 0, 0.001},
+	}
+	opts.Rectifier, err = NewRectifier(cal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RunBatch(context.Background(), pairs, opts); !errors.Is(err, ErrManifestCalibration) {
+		t.Errorf("RunBatch() of rectified pairs error = %v, want %v", err, ErrManifestCalibration)
+	}
+	opts.Out = filepath.Join(dir, "rectified")
+	for range 2 {
+		_, err = RunBatch(context.Background(), pairs, opts)
+		if err != nil {
+			t.Fatalf("rectified RunBatch() error = %v", err)
+		}
+	}
+	if last.Skipped != 3 {
+		t.Errorf("resumed rectified progress = %+v, want 3 skipped", last)
+	}
+	cal.T[2] = 0.002
+	opts.Rectifier, err = NewRectifier(cal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RunBatch(context.Background(), pairs, opts); !errors.Is(err, ErrManifestCalibration) {
+		t.Errorf("RunBatch() with another calibration error = %v, want %v", err, ErrManifestCalibration)
+	}
+}
+
+func TestRunBatchNegativeWorkers(t *testing.T) {
+	pairs := []StereoPair{{Name: "a", Left: "L_a.png", Right: "R_a.png"}}
+	opts := BatchOptions{Out: t.TempDir(), Params: *DefaultParams(), Workers: -1}
+	// Without workers the batch would wait for its context
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := RunBatch(ctx, pairs, opts)
+	if err == nil || errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("RunBatch() with -1 workers error = %v, want an invalid workers error", err)
+	}
+}
+
+func TestResultStats(t *testing.T) {
+	rect := image.Rect(0, 0, 2, 2)
+	res := Result{Raw: image.NewGray16(rect), Valid: image.NewGray(rect)}
+	res.Raw.SetGray16(0, 0, color.Gray16{Y: 2 * DisparityScale})
+	res.Raw.SetGray16(1, 0, color.Gray16{Y: 6 * DisparityScale})
+	res.Raw.SetGray16(0, 1, color.Gray16{Y: 100 * DisparityScale})
+	res.Valid.SetGray(0, 0, color.Gray{Y: 255})
+	res.Valid.SetGray(1, 0, color.Gray{Y: 255})
+
+	want := DisparityStats{Width: 2, Height: 2, ValidRatio: 0.5, Min: 2, Max: 6, Mean: 4}
+	if got := res.Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,101 @@
+package despair
+
+import (
+	"image"
+	"math/bits"
+)
+
+// CensusWindow is the width and height of the neighbourhood encoded by the
+// census transform. A 7x7 window yields 48 comparison bits per pixel.
+const CensusWindow = 7
+
+// Census holds the census transform of a region of a grayscale image.
+//
+// Each pixel is described by a bit string recording which of its
+// neighbours are darker than it, which makes matching costs built on it
+// insensitive to differences in exposure and gain between the cameras.
+type Census struct {
+	// Bits holds one bit string per pixel, in row-major order.
+	Bits []uint64
+	// Stride is the distance in elements between vertically adjacent pixels.
+	Stride int
+	// Rect is the region of the source image the transform covers.
+	Rect image.Rectangle
+}
+
+// CensusTransform computes the census transform of img over rect.
+//
+// The region is clipped to the image bounds, and neighbours that fall
+// outside the image are replaced by the nearest edge pixel.
+func CensusTransform(img *image.Gray, rect image.Rectangle) *Census {
+	rect = rect.Intersect(img.Rect)
+	census := &Census{
+		Bits:   make([]uint64, rect.Dx()*rect.Dy()),
+		Stride: rect.Dx(),
+		Rect:   rect,
+	}
+	half := CensusWindow / 2
+
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			center := img.Pix[img.PixOffset(x, y)]
+
+			var code uint64
+			for dy := -half; dy <= half; dy++ {
+				ny := min(max(y+dy, img.Rect.Min.Y), img.Rect.Max.Y-1)
+				for dx := -half; dx <= half; dx++ {
+					if dx == 0 && dy == 0 {
+						continue
+					}
+					nx := min(max(x+dx, img.Rect.Min.X), img.Rect.Max.X-1)
+					code <<= 1
+					if img.Pix[img.PixOffset(nx, ny)] < center {
+						code |= 1
+					}
+				}
+			}
+			census.Bits[(y-rect.Min.Y)*census.Stride+x-rect.Min.X] = code
+		}
+	}
+
+	return census
+}
+
+// At returns the census bit string of the pixel (x, y).
+func (c *Census) At(x, y int) uint64 {
+	return c.Bits[(y-c.Rect.Min.Y)*c.Stride+x-c.Rect.Min.X]
+}
+
+// SumHammingDistances calculates the sum of Hamming distances between the
+// census bit strings of two blocks.
+//
+// Window pixels that fall outside either transform are skipped.
+func SumHammingDistances(
+	left, right *Census,
+	leftX, leftY, rightX, rightY, blockSize int,
+) int {
+	halfSize := blockSize / 2
+
+	var distance int
+	for dy := -halfSize; dy <= halfSize; dy++ {
+		ly, ry := leftY+dy, rightY+dy
+		if ly < left.Rect.Min.Y || ly >= left.Rect.Max.Y ||
+			ry < right.Rect.Min.Y || ry >= right.Rect.Max.Y {
+			continue
+		}
+		leftRow := left.Bits[(ly-left.Rect.Min.Y)*left.Stride:]
+		rightRow := right.Bits[(ry-right.Rect.Min.Y)*right.Stride:]
+		for dx := -halfSize; dx <= halfSize; dx++ {
+			lx, rx := leftX+dx, rightX+dx
+			if lx < left.Rect.Min.X || lx >= left.Rect.Max.X ||
+				rx < right.Rect.Min.X || rx >= right.Rect.Max.X {
+				continue
+			}
+			distance += bits.OnesCount64(
+				leftRow[lx-left.Rect.Min.X] ^ rightRow[rx-right.Rect.Min.X],
+			)
+		}
+	}
+
+	return distance
+}
@@ -0,0 +1,55 @@
+package despair
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestCensusTransform(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 9, 9))
+	for i := range img.Pix {
+		img.Pix[i] = 100
+	}
+
+	// A flat image has no darker neighbours anywhere
+	census := CensusTransform(img, img.Rect)
+	for i, code := range census.Bits {
+		if code != 0 {
+			t.Fatalf("flat image census at %d = %x, want 0", i, code)
+		}
+	}
+
+	// A bright centre sees every neighbour as darker
+	img.SetGray(4, 4, color.Gray{Y: 200})
+	census = CensusTransform(img, image.Rect(2, 2, 7, 7))
+	if census.Rect != image.Rect(2, 2, 7, 7) {
+		t.Fatalf("census rect = %v, want %v", census.Rect, image.Rect(2, 2, 7, 7))
+	}
+	const bits = CensusWindow*CensusWindow - 1
+	if got := census.At(4, 4); got != 1<<bits-1 {
+		t.Errorf("census at centre = %x, want %x", got, uint64(1<<bits-1))
+	}
+}
+
+func TestMatchBlockCensusToleratesGain(t *testing.T) {
+	const disparity = 5
+	left, right := newStereoPair(t, 64, 32, disparity)
+
+	// Simulate a left camera with lower gain and a raised black level
+	for i, v := range left.Pix {
+		left.Pix[i] = uint8(int(v)*6/10 + 40)
+	}
+
+	params := Parameters{BlockSize: 5, MaxDisparity: 16, Cost: CostCensus}
+	out := matchBlock(InputChunk{Left: left, Right: right, Region: left.Rect}, params)
+
+	want := uint8(disparity * 255 / params.MaxDisparity)
+	for y := params.BlockSize; y < left.Rect.Dy()-params.BlockSize; y++ {
+		for x := disparity + params.BlockSize; x < left.Rect.Dx()-params.BlockSize; x++ {
+			if got := out.DisparityData[y*left.Rect.Dx()+x]; got != want {
+				t.Fatalf("disparity at (%d,%d) = %d, want %d", x, y, got, want)
+			}
+		}
+	}
+}
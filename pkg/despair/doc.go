@@ -14,10 +14,18 @@
 // # Data Structures
 //
 //	InputChunk: Represents a portion of the image pair to process
-//	OutputChunk: Contains processed disparity data for a specific region
+//	OutputChunk: Contains processed disparity data and validity for a specific region
+//	Result: An assembled disparity map together with its validity mask
+//	Matcher: A disparity algorithm, registered by name with RegisterMatcher
 //	Parameters: Configuration settings for the algorithm including:
 //	`BlockSize`: Size of pixel blocks for comparison
 //	`MaxDisparity`: Maximum pixel displacement to check
+//	`LRCheck`: Enables the left-right consistency check
+//	`LRThreshold`: Maximum disagreement between left and right disparities
+//	`Subpixel`: Interpolation used to refine disparities between integer steps
+//	`Cost`: Block matching cost, either SAD or census
+//	`Algorithm`: Name of the registered Matcher, such as block matching or semi-global matching
+//	`P1`, `P2`, `Paths`: Semi-global matching penalties and aggregation paths
 //
 // # Processing Pipeline
 //
@@ -28,9 +36,93 @@
 //     - Distributes processing across workers
 //     - Assembles final disparity map
 //
-//  3. `AssembleDisparityMap`: Combines processed chunks into a complete disparity map
+//  3. `Run`: Like `RunSad`, but takes the full `Parameters` and returns the assembled `Result`
 //
-//  4. `sumAbsoluteDifferences`: Low-level function that calculates block matching scores
+//  4. `AssembleDisparityMap`: Combines processed chunks into a complete disparity map
+//
+//  5. `Assemble`: Combines processed chunks into a disparity map and validity mask
+//
+//  6. `SumAbsoluteDifferences`: Low-level function that calculates block matching scores
+//
+//  7. `CensusTransform` and `SumHammingDistances`: Census transform and its block matching score
+//
+// Each `InputChunk` carries the `Parameters` it is processed with, so
+// several pipelines can run with different settings and a frame is never
+// computed with a mix of old and new settings. Chunks without parameters
+// fall back to the defaults set with `SetDefaultParams`.
+//
+// # Matching Costs
+//
+// Blocks are compared with the Sum of Absolute Differences by default.
+// Setting `Cost` to `CostCensus` compares the census transforms of the
+// blocks instead, summing the Hamming distances between each pixel's
+// `CensusWindow` neighbourhood bit strings. Census matching only depends on
+// the ordering of intensities, so it tolerates exposure and gain differences
+// between the two cameras.
+//
+// # Semi-Global Matching
+//
+// Setting `Algorithm` to `AlgorithmSGM` aggregates the matching costs along
+// 4 or 8 paths through each chunk, penalizing disparity changes of one pixel
+// between neighbours by `P1` and larger changes by `P2`. This suppresses the
+// streaks and noise block matching produces on low-texture surfaces. Paths
+// are confined to the chunk being processed, so the output only depends on
+// the input chunk and its parameters and is fully deterministic. SGM is best
+// combined with a small `BlockSize`, as the aggregation provides the
+// smoothing a large block would otherwise have to.
+//
+// # Matchers
+//
+// Disparity algorithms implement the `Matcher` interface, which computes the
+// disparity of a region of a rectified stereo pair. Matchers are registered
+// by name with `RegisterMatcher` and selected at runtime through the
+// `Algorithm` parameter, so further algorithms, including hardware-backed
+// ones, can be plugged into the pipeline without changing it. `BlockMatcher`
+// and `SGMMatcher` are registered as "block" and "sgm".
+//
+// # Rectification
+//
+// Block matching expects corresponding points on the same image row. A
+// `Rectifier`, built once from a stereo `Calibration` of both cameras'
+// intrinsics, lens distortion and relative pose, remaps each frame pair with
+// bilinear interpolation so that this holds for cameras that are not
+// perfectly aligned. Calibrations are stored as JSON with `SaveCalibration`
+// and read back with `LoadCalibration`.
+//
+// # Depth and Point Clouds
+//
+// `Depth` converts a fixed-point disparity map to metric depth with
+// Z = f*B/d, from the focal length of the rectified cameras and their
+// baseline, both of which a `Rectifier` reports. `NewPointCloud`
+// triangulates every pixel with a disparity into a `PointCloud`, optionally
+// colored with the rectified left image's intensity, which is written as
+// ASCII or binary PLY or as PCD with `PointCloud.Write` or `SavePointCloud`.
+// Raw disparity maps saved as 16-bit PNGs are read back with `LoadRawPNG`.
+//
+// # Left-Right Consistency
+//
+// With `LRCheck` enabled each pixel's disparity is also computed from the
+// right image's point of view. Occluded or mismatched pixels, whose two
+// estimates disagree by more than `LRThreshold`, are written as zero and
+// reported as invalid in the validity mask.
+//
+// # Subpixel Disparity
+//
+// The integer disparity with the lowest matching cost can be refined with
+// parabolic or equiangular interpolation over the costs of its neighbours.
+// The refined value is reported as a fixed-point `image.Gray16` in
+// `Result.Raw`, where a raw value divided by `DisparityScale` (16) is the
+// disparity in pixels. `Result.Disparity` remains the 8-bit view normalized
+// by `MaxDisparity` for display.
+//
+// # Batches
+//
+// `RunBatch` computes the disparity maps of the stereo pairs found by
+// `FindStereoPairs`, such as the `L_*.png`/`R_*.png` pairs of a capture,
+// a few pairs at a time. It writes the maps of every pair and a
+// `manifest.json` of the parameters, calibration, timings and disparity
+// statistics to an output directory, and skips the pairs the manifest
+// already records, so that an interrupted batch resumes where it stopped.
 //
 // # Image Handling
 //
@@ -47,6 +139,9 @@
 //   - Direct Pixel Access: Works with underlying pixel arrays rather than the higher-level interface
 //   - Type-Specific Optimizations: Different code paths for different image types
 //   - Early Termination: Breaks comparison loops when perfect matches are found
+//   - Running Sums: SAD block costs are aggregated with running column sums and prefix sums,
+//     so their cost per pixel and disparity does not grow with the block size while staying
+//     bit-identical to `SumAbsoluteDifferences`
 //   - Optimized Bounds Checking: Reduces redundant checks in inner loops
 //   - Precomputed Lookup Tables: Uses LUTs for common conversions
 //
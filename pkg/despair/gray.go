@@ -34,7 +34,7 @@ func convertRGBAToGray(
 			// Use integer arithmetic
 			grayPix[rowStart+x-bounds.Min.X] = uint8((19595*uint32(r) +
 				38470*uint32(g) +
-				7471*uint32(b) + 1<<15) >> 24)
+				7471*uint32(b) + 1<<15) >> 16)
 		}
 	}
 }
@@ -0,0 +1,124 @@
+package despair
+
+import (
+	"cmp"
+	"fmt"
+	"image"
+	"log/slog"
+	"slices"
+	"sync"
+)
+
+// Matcher computes the disparity of a region of a rectified stereo pair.
+//
+// Implementations must only write the pixels of region and must be safe
+// for concurrent use, as the pipeline's workers share a single matcher.
+type Matcher interface {
+	Match(left, right *image.Gray, region image.Rectangle, params Parameters) OutputChunk
+}
+
+// MatcherFunc adapts an ordinary function to the Matcher interface.
+type MatcherFunc func(left, right *image.Gray, region image.Rectangle, params Parameters) OutputChunk
+
+// Match calls f(left, right, region, params).
+func (f MatcherFunc) Match(
+	left, right *image.Gray,
+	region image.Rectangle,
+	params Parameters,
+) OutputChunk {
+	return f(left, right, region, params)
+}
+
+// BlockMatcher picks, per pixel, the disparity with the lowest block
+// matching cost.
+type BlockMatcher struct{}
+
+// Match implements Matcher.
+func (BlockMatcher) Match(
+	left, right *image.Gray,
+	region image.Rectangle,
+	params Parameters,
+) OutputChunk {
+	return matchBlock(InputChunk{Left: left, Right: right, Region: region}, params)
+}
+
+// SGMMatcher computes disparities by semi-global matching.
+type SGMMatcher struct{}
+
+// Match implements Matcher.
+func (SGMMatcher) Match(
+	left, right *image.Gray,
+	region image.Rectangle,
+	params Parameters,
+) OutputChunk {
+	return matchSGM(InputChunk{Left: left, Right: right, Region: region}, params)
+}
+
+var (
+	matchers   = map[Algorithm]Matcher{}
+	matchersMu sync.RWMutex
+)
+
+func init() {
+	RegisterMatcher(AlgorithmBlock, BlockMatcher{})
+	RegisterMatcher(AlgorithmSGM, SGMMatcher{})
+}
+
+// RegisterMatcher makes a matcher selectable by name through
+// Parameters.Algorithm.
+//
+// Registering an existing name replaces the previous matcher, which allows,
+// for example, a hardware-backed matcher to take over at runtime.
+func RegisterMatcher(name Algorithm, m Matcher) {
+	if name == "" || m == nil {
+		panic("despair: RegisterMatcher requires a name and a matcher")
+	}
+	matchersMu.Lock()
+	defer matchersMu.Unlock()
+	matchers[name] = m
+}
+
+// LookupMatcher returns the matcher registered under name.
+func LookupMatcher(name Algorithm) (Matcher, error) {
+	matchersMu.RLock()
+	defer matchersMu.RUnlock()
+	m, ok := matchers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown algorithm: %q", name)
+	}
+
+	return m, nil
+}
+
+// Matchers returns the names of all registered matchers in sorted order.
+func Matchers() []Algorithm {
+	matchersMu.RLock()
+	defer matchersMu.RUnlock()
+	names := make([]Algorithm, 0, len(matchers))
+	for name := range matchers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// fallbacks holds the algorithms match fell back from, so that each is
+// logged once rather than for every chunk.
+var fallbacks sync.Map
+
+// match computes the disparity of the chunk's region with the matcher
+// registered for params.Algorithm, block matching when it is empty. It
+// falls back to block matching, and logs it, when no matcher is registered
+// under that name, which ParseAlgorithm rejects.
+func match(chunk InputChunk, params Parameters) OutputChunk {
+	m, err := LookupMatcher(cmp.Or(params.Algorithm, AlgorithmBlock))
+	if err != nil {
+		if _, logged := fallbacks.LoadOrStore(params.Algorithm, true); !logged {
+			slog.Warn("falling back to block matching", "err", err)
+		}
+		m = BlockMatcher{}
+	}
+
+	return m.Match(chunk.Left, chunk.Right, chunk.Region, params)
+}
@@ -0,0 +1,53 @@
+package despair
+
+import (
+	"image"
+	"slices"
+	"testing"
+)
+
+func TestMatchersRegistered(t *testing.T) {
+	names := Matchers()
+	for _, want := range []Algorithm{AlgorithmBlock, AlgorithmSGM} {
+		if !slices.Contains(names, want) {
+			t.Errorf("Matchers() = %v, missing %q", names, want)
+		}
+	}
+	if !slices.IsSorted(names) {
+		t.Errorf("Matchers() = %v, want sorted names", names)
+	}
+	if _, err := ParseAlgorithm("no-such-matcher"); err == nil {
+		t.Error("ParseAlgorithm() accepted an unregistered name")
+	}
+}
+
+func TestRegisterMatcher(t *testing.T) {
+	const name Algorithm = "test-constant"
+	RegisterMatcher(name, MatcherFunc(func(
+		_, _ *image.Gray,
+		region image.Rectangle,
+		_ Parameters,
+	) OutputChunk {
+		data := make([]uint8, region.Dx()*region.Dy())
+		for i := range data {
+			data[i] = 42
+		}
+
+		return OutputChunk{DisparityData: data, Region: region}
+	}))
+
+	if got, err := ParseAlgorithm(string(name)); err != nil || got != name {
+		t.Fatalf("ParseAlgorithm(%q) = %q, %v", name, got, err)
+	}
+
+	left, right := newStereoPair(t, 16, 8, 2)
+	out := match(
+		InputChunk{Left: left, Right: right, Region: left.Rect},
+		Parameters{BlockSize: 3, MaxDisparity: 16, Algorithm: name},
+	)
+	for i, d := range out.DisparityData {
+		if d != 42 {
+			t.Fatalf("disparity %d = %d, want the registered matcher's 42", i, d)
+		}
+	}
+}
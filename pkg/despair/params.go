@@ -1,30 +1,34 @@
 package despair
 
 import (
-	"sync"
+	"fmt"
 	"sync/atomic"
 )
 
-var (
-	defaultParams   = atomic.Pointer[Parameters]{}
-	defaultParamsMu sync.Mutex
-)
+var defaultParams = atomic.Pointer[Parameters]{}
 
 func init() {
 	SetDefaultParams(Parameters{
 		BlockSize:    16,
 		MaxDisparity: 64,
+		LRThreshold:  1,
+		Subpixel:     SubpixelNone,
+		Cost:         CostSAD,
+		Algorithm:    AlgorithmBlock,
+		Paths:        8,
 	})
 }
 
 // SetDefaultParams sets the default stereoscopic algorithm parameters.
+//
+// The defaults seed new pipelines and are used for chunks that carry no
+// parameters of their own.
 func SetDefaultParams(params Parameters) {
-	defaultParamsMu.Lock()
-	defer defaultParamsMu.Unlock()
 	defaultParams.Store(&params)
 }
 
-// DefaultParams returns the default stereoscopic algorithm parameters.
+// DefaultParams returns the default stereoscopic algorithm parameters. The
+// returned value must not be modified.
 func DefaultParams() *Parameters {
 	return defaultParams.Load()
 }
@@ -34,4 +38,108 @@ func DefaultParams() *Parameters {
 type Parameters struct {
 	BlockSize    int `json:"blockSize"`
 	MaxDisparity int `json:"maxDisparity"`
+
+	// LRCheck enables the left-right consistency check. When enabled the
+	// right-referenced disparity is computed as well and pixels whose two
+	// estimates disagree by more than LRThreshold are marked invalid.
+	LRCheck     bool `json:"lrCheck"`
+	LRThreshold int  `json:"lrThreshold"`
+
+	// Subpixel selects how the integer disparity is refined around the
+	// cost minimum. The zero value disables refinement.
+	Subpixel SubpixelMode `json:"subpixel"`
+
+	// Cost selects the block matching cost. The zero value uses SAD.
+	Cost CostFunction `json:"cost"`
+
+	// Algorithm selects the disparity algorithm by the name of a registered
+	// Matcher. The zero value uses block matching.
+	Algorithm Algorithm `json:"algorithm"`
+
+	// P1 and P2 are the semi-global matching penalties for a disparity
+	// change of one pixel and of more than one pixel between neighbours.
+	// Zero selects penalties scaled to the block area and matching cost.
+	P1 int `json:"p1"`
+	P2 int `json:"p2"`
+
+	// Paths is the number of semi-global matching aggregation paths, 4 or 8.
+	Paths int `json:"paths"`
+}
+
+// Algorithm selects how disparities are computed from matching costs. It
+// names a Matcher registered with RegisterMatcher.
+type Algorithm string
+
+const (
+	// AlgorithmBlock picks, per pixel, the disparity with the lowest block
+	// matching cost.
+	AlgorithmBlock Algorithm = "block"
+	// AlgorithmSGM aggregates matching costs along several image paths with
+	// smoothness penalties before picking the disparity (semi-global matching).
+	AlgorithmSGM Algorithm = "sgm"
+)
+
+// ParseAlgorithm parses the name of a registered matcher, treating the
+// empty string as AlgorithmBlock.
+func ParseAlgorithm(s string) (Algorithm, error) {
+	if s == "" {
+		return AlgorithmBlock, nil
+	}
+	if _, err := LookupMatcher(Algorithm(s)); err != nil {
+		return "", err
+	}
+
+	return Algorithm(s), nil
+}
+
+// CostFunction selects the cost used to compare blocks of pixels.
+type CostFunction string
+
+const (
+	// CostSAD compares blocks by the sum of absolute intensity differences.
+	CostSAD CostFunction = "sad"
+	// CostCensus compares blocks by the sum of Hamming distances between
+	// their census transforms, which tolerates exposure and gain differences
+	// between the cameras.
+	CostCensus CostFunction = "census"
+)
+
+// ParseCostFunction parses a matching cost name, treating the empty string
+// as CostSAD.
+func ParseCostFunction(s string) (CostFunction, error) {
+	switch cost := CostFunction(s); cost {
+	case "", CostSAD:
+		return CostSAD, nil
+	case CostCensus:
+		return cost, nil
+	default:
+		return "", fmt.Errorf("unknown matching cost: %q", s)
+	}
+}
+
+// SubpixelMode selects the interpolation used to refine a disparity
+// between integer steps.
+type SubpixelMode string
+
+const (
+	// SubpixelNone keeps the integer disparity.
+	SubpixelNone SubpixelMode = "none"
+	// SubpixelParabolic fits a parabola through the three costs around the minimum.
+	SubpixelParabolic SubpixelMode = "parabolic"
+	// SubpixelEquiangular fits two lines of equal and opposite slope through the
+	// three costs around the minimum.
+	SubpixelEquiangular SubpixelMode = "equiangular"
+)
+
+// ParseSubpixelMode parses a subpixel mode name, treating the empty string
+// as SubpixelNone.
+func ParseSubpixelMode(s string) (SubpixelMode, error) {
+	switch mode := SubpixelMode(s); mode {
+	case "", SubpixelNone:
+		return SubpixelNone, nil
+	case SubpixelParabolic, SubpixelEquiangular:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown subpixel mode: %q", s)
+	}
 }
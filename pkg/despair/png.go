@@ -50,6 +50,34 @@ func MustLoadPNG(filename string) *image.Gray {
 	return img
 }
 
+// LoadRawPNG loads a fixed-point disparity map, as saved from Result.Raw,
+// keeping its 16-bit values.
+func LoadRawPNG(filename string) (*image.Gray16, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	img, err := png.Decode(file)
+	if err != nil {
+		return nil, err
+	}
+	if gray, ok := img.(*image.Gray16); ok {
+		return gray, nil
+	}
+
+	bounds := img.Bounds()
+	gray := image.NewGray16(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			gray.Set(x, y, img.At(x, y))
+		}
+	}
+
+	return gray, nil
+}
+
 // SavePNG saves a PNG image with optimizations to the given filename
 // and returns an error if one occurs.
 func SavePNG(filename string, img image.Image) error {
@@ -315,3 +315,23 @@ func saveTestImage(path string, img image.Image) error {
 
 	return png.Encode(file, img)
 }
+
+func TestLoadPNGRGBALuma(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "white.png")
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	img.SetRGBA(0, 0, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	img.SetRGBA(1, 0, color.RGBA{R: 128, G: 128, B: 128, A: 255})
+	if err := saveTestImage(path, img); err != nil {
+		t.Fatalf("Failed to create test RGBA image: %v", err)
+	}
+
+	got, err := LoadPNG(path)
+	if err != nil {
+		t.Fatalf("LoadPNG() error = %v", err)
+	}
+	for x, want := range []uint8{255, 128} {
+		if y := got.GrayAt(x, 0).Y; y != want {
+			t.Errorf("LoadPNG() luma at (%d,0) = %d, want %d", x, y, want)
+		}
+	}
+}
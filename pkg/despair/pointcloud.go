@@ -0,0 +1,246 @@
+package despair
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"os"
+	"strconv"
+)
+
+// Depth converts a fixed-point disparity map, as in Result.Raw, to metric
+// depth with Z = focal * baseline / disparity.
+//
+// The focal length is in pixels and the depth is in the unit of the
+// baseline. The depths are returned row by row; pixels without a disparity
+// have a depth of zero.
+func Depth(raw *image.Gray16, focal, baseline float64) []float32 {
+	width, height := raw.Rect.Dx(), raw.Rect.Dy()
+	depth := make([]float32, width*height)
+	scale := focal * baseline * DisparityScale
+
+	for y := range height { // y := 0; y < height; y++
+		row := raw.Pix[y*raw.Stride:]
+		for x := range width { // x := 0; x < width; x++
+			d := uint16(row[2*x])<<8 | uint16(row[2*x+1])
+			if d == 0 {
+				continue
+			}
+			depth[y*width+x] = float32(scale / float64(d))
+		}
+	}
+
+	return depth
+}
+
+// CloudPoint is a point of a point cloud, in the left rectified camera's
+// frame: x to the right, y down and z along the optical axis.
+type CloudPoint struct {
+	X, Y, Z float32
+	// Intensity is the gray level of the point in the left image.
+	Intensity uint8
+}
+
+// PointCloud is a set of points triangulated from a disparity map.
+type PointCloud struct {
+	Points []CloudPoint
+	// HasIntensity reports whether the points carry the left image's
+	// intensity, which is written as the point color.
+	HasIntensity bool
+}
+
+// NewPointCloud triangulates a point for every pixel of a fixed-point
+// disparity map that has a disparity.
+//
+// cam holds the intrinsics of the rectified cameras, as returned by
+// Rectifier.Intrinsics, and baseline the distance between them. When left
+// is not nil, the points are colored with the intensity of the rectified
+// left image, which must be the size of raw.
+func NewPointCloud(
+	raw *image.Gray16,
+	left *image.Gray,
+	cam CameraModel,
+	baseline float64,
+) (*PointCloud, error) {
+	if cam.Fx <= 0 || cam.Fy <= 0 {
+		return nil, fmt.Errorf("invalid focal lengths %v and %v", cam.Fx, cam.Fy)
+	}
+	if baseline <= 0 {
+		return nil, fmt.Errorf("invalid baseline %v", baseline)
+	}
+	if left != nil && left.Rect.Size() != raw.Rect.Size() {
+		return nil, fmt.Errorf("left image size %v does not match disparity size %v",
+			left.Rect.Size(), raw.Rect.Size())
+	}
+
+	width := raw.Rect.Dx()
+	depth := Depth(raw, cam.Fx, baseline)
+	cloud := &PointCloud{HasIntensity: left != nil}
+	for i, z := range depth {
+		if z == 0 {
+			continue
+		}
+		x, y := i%width, i/width
+		p := CloudPoint{
+			X: float32((float64(x) - cam.Cx) * float64(z) / cam.Fx),
+			Y: float32((float64(y) - cam.Cy) * float64(z) / cam.Fy),
+			Z: z,
+		}
+		if left != nil {
+			p.Intensity = left.Pix[y*left.Stride+x]
+		}
+		cloud.Points = append(cloud.Points, p)
+	}
+
+	return cloud, nil
+}
+
+// PointCloudFormat is a point cloud file format.
+type PointCloudFormat string
+
+const (
+	// FormatPLY is the ASCII Polygon File Format.
+	FormatPLY PointCloudFormat = "ply"
+	// FormatPLYBinary is the little-endian binary Polygon File Format.
+	FormatPLYBinary PointCloudFormat = "ply-binary"
+	// FormatPCD is the ASCII Point Cloud Data format of the Point Cloud
+	// Library.
+	FormatPCD PointCloudFormat = "pcd"
+)
+
+// ParsePointCloudFormat parses a point cloud format name.
+func ParsePointCloudFormat(s string) (PointCloudFormat, error) {
+	switch f := PointCloudFormat(s); f {
+	case FormatPLY, FormatPLYBinary, FormatPCD:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown point cloud format %q", s)
+	}
+}
+
+// Extension returns the file name extension of the format.
+func (f PointCloudFormat) Extension() string {
+	if f == FormatPCD {
+		return ".pcd"
+	}
+
+	return ".ply"
+}
+
+// Write writes the point cloud to w in the given format.
+func (pc *PointCloud) Write(w io.Writer, format PointCloudFormat) error {
+	bw := bufio.NewWriter(w)
+	var err error
+	switch format {
+	case FormatPLY:
+		err = pc.writePLY(bw, false)
+	case FormatPLYBinary:
+		err = pc.writePLY(bw, true)
+	case FormatPCD:
+		err = pc.writePCD(bw)
+	default:
+		err = fmt.Errorf("unknown point cloud format %q", format)
+	}
+	if err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+// SavePointCloud writes the point cloud to a file in the given format.
+func SavePointCloud(filename string, pc *PointCloud, format PointCloudFormat) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	err = pc.Write(file, format)
+	if err != nil {
+		file.Close()
+
+		return err
+	}
+
+	return file.Close()
+}
+
+func (pc *PointCloud) writePLY(w *bufio.Writer, binaryData bool) error {
+	format := "ascii"
+	if binaryData {
+		format = "binary_little_endian"
+	}
+	fmt.Fprintf(w, "ply\nformat %s 1.0\nelement vertex %d\n", format, len(pc.Points))
+	w.WriteString("property float x\nproperty float y\nproperty float z\n")
+	if pc.HasIntensity {
+		w.WriteString("property uchar red\nproperty uchar green\nproperty uchar blue\n")
+	}
+	w.WriteString("end_header\n")
+
+	var buf []byte
+	for _, p := range pc.Points {
+		buf = buf[:0]
+		if binaryData {
+			buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(p.X))
+			buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(p.Y))
+			buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(p.Z))
+			if pc.HasIntensity {
+				buf = append(buf, p.Intensity, p.Intensity, p.Intensity)
+			}
+		} else {
+			buf = appendFloats(buf, p)
+			if pc.HasIntensity {
+				for range 3 {
+					buf = append(buf, ' ')
+					buf = strconv.AppendUint(buf, uint64(p.Intensity), 10)
+				}
+			}
+			buf = append(buf, '\n')
+		}
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (pc *PointCloud) writePCD(w *bufio.Writer) error {
+	fields, size, typ, count := "x y z", "4 4 4", "F F F", "1 1 1"
+	if pc.HasIntensity {
+		// Colors are packed as 0x00RRGGBB
+		fields, size, typ, count = fields+" rgb", size+" 4", typ+" U", count+" 1"
+	}
+	fmt.Fprintf(w, "# .PCD v0.7 - Point Cloud Data file format\n"+
+		"VERSION 0.7\nFIELDS %s\nSIZE %s\nTYPE %s\nCOUNT %s\n"+
+		"WIDTH %d\nHEIGHT 1\nVIEWPOINT 0 0 0 1 0 0 0\nPOINTS %d\nDATA ascii\n",
+		fields, size, typ, count, len(pc.Points), len(pc.Points))
+
+	var buf []byte
+	for _, p := range pc.Points {
+		buf = appendFloats(buf[:0], p)
+		if pc.HasIntensity {
+			i := uint64(p.Intensity)
+			buf = append(buf, ' ')
+			buf = strconv.AppendUint(buf, i<<16|i<<8|i, 10)
+		}
+		buf = append(buf, '\n')
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// appendFloats appends the space separated coordinates of p.
+func appendFloats(buf []byte, p CloudPoint) []byte {
+	buf = strconv.AppendFloat(buf, float64(p.X), 'g', -1, 32)
+	buf = append(buf, ' ')
+	buf = strconv.AppendFloat(buf, float64(p.Y), 'g', -1, 32)
+	buf = append(buf, ' ')
+
+	return strconv.AppendFloat(buf, float64(p.Z), 'g', -1, 32)
+}
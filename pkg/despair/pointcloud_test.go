@@ -0,0 +1,135 @@
+package despair
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDepth(t *testing.T) {
+	raw := image.NewGray16(image.Rect(0, 0, 3, 1))
+	raw.SetGray16(0, 0, color.Gray16{Y: 10 * DisparityScale})
+	raw.SetGray16(1, 0, color.Gray16{Y: 4*DisparityScale + DisparityScale/2})
+
+	depth := Depth(raw, 500, 0.1)
+	want := []float32{5, 50.0 / 4.5, 0}
+	for i := range want {
+		if math.Abs(float64(depth[i]-want[i])) > 1e-5 {
+			t.Errorf("depth[%d] = %v, want %v", i, depth[i], want[i])
+		}
+	}
+}
+
+func TestNewPointCloud(t *testing.T) {
+	raw := image.NewGray16(image.Rect(0, 0, 4, 2))
+	raw.SetGray16(3, 1, color.Gray16{Y: 20 * DisparityScale})
+	left := image.NewGray(raw.Rect)
+	left.SetGray(3, 1, color.Gray{Y: 77})
+	cam := CameraModel{Fx: 100, Fy: 200, Cx: 1, Cy: 0}
+
+	cloud, err := NewPointCloud(raw, left, cam, 0.2)
+	if err != nil {
+		t.Fatalf("NewPointCloud() error = %v", err)
+	}
+	if len(cloud.Points) != 1 {
+		t.Fatalf("NewPointCloud() returned %d points, want 1", len(cloud.Points))
+	}
+	// Z = 100*0.2/20, X = (3-1)*Z/100, Y = (1-0)*Z/200
+	want := CloudPoint{X: 0.02, Y: 0.005, Z: 1, Intensity: 77}
+	got := cloud.Points[0]
+	if math.Abs(float64(got.X-want.X)) > 1e-6 || math.Abs(float64(got.Y-want.Y)) > 1e-6 ||
+		math.Abs(float64(got.Z-want.Z)) > 1e-6 || got.Intensity != want.Intensity {
+		t.Errorf("point = %+v, want %+v", got, want)
+	}
+
+	if _, err := NewPointCloud(raw, nil, CameraModel{}, 0.2); err == nil {
+		t.Error("NewPointCloud() accepted a zero focal length")
+	}
+}
+
+func TestPointCloudWrite(t *testing.T) {
+	cloud := &PointCloud{
+		Points: []CloudPoint{
+			{X: 1, Y: -2, Z: 3.5, Intensity: 10},
+			{X: 0.25, Y: 0, Z: 8, Intensity: 255},
+		},
+		HasIntensity: true,
+	}
+
+	var buf bytes.Buffer
+	if err := cloud.Write(&buf, FormatPLY); err != nil {
+		t.Fatalf("Write(ply) error = %v", err)
+	}
+	header, body := splitHeader(t, buf.Bytes(), "end_header\n")
+	if !strings.Contains(header, "format ascii 1.0\nelement vertex 2\n") ||
+		!strings.Contains(header, "property uchar red\n") {
+		t.Errorf("unexpected PLY header:\n%s", header)
+	}
+	if want := "1 -2 3.5 10 10 10\n0.25 0 8 255 255 255\n"; string(body) != want {
+		t.Errorf("PLY body = %q, want %q", body, want)
+	}
+
+	buf.Reset()
+	if err := cloud.Write(&buf, FormatPLYBinary); err != nil {
+		t.Fatalf("Write(ply-binary) error = %v", err)
+	}
+	header, body = splitHeader(t, buf.Bytes(), "end_header\n")
+	if !strings.Contains(header, "format binary_little_endian 1.0\n") {
+		t.Errorf("unexpected binary PLY header:\n%s", header)
+	}
+	if len(body) != 2*15 {
+		t.Fatalf("binary PLY body is %d bytes, want %d", len(body), 2*15)
+	}
+	if z := math.Float32frombits(binary.LittleEndian.Uint32(body[8:])); z != 3.5 || body[14] != 10 {
+		t.Errorf("binary PLY first point has z %v and blue %d", z, body[14])
+	}
+
+	buf.Reset()
+	if err := cloud.Write(&buf, FormatPCD); err != nil {
+		t.Fatalf("Write(pcd) error = %v", err)
+	}
+	header, body = splitHeader(t, buf.Bytes(), "DATA ascii\n")
+	if !strings.Contains(header, "FIELDS x y z rgb\n") || !strings.Contains(header, "POINTS 2\n") {
+		t.Errorf("unexpected PCD header:\n%s", header)
+	}
+	if want := "1 -2 3.5 657930\n0.25 0 8 16777215\n"; string(body) != want {
+		t.Errorf("PCD body = %q, want %q", body, want)
+	}
+}
+
+func TestSavePointCloudWithoutIntensity(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cloud.ply")
+	cloud := &PointCloud{Points: []CloudPoint{{X: 1, Y: 2, Z: 3}}}
+	if err := SavePointCloud(path, cloud, FormatPLY); err != nil {
+		t.Fatalf("SavePointCloud() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(data); strings.Contains(s, "red") || !strings.HasSuffix(s, "end_header\n1 2 3\n") {
+		t.Errorf("unexpected PLY without intensity:\n%s", s)
+	}
+
+	if _, err := ParsePointCloudFormat("obj"); err == nil {
+		t.Error("ParsePointCloudFormat() accepted an unknown format")
+	}
+}
+
+// splitHeader splits a point cloud file after the line that ends its
+// header.
+func splitHeader(t *testing.T, data []byte, end string) (string, []byte) {
+	t.Helper()
+	i := bytes.Index(data, []byte(end))
+	if i < 0 {
+		t.Fatalf("no %q in output", end)
+	}
+
+	return string(data[:i+len(end)]), data[i+len(end):]
+}
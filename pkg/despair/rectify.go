@@ -0,0 +1,291 @@
+package despair
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"os"
+)
+
+// CameraModel holds the intrinsic parameters and lens distortion of a
+// pinhole camera, in pixels.
+type CameraModel struct {
+	Fx float64 `json:"fx"`
+	Fy float64 `json:"fy"`
+	Cx float64 `json:"cx"`
+	Cy float64 `json:"cy"`
+
+	// Distortion holds the Brown-Conrady distortion coefficients
+	// k1, k2, p1, p2 and k3.
+	Distortion [5]float64 `json:"distortion"`
+}
+
+// Calibration describes a calibrated stereo camera pair.
+type Calibration struct {
+	// Width and Height are the size of the calibrated images.
+	Width  int `json:"width"`
+	Height int `json:"height"`
+
+	Left  CameraModel `json:"left"`
+	Right CameraModel `json:"right"`
+
+	// R and T transform points from the left camera's coordinate frame to
+	// the right camera's: Xr = R*Xl + T. R is stored in row-major order and
+	// T is in the unit of the calibration target, typically meters.
+	R [9]float64 `json:"r"`
+	T [3]float64 `json:"t"`
+}
+
+// Validate reports whether the calibration describes a usable stereo pair.
+func (c Calibration) Validate() error {
+	if c.Width <= 0 || c.Height <= 0 {
+		return fmt.Errorf("invalid calibration image size: %dx%d", c.Width, c.Height)
+	}
+	if c.Left.Fx <= 0 || c.Left.Fy <= 0 || c.Right.Fx <= 0 || c.Right.Fy <= 0 {
+		return errors.New("calibration focal lengths must be positive")
+	}
+	if norm(c.T) == 0 {
+		return errors.New("calibration baseline must not be zero")
+	}
+
+	return nil
+}
+
+// LoadCalibration reads a calibration from a JSON file.
+func LoadCalibration(filename string) (Calibration, error) {
+	var cal Calibration
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return cal, fmt.Errorf("failed to read calibration: %w", err)
+	}
+	err = json.Unmarshal(data, &cal)
+	if err != nil {
+		return cal, fmt.Errorf("failed to parse calibration: %w", err)
+	}
+
+	return cal, cal.Validate()
+}
+
+// SaveCalibration writes a calibration to a JSON file.
+func SaveCalibration(filename string, cal Calibration) error {
+	data, err := json.MarshalIndent(cal, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode calibration: %w", err)
+	}
+
+	return os.WriteFile(filename, append(data, '\n'), 0o644)
+}
+
+// Rectifier remaps stereo pairs so that corresponding points lie on the
+// same image row, as block matching expects.
+//
+// The remapping of each pixel is computed once from the calibration, so
+// rectifying a frame only costs a bilinear interpolation per pixel.
+type Rectifier struct {
+	calibration   Calibration
+	width, height int
+	intrinsics    CameraModel
+	baseline      float64
+	left, right   rectifyMap
+}
+
+// rectifyMap holds, for every rectified pixel, the position in the source
+// image it is sampled from. Negative positions lie outside the source.
+type rectifyMap struct {
+	x, y []float32
+}
+
+// NewRectifier computes the rectification maps of a calibration.
+//
+// Both cameras are rotated about their optical centres so that their x
+// axes are parallel to the baseline, the left camera looks along the
+// average of the two optical axes, and both share the same intrinsics.
+func NewRectifier(cal Calibration) (*Rectifier, error) {
+	if err := cal.Validate(); err != nil {
+		return nil, err
+	}
+
+	// The right camera's centre in the left camera's frame is -R^T*T
+	rt := transpose(cal.R)
+	centre := matVec(rt, cal.T)
+	centre = [3]float64{-centre[0], -centre[1], -centre[2]}
+
+	// New x axis along the baseline, z axis close to both optical axes
+	xAxis := normalize(centre)
+	opticalAxis := [3]float64{cal.R[6], cal.R[7], cal.R[8] + 1}
+	yAxis := normalize(cross(opticalAxis, xAxis))
+	zAxis := cross(xAxis, yAxis)
+	rect := [9]float64{
+		xAxis[0], xAxis[1], xAxis[2],
+		yAxis[0], yAxis[1], yAxis[2],
+		zAxis[0], zAxis[1], zAxis[2],
+	}
+
+	focal := (cal.Left.Fx + cal.Left.Fy + cal.Right.Fx + cal.Right.Fy) / 4
+	r := &Rectifier{
+		calibration: cal,
+		width:       cal.Width,
+		height:      cal.Height,
+		intrinsics: CameraModel{
+			Fx: focal,
+			Fy: focal,
+			Cx: (cal.Left.Cx + cal.Right.Cx) / 2,
+			Cy: (cal.Left.Cy + cal.Right.Cy) / 2,
+		},
+		baseline: norm(cal.T),
+	}
+	r.left = r.computeMap(cal.Left, rect)
+	r.right = r.computeMap(cal.Right, matMul(rect, rt))
+
+	return r, nil
+}
+
+// Calibration returns the calibration the rectifier was computed from.
+func (r *Rectifier) Calibration() Calibration {
+	return r.calibration
+}
+
+// Intrinsics returns the shared, distortion free, intrinsics of the
+// rectified cameras.
+func (r *Rectifier) Intrinsics() CameraModel {
+	return r.intrinsics
+}
+
+// Baseline returns the distance between the optical centres of the two
+// cameras, in the unit of the calibration.
+func (r *Rectifier) Baseline() float64 {
+	return r.baseline
+}
+
+// Rectify remaps a stereo pair with the precomputed rectification maps.
+//
+// Rectified pixels that fall outside the source images are black.
+func (r *Rectifier) Rectify(left, right *image.Gray) (*image.Gray, *image.Gray, error) {
+	for _, img := range []*image.Gray{left, right} {
+		if img.Rect.Dx() != r.width || img.Rect.Dy() != r.height {
+			return nil, nil, fmt.Errorf(
+				"image size %dx%d does not match calibration size %dx%d",
+				img.Rect.Dx(), img.Rect.Dy(), r.width, r.height,
+			)
+		}
+	}
+
+	return r.left.remap(left), r.right.remap(right), nil
+}
+
+// computeMap computes the map of a camera whose rotation into the
+// rectified frame is rot.
+func (r *Rectifier) computeMap(cam CameraModel, rot [9]float64) rectifyMap {
+	m := rectifyMap{
+		x: make([]float32, r.width*r.height),
+		y: make([]float32, r.width*r.height),
+	}
+	// Rectified rays are rotated back into the camera's frame
+	inverse := transpose(rot)
+	k1, k2, p1, p2, k3 := cam.Distortion[0], cam.Distortion[1],
+		cam.Distortion[2], cam.Distortion[3], cam.Distortion[4]
+
+	for v := range r.height { // v := 0; v < height; v++
+		for u := range r.width { // u := 0; u < width; u++
+			i := v*r.width + u
+			ray := matVec(inverse, [3]float64{
+				(float64(u) - r.intrinsics.Cx) / r.intrinsics.Fx,
+				(float64(v) - r.intrinsics.Cy) / r.intrinsics.Fy,
+				1,
+			})
+			if ray[2] <= 0 {
+				// Behind the camera
+				m.x[i], m.y[i] = -1, -1
+
+				continue
+			}
+
+			x, y := ray[0]/ray[2], ray[1]/ray[2]
+			r2 := x*x + y*y
+			radial := 1 + r2*(k1+r2*(k2+r2*k3))
+			xd := x*radial + 2*p1*x*y + p2*(r2+2*x*x)
+			yd := y*radial + p1*(r2+2*y*y) + 2*p2*x*y
+
+			m.x[i] = float32(cam.Fx*xd + cam.Cx)
+			m.y[i] = float32(cam.Fy*yd + cam.Cy)
+		}
+	}
+
+	return m
+}
+
+// remap samples src at the map's positions with bilinear interpolation.
+func (m rectifyMap) remap(src *image.Gray) *image.Gray {
+	width, height := src.Rect.Dx(), src.Rect.Dy()
+	dst := image.NewGray(image.Rect(0, 0, width, height))
+	maxX, maxY := float32(width-1), float32(height-1)
+
+	for i := range m.x {
+		x, y := m.x[i], m.y[i]
+		if x < 0 || y < 0 || x > maxX || y > maxY {
+			continue
+		}
+
+		x0, y0 := int(x), int(y)
+		x1, y1 := min(x0+1, width-1), min(y0+1, height-1)
+		fx, fy := x-float32(x0), y-float32(y0)
+
+		row0 := src.Pix[y0*src.Stride:]
+		row1 := src.Pix[y1*src.Stride:]
+		top := float32(row0[x0])*(1-fx) + float32(row0[x1])*fx
+		bottom := float32(row1[x0])*(1-fx) + float32(row1[x1])*fx
+
+		dst.Pix[(i/width)*dst.Stride+i%width] = uint8(top*(1-fy) + bottom*fy + 0.5)
+	}
+
+	return dst
+}
+
+func matMul(a, b [9]float64) [9]float64 {
+	var out [9]float64
+	for i := range 3 {
+		for j := range 3 {
+			for k := range 3 {
+				out[i*3+j] += a[i*3+k] * b[k*3+j]
+			}
+		}
+	}
+
+	return out
+}
+
+func matVec(m [9]float64, v [3]float64) [3]float64 {
+	return [3]float64{
+		m[0]*v[0] + m[1]*v[1] + m[2]*v[2],
+		m[3]*v[0] + m[4]*v[1] + m[5]*v[2],
+		m[6]*v[0] + m[7]*v[1] + m[8]*v[2],
+	}
+}
+
+func transpose(m [9]float64) [9]float64 {
+	return [9]float64{
+		m[0], m[3], m[6],
+		m[1], m[4], m[7],
+		m[2], m[5], m[8],
+	}
+}
+
+func cross(a, b [3]float64) [3]float64 {
+	return [3]float64{
+		a[1]*b[2] - a[2]*b[1],
+		a[2]*b[0] - a[0]*b[2],
+		a[0]*b[1] - a[1]*b[0],
+	}
+}
+
+func norm(v [3]float64) float64 {
+	return math.Sqrt(v[0]*v[0] + v[1]*v[1] + v[2]*v[2])
+}
+
+func normalize(v [3]float64) [3]float64 {
+	n := norm(v)
+
+	return [3]float64{v[0] / n, v[1] / n, v[2] / n}
+}
@@ -0,0 +1,151 @@
+package despair
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+func TestRectifyIdentity(t *testing.T) {
+	left, right := newStereoPair(t, 48, 32, 4)
+	model := CameraModel{Fx: 50, Fy: 50, Cx: 24, Cy: 16}
+	rectifier, err := NewRectifier(Calibration{
+		Width:  48,
+		Height: 32,
+		Left:   model,
+		Right:  model,
+		R:      [9]float64{1, 0, 0, 0, 1, 0, 0, 0, 1},
+		T:      [3]float64{-0.1, 0, 0},
+	})
+	if err != nil {
+		t.Fatalf("NewRectifier() error = %v", err)
+	}
+
+	gotLeft, gotRight, err := rectifier.Rectify(left, right)
+	if err != nil {
+		t.Fatalf("Rectify() error = %v", err)
+	}
+	for i := range left.Pix {
+		if gotLeft.Pix[i] != left.Pix[i] || gotRight.Pix[i] != right.Pix[i] {
+			t.Fatalf("aligned pair changed by rectification at pixel %d", i)
+		}
+	}
+	if b := rectifier.Baseline(); math.Abs(b-0.1) > 1e-12 {
+		t.Errorf("Baseline() = %v, want 0.1", b)
+	}
+}
+
+func TestRectifyAlignsRows(t *testing.T) {
+	const width, height = 160, 120
+	cal := Calibration{
+		Width:  width,
+		Height: height,
+		Left: CameraModel{
+			Fx: 150, Fy: 152, Cx: 81, Cy: 58,
+			Distortion: [5]float64{-0.1, 0.02, 0.001, -0.002, 0},
+		},
+		Right: CameraModel{
+			Fx: 148, Fy: 149, Cx: 78, Cy: 62,
+			Distortion: [5]float64{-0.08, 0.01, 0, 0.001, 0},
+		},
+		R: rotation([3]float64{0.01, -0.03, 0.02}),
+		T: [3]float64{-0.12, 0.004, 0.002},
+	}
+	rectifier, err := NewRectifier(cal)
+	if err != nil {
+		t.Fatalf("NewRectifier() error = %v", err)
+	}
+
+	// Points in front of the rig seen by both cameras
+	for _, point := range [][3]float64{
+		{0, 0, 2}, {0.2, -0.1, 1.5}, {-0.3, 0.2, 3}, {0.1, 0.25, 1.2},
+	} {
+		rightPoint := matVec(cal.R, point)
+		for i := range rightPoint {
+			rightPoint[i] += cal.T[i]
+		}
+		lu, lv := project(cal.Left, point)
+		ru, rv := project(cal.Right, rightPoint)
+
+		// Find where the point lands in each rectified image
+		lx, ly := findInMap(rectifier.left, width, lu, lv)
+		rx, ry := findInMap(rectifier.right, width, ru, rv)
+		if math.Abs(ly-ry) > 1 {
+			t.Errorf("point %v rectified to rows %.2f and %.2f", point, ly, ry)
+		}
+		if lx <= rx {
+			t.Errorf("point %v has non-positive disparity %.2f", point, lx-rx)
+		}
+	}
+}
+
+func TestCalibrationRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calibration.json")
+	want := Calibration{
+		Width:  640,
+		Height: 480,
+		Left:   CameraModel{Fx: 500, Fy: 501, Cx: 320, Cy: 240, Distortion: [5]float64{0.1}},
+		Right:  CameraModel{Fx: 502, Fy: 503, Cx: 321, Cy: 239},
+		R:      [9]float64{1, 0, 0, 0, 1, 0, 0, 0, 1},
+		T:      [3]float64{-0.06, 0, 0},
+	}
+	if err := SaveCalibration(path, want); err != nil {
+		t.Fatalf("SaveCalibration() error = %v", err)
+	}
+	got, err := LoadCalibration(path)
+	if err != nil {
+		t.Fatalf("LoadCalibration() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("LoadCalibration() = %+v, want %+v", got, want)
+	}
+
+	if _, err := NewRectifier(Calibration{Width: 10, Height: 10}); err == nil {
+		t.Error("NewRectifier() accepted an empty calibration")
+	}
+}
+
+// rotation returns the rotation matrix of a Rodrigues rotation vector.
+func rotation(v [3]float64) [9]float64 {
+	theta := norm(v)
+	k := normalize(v)
+	c, s := math.Cos(theta), math.Sin(theta)
+	kx := [9]float64{0, -k[2], k[1], k[2], 0, -k[0], -k[1], k[0], 0}
+	kx2 := matMul(kx, kx)
+
+	var r [9]float64
+	for i := range r {
+		r[i] = s*kx[i] + (1-c)*kx2[i]
+	}
+	r[0], r[4], r[8] = r[0]+1, r[4]+1, r[8]+1
+
+	return r
+}
+
+// project projects a point in the camera's frame to distorted pixel
+// coordinates.
+func project(cam CameraModel, p [3]float64) (float64, float64) {
+	x, y := p[0]/p[2], p[1]/p[2]
+	k1, k2, p1, p2, k3 := cam.Distortion[0], cam.Distortion[1],
+		cam.Distortion[2], cam.Distortion[3], cam.Distortion[4]
+	r2 := x*x + y*y
+	radial := 1 + r2*(k1+r2*(k2+r2*k3))
+	xd := x*radial + 2*p1*x*y + p2*(r2+2*x*x)
+	yd := y*radial + p1*(r2+2*y*y) + 2*p2*x*y
+
+	return cam.Fx*xd + cam.Cx, cam.Fy*yd + cam.Cy
+}
+
+// findInMap returns the rectified pixel whose source position is closest
+// to (u, v).
+func findInMap(m rectifyMap, width int, u, v float64) (float64, float64) {
+	best, bestDist := 0, math.Inf(1)
+	for i := range m.x {
+		du, dv := float64(m.x[i])-u, float64(m.y[i])-v
+		if dist := du*du + dv*dv; dist < bestDist {
+			best, bestDist = i, dist
+		}
+	}
+
+	return float64(best % width), float64(best / width)
+}
@@ -0,0 +1,149 @@
+package despair
+
+import "image"
+
+// runningSAD computes the same block costs as SumAbsoluteDifferences for
+// blocks on a common image row, in O(width*disparities) per row
+// independent of the block size.
+//
+// SumAbsoluteDifferences clips the left and right windows at the image
+// edges independently, so the pixel compared with the left pixel at column
+// c is the right pixel at column c-s, where the shift s only depends on how
+// far each window was clipped. For every shift the absolute differences are
+// summed over the window rows per column, kept up to date with running
+// sums as the row advances, and turned into prefix sums along the row so
+// each block cost is the difference of two prefix sums.
+type runningSAD struct {
+	left, right *image.Gray
+	blockSize   int
+	halfSize    int
+	maxShift    int
+
+	// Left image columns covered by the sums: [minX, maxX)
+	minX, maxX int
+	// Row the sums currently describe, or -1 before the first row
+	y int
+
+	// colSums holds, per shift, the absolute differences of every column
+	// summed over the window rows; prefix holds their prefix sums.
+	colSums []int32
+	prefix  []int32
+}
+
+// newRunningSAD returns the running-sum SAD cost of the chunk's image pair
+// or nil when the images do not allow it, in which case the cost must be
+// computed with SumAbsoluteDifferences directly.
+func newRunningSAD(chunk InputChunk, params Parameters) *runningSAD {
+	left, right := chunk.Left, chunk.Right
+	// SumAbsoluteDifferences addresses pixels relative to the origin and
+	// clips against both images, the sums assume both match.
+	if left.Rect != right.Rect || left.Rect.Min != (image.Point{}) {
+		return nil
+	}
+
+	halfSize := params.BlockSize / 2
+	rs := &runningSAD{
+		left:      left,
+		right:     right,
+		blockSize: params.BlockSize,
+		halfSize:  halfSize,
+		maxShift:  params.MaxDisparity,
+		// The right-referenced search looks up to MaxDisparity columns
+		// right of the region.
+		minX: max(chunk.Region.Min.X-halfSize, 0),
+		maxX: min(chunk.Region.Max.X+params.MaxDisparity+halfSize, left.Rect.Max.X),
+		y:    -1,
+	}
+	width := max(rs.maxX-rs.minX, 0)
+	rs.colSums = make([]int32, (rs.maxShift+1)*width)
+	rs.prefix = make([]int32, (rs.maxShift+1)*(width+1))
+
+	return rs
+}
+
+// cost returns the SAD of the left block centred on (leftX, leftY) and the
+// right block centred on (rightX, rightY), bit-identical to
+// SumAbsoluteDifferences.
+func (rs *runningSAD) cost(leftX, leftY, rightX, rightY int) int {
+	shift := max(leftX-rs.halfSize, 0) - max(rightX-rs.halfSize, 0)
+	startX := max(leftX-rs.halfSize, 0)
+	endX := min(leftX+rs.halfSize+1, rs.left.Rect.Max.X)
+	if leftY != rightY || shift < 0 || shift > rs.maxShift ||
+		startX < rs.minX || endX > rs.maxX ||
+		leftY < 0 || leftY >= rs.left.Rect.Max.Y {
+		return SumAbsoluteDifferences(
+			rs.left,
+			rs.right,
+			leftX,
+			leftY,
+			rightX,
+			rightY,
+			rs.blockSize,
+		)
+	}
+	if startX >= endX {
+		return 0
+	}
+
+	rs.seek(leftY)
+	prefix := rs.prefix[shift*(rs.maxX-rs.minX+1):]
+
+	return int(prefix[endX-rs.minX] - prefix[startX-rs.minX])
+}
+
+// seek updates the sums to describe row y, advancing them incrementally
+// when y follows the current row.
+func (rs *runningSAD) seek(y int) {
+	if y == rs.y {
+		return
+	}
+
+	height := rs.left.Rect.Max.Y
+	if rs.y >= 0 && y == rs.y+1 {
+		// Slide the window down by one row
+		if removed := y - 1 - rs.halfSize; removed >= 0 {
+			rs.addRow(removed, -1)
+		}
+		if added := y + rs.halfSize; added < height {
+			rs.addRow(added, 1)
+		}
+	} else {
+		clear(rs.colSums)
+		for row := max(y-rs.halfSize, 0); row < min(y+rs.halfSize+1, height); row++ {
+			rs.addRow(row, 1)
+		}
+	}
+	rs.y = y
+
+	// Rebuild the prefix sums of every shift
+	width := rs.maxX - rs.minX
+	for shift := range rs.maxShift + 1 { // shift := 0; shift <= maxShift; shift++
+		colSums := rs.colSums[shift*width:][:width]
+		prefix := rs.prefix[shift*(width+1):][:width+1]
+		var sum int32
+		for i, c := range colSums {
+			prefix[i] = sum
+			sum += c
+		}
+		prefix[width] = sum
+	}
+}
+
+// addRow adds, or removes when sign is -1, the absolute differences of a
+// row to the column sums of every shift.
+func (rs *runningSAD) addRow(row, sign int) {
+	width := rs.maxX - rs.minX
+	leftRow := rs.left.Pix[row*rs.left.Stride:]
+	rightRow := rs.right.Pix[row*rs.right.Stride:]
+	for shift := range rs.maxShift + 1 { // shift := 0; shift <= maxShift; shift++
+		colSums := rs.colSums[shift*width:][:width]
+		// Columns left of the shift have no right pixel and are never used
+		for x := max(rs.minX, shift); x < rs.maxX; x++ {
+			diff := int32(leftRow[x]) - int32(rightRow[x-shift])
+			if diff < 0 {
+				diff = -diff
+			}
+			colSums[x-rs.minX] += int32(sign) * diff
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package despair
+
+import (
+	"fmt"
+	"image"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunningSADMatchesDirect(t *testing.T) {
+	left, right := newStereoPair(t, 48, 24, 5)
+	regions := []image.Rectangle{
+		left.Rect,
+		image.Rect(0, 0, 48, 3),
+		image.Rect(10, 7, 30, 15),
+		image.Rect(40, 20, 48, 24),
+	}
+
+	for _, blockSize := range []int{1, 3, 4, 7, 16} {
+		for _, region := range regions {
+			params := Parameters{BlockSize: blockSize, MaxDisparity: 16}
+			chunk := InputChunk{Left: left, Right: right, Region: region}
+			rs := newRunningSAD(chunk, params)
+			if rs == nil {
+				t.Fatal("newRunningSAD() = nil for a matching image pair")
+			}
+
+			for y := region.Min.Y; y < region.Max.Y; y++ {
+				for x := region.Min.X; x < region.Max.X; x++ {
+					for d := 0; d <= params.MaxDisparity; d++ {
+						// Left-referenced search
+						if x-d >= 0 {
+							want := SumAbsoluteDifferences(left, right, x, y, x-d, y, blockSize)
+							if got := rs.cost(x, y, x-d, y); got != want {
+								t.Fatalf("block %d, region %v: cost(%d,%d,%d,%d) = %d, want %d",
+									blockSize, region, x, y, x-d, y, got, want)
+							}
+						}
+						// Right-referenced search
+						if x+d < left.Rect.Max.X {
+							want := SumAbsoluteDifferences(left, right, x+d, y, x, y, blockSize)
+							if got := rs.cost(x+d, y, x, y); got != want {
+								t.Fatalf("block %d, region %v: cost(%d,%d,%d,%d) = %d, want %d",
+									blockSize, region, x+d, y, x, y, got, want)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestRunningSADMatchBlockIdentical(t *testing.T) {
+	left := MustLoadPNG(filepath.Join("..", "..", "testdata", "L_00001.png"))
+	right := MustLoadPNG(filepath.Join("..", "..", "testdata", "R_00001.png"))
+	region := image.Rect(0, 200, left.Rect.Dx(), 216)
+	params := Parameters{
+		BlockSize:    9,
+		MaxDisparity: 64,
+		LRCheck:      true,
+		LRThreshold:  1,
+		Subpixel:     SubpixelParabolic,
+	}
+	chunk := InputChunk{Left: left, Right: right, Region: region}
+
+	got := matchBlock(chunk, params)
+	want := matchBlockWith(chunk, params, directSADCost(chunk, params))
+	for i := range want.RawDisparity {
+		if got.RawDisparity[i] != want.RawDisparity[i] || got.Valid[i] != want.Valid[i] {
+			t.Fatalf("pixel %d = (%d, %t), want (%d, %t)", i,
+				got.RawDisparity[i], got.Valid[i], want.RawDisparity[i], want.Valid[i])
+		}
+	}
+}
+
+// directSADCost returns the block cost computed with SumAbsoluteDifferences
+// for every block, the reference for the running sums.
+func directSADCost(chunk InputChunk, params Parameters) blockCost {
+	return func(leftX, leftY, rightX, rightY int) int {
+		return SumAbsoluteDifferences(
+			chunk.Left,
+			chunk.Right,
+			leftX,
+			leftY,
+			rightX,
+			rightY,
+			params.BlockSize,
+		)
+	}
+}
+
+func BenchmarkSADCost(b *testing.B) {
+	left := MustLoadPNG(filepath.Join("..", "..", "testdata", "L_00001.png"))
+	right := MustLoadPNG(filepath.Join("..", "..", "testdata", "R_00001.png"))
+	region := image.Rect(0, 200, left.Rect.Dx(), 208)
+
+	for _, blockSize := range []int{5, 15, 31} {
+		params := Parameters{BlockSize: blockSize, MaxDisparity: 64}
+		chunk := InputChunk{Left: left, Right: right, Region: region}
+		costs := map[string]func() blockCost{
+			"direct":  func() blockCost { return directSADCost(chunk, params) },
+			"running": func() blockCost { return newRunningSAD(chunk, params).cost },
+		}
+		for _, name := range []string{"direct", "running"} {
+			b.Run(fmt.Sprintf("%s/block=%d", name, blockSize), func(b *testing.B) {
+				for range b.N {
+					// Compute the full cost volume of the region
+					cost := costs[name]()
+					for y := region.Min.Y; y < region.Max.Y; y++ {
+						for x := region.Min.X; x < region.Max.X; x++ {
+							for d := 0; d <= params.MaxDisparity && d <= x; d++ {
+								cost(x, y, x-d, y)
+							}
+						}
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkMatchBlock(b *testing.B) {
+	left := MustLoadPNG(filepath.Join("..", "..", "testdata", "L_00001.png"))
+	right := MustLoadPNG(filepath.Join("..", "..", "testdata", "R_00001.png"))
+	chunk := InputChunk{
+		Left:   left,
+		Right:  right,
+		Region: image.Rect(0, 200, left.Rect.Dx(), 216),
+	}
+	params := Parameters{BlockSize: 15, MaxDisparity: 64}
+
+	b.Run("direct", func(b *testing.B) {
+		for range b.N {
+			matchBlockWith(chunk, params, directSADCost(chunk, params))
+		}
+	})
+	b.Run("running", func(b *testing.B) {
+		for range b.N {
+			matchBlock(chunk, params)
+		}
+	})
+}
@@ -12,18 +12,47 @@ import (
 type InputChunk struct {
 	Left, Right *image.Gray
 	Region      image.Rectangle
+
+	// Params are the parameters the chunk is processed with. All chunks of
+	// a frame should share one snapshot so the frame is computed with
+	// consistent settings. Nil uses DefaultParams at the time the chunk is
+	// processed.
+	Params *Parameters
 }
 
+// DisparityScale is the fixed-point scale of raw disparities: a raw value
+// divided by DisparityScale is the disparity in pixels.
+const DisparityScale = 16
+
 // OutputChunk represents the processed disparity data for a region.
 type OutputChunk struct {
+	// DisparityData is the disparity normalized to 0-255 for display.
 	DisparityData []uint8
-	Region        image.Rectangle
+	// RawDisparity is the disparity in fixed point, scaled by DisparityScale.
+	RawDisparity []uint16
+	// Valid reports, for each entry of DisparityData, whether the pixel
+	// passed the left-right consistency check. Every pixel is valid when
+	// the check is disabled.
+	Valid  []bool
+	Region image.Rectangle
+}
+
+// Result is an assembled disparity map together with its validity mask.
+type Result struct {
+	// Disparity is the normalized disparity map, intended for display.
+	Disparity *image.Gray
+	// Raw is the fixed-point disparity map, scaled by DisparityScale.
+	Raw *image.Gray16
+	// Valid is the validity mask: 255 marks a trusted pixel, 0 marks a
+	// pixel that was occluded or failed the left-right consistency check.
+	Valid *image.Gray
 }
 
 // SetupConcurrentSAD sets up a concurrent SAD processing pipeline.
 //
 // It returns an input channel to feed image chunks into and an
-// output channel to receive results from.
+// output channel to receive results from. Each chunk is processed by the
+// Matcher registered under the Algorithm parameter.
 //
 // If the input channel is closed, the processing pipeline will stop.
 func SetupConcurrentSAD(
@@ -45,60 +74,13 @@ func SetupConcurrentSAD(
 
 			// Process chunks until the input channel is closed
 			for chunk := range inputChan {
-				data := make([]uint8,
-					chunk.Region.Dx()*chunk.Region.Dy(),
-				)
-				defaultParamsMu.Lock()
-				params := *defaultParams.Load()
-				defaultParamsMu.Unlock()
-				// Process each row in the region
-				for y := range chunk.Region.Dy() { // y := 0; y < height; y++
-					globalY := chunk.Region.Min.Y + y
-					for x := range chunk.Region.Dx() { // x := 0; x < width; x++
-						// Calculate disparity for this pixel
-						minSAD := math.MaxInt32
-						var bestDisparity int
-
-						for d := 0; d <= params.MaxDisparity; d++ {
-							// Skip if we would go beyond the left edge
-							if (chunk.Region.Min.X+x)-d <
-								chunk.Left.Rect.Min.X {
-								continue
-							}
-
-							sad := SumAbsoluteDifferences(
-								chunk.Left,
-								chunk.Right,
-								chunk.Region.Min.X+x,
-								globalY,
-								chunk.Region.Min.X+x-d,
-								globalY,
-								params.BlockSize,
-							)
-
-							if sad < minSAD {
-								minSAD = sad
-								bestDisparity = d
-
-								// Early termination for perfect matches
-								if sad == 0 {
-									break
-								}
-							}
-						}
-
-						// Store the disparity value
-						data[y*chunk.Region.Dx()+x] = uint8(
-							(bestDisparity * 255) / params.MaxDisparity,
-						)
-					}
+				params := chunk.Params
+				if params == nil {
+					params = DefaultParams()
 				}
 
 				// Send the processed chunk to the output channel
-				outputChan <- OutputChunk{
-					DisparityData: data,
-					Region:        chunk.Region,
-				}
+				outputChan <- match(chunk, *params)
 			}
 		}()
 	}
@@ -120,10 +102,18 @@ func RunSad(
 	left, right *image.Gray,
 	blockSize, maxDisparity int,
 ) *image.Gray {
-	SetDefaultParams(Parameters{
+	return Run(left, right, Parameters{
 		BlockSize:    blockSize,
 		MaxDisparity: maxDisparity,
-	})
+	}).Disparity
+}
+
+// Run sets up a pipeline, feeds it the images with params, and assembles
+// the disparity map and its validity mask.
+//
+// The parameters are bound to the frame, so Run neither reads nor changes
+// the default parameters.
+func Run(left, right *image.Gray, params Parameters) Result {
 	// Determine number of workers and chunks
 	numWorkers := runtime.NumCPU() * 4
 	numChunks := numWorkers * 4
@@ -135,13 +125,13 @@ func RunSad(
 	var dims = left.Rect
 	chunks := make([]image.Rectangle, 0, numChunks)
 
-	chunkWidth := int(
+	chunkWidth := max(1, int(
 		math.Sqrt(
-			float64((dims.Dx() * dims.Dy()) / numChunks),
+			float64((dims.Dx()*dims.Dy())/numChunks),
 		),
-	)
+	))
 	horChunks := max(1, dims.Dx()/chunkWidth)
-	verChunks := max(1, numChunks/horChunks)
+	verChunks := max(1, min(numChunks/horChunks, dims.Dy()))
 	chunkWidth = dims.Dx() / horChunks
 	chunkHeight := dims.Dy() / verChunks
 	for startY := dims.Min.Y; startY < dims.Max.Y; startY += chunkHeight {
@@ -159,13 +149,14 @@ func RunSad(
 				Left:   left,
 				Right:  right,
 				Region: chunk,
+				Params: &params,
 			}
 		}
 		close(inputChan)
 	}()
 
 	// Assemble and return the disparity map
-	return AssembleDisparityMap(outputChan, left.Rect, len(chunks))
+	return Assemble(outputChan, left.Rect, len(chunks))
 }
 
 // AssembleDisparityMap assembles the disparity map from output chunks.
@@ -174,12 +165,25 @@ func AssembleDisparityMap(
 	dimensions image.Rectangle,
 	chunks int,
 ) *image.Gray {
-	disparityMap := image.NewGray(dimensions)
+	return Assemble(outputChan, dimensions, chunks).Disparity
+}
 
-	var i int
-	for chunk := range outputChan {
-		i++
-		if i >= chunks {
+// Assemble assembles the disparity map and its validity mask from output
+// chunks.
+func Assemble(
+	outputChan <-chan OutputChunk,
+	dimensions image.Rectangle,
+	chunks int,
+) Result {
+	result := Result{
+		Disparity: image.NewGray(dimensions),
+		Raw:       image.NewGray16(dimensions),
+		Valid:     image.NewGray(dimensions),
+	}
+
+	for range chunks {
+		chunk, ok := <-outputChan
+		if !ok {
 			break
 		}
 		width := chunk.Region.Dx()
@@ -187,21 +191,252 @@ func AssembleDisparityMap(
 			globalY := chunk.Region.Min.Y + y
 			for x := range width { // x := 0; x < width; x++
 				globalX := chunk.Region.Min.X + x
+				i := y*width + x
 
-				disparityValue := chunk.DisparityData[y*width+x]
-				disparityMap.SetGray(
+				result.Disparity.SetGray(
 					globalX,
 					globalY,
-					color.Gray{Y: disparityValue},
+					color.Gray{Y: chunk.DisparityData[i]},
 				)
+				if chunk.RawDisparity != nil {
+					result.Raw.SetGray16(
+						globalX,
+						globalY,
+						color.Gray16{Y: chunk.RawDisparity[i]},
+					)
+				}
+				if chunk.Valid == nil || chunk.Valid[i] {
+					result.Valid.SetGray(globalX, globalY, color.Gray{Y: 255})
+				}
 			}
 		}
 	}
 
-	return disparityMap
+	return result
+}
+
+// blockCost returns the matching cost between the left image block centred
+// on (leftX, leftY) and the right image block centred on (rightX, rightY).
+type blockCost func(leftX, leftY, rightX, rightY int) int
+
+// newBlockCost returns the block matching cost selected by params for the
+// chunk's image pair.
+func newBlockCost(chunk InputChunk, params Parameters) blockCost {
+	if params.Cost == CostCensus {
+		// Transform only the rows the chunk's blocks can reach
+		halfSize := params.BlockSize / 2
+		band := image.Rect(
+			chunk.Left.Rect.Min.X,
+			chunk.Region.Min.Y-halfSize,
+			chunk.Left.Rect.Max.X,
+			chunk.Region.Max.Y+halfSize+1,
+		)
+		left := CensusTransform(chunk.Left, band)
+		right := CensusTransform(chunk.Right, band)
+
+		return func(leftX, leftY, rightX, rightY int) int {
+			return SumHammingDistances(left, right, leftX, leftY, rightX, rightY, params.BlockSize)
+		}
+	}
+
+	// Block costs on a common row are computed with running sums, which
+	// yields the same costs in time independent of the block size.
+	if rs := newRunningSAD(chunk, params); rs != nil {
+		return rs.cost
+	}
+
+	return func(leftX, leftY, rightX, rightY int) int {
+		return SumAbsoluteDifferences(
+			chunk.Left,
+			chunk.Right,
+			leftX,
+			leftY,
+			rightX,
+			rightY,
+			params.BlockSize,
+		)
+	}
+}
+
+// matchBlock computes the disparity of every pixel in the chunk's region
+// by block matching and, when enabled, validates it with a left-right
+// consistency check.
+func matchBlock(chunk InputChunk, params Parameters) OutputChunk {
+	return matchBlockWith(chunk, params, newBlockCost(chunk, params))
+}
+
+// matchBlockWith is matchBlock using the given block matching cost.
+func matchBlockWith(chunk InputChunk, params Parameters, cost blockCost) OutputChunk {
+	width := chunk.Region.Dx()
+	data := make([]uint8, width*chunk.Region.Dy())
+	raw := make([]uint16, len(data))
+	valid := make([]bool, len(data))
+	bounds := chunk.Left.Rect
+
+	// Process each row in the region
+	for y := range chunk.Region.Dy() { // y := 0; y < height; y++
+		globalY := chunk.Region.Min.Y + y
+		for x := range width { // x := 0; x < width; x++
+			globalX := chunk.Region.Min.X + x
+			i := y*width + x
+
+			disparity, minCost := leftDisparity(cost, bounds, globalX, globalY, params)
+			if params.LRCheck {
+				// The matching right pixel must point back at this pixel
+				back := rightDisparity(cost, bounds, globalX-disparity, globalY, params)
+				if abs(disparity-back) > params.LRThreshold {
+					continue
+				}
+			}
+
+			offset := refineDisparity(
+				cost,
+				bounds,
+				globalX,
+				globalY,
+				disparity,
+				minCost,
+				params,
+			)
+
+			valid[i] = true
+			// Store the disparity value
+			raw[i], data[i] = encodeDisparity(disparity, offset, params)
+		}
+	}
+
+	return OutputChunk{
+		DisparityData: data,
+		RawDisparity:  raw,
+		Valid:         valid,
+		Region:        chunk.Region,
+	}
+}
+
+// leftDisparity returns the disparity that best matches the left image pixel
+// (x, y) against the right image along with its matching cost.
+func leftDisparity(cost blockCost, bounds image.Rectangle, x, y int, params Parameters) (int, int) {
+	minCost := math.MaxInt32
+	var bestDisparity int
+
+	for d := 0; d <= params.MaxDisparity; d++ {
+		// Skip if we would go beyond the left edge
+		if x-d < bounds.Min.X {
+			continue
+		}
+
+		c := cost(x, y, x-d, y)
+		if c < minCost {
+			minCost = c
+			bestDisparity = d
+
+			// Early termination for perfect matches
+			if c == 0 {
+				break
+			}
+		}
+	}
+
+	return bestDisparity, minCost
+}
+
+// rightDisparity returns the disparity that best matches the right image
+// pixel (x, y) against the left image, searching towards the right edge.
+func rightDisparity(cost blockCost, bounds image.Rectangle, x, y int, params Parameters) int {
+	minCost := math.MaxInt32
+	var bestDisparity int
+
+	for d := 0; d <= params.MaxDisparity; d++ {
+		// Stop once we would go beyond the right edge
+		if x+d >= bounds.Max.X {
+			break
+		}
+
+		c := cost(x+d, y, x, y)
+		if c < minCost {
+			minCost = c
+			bestDisparity = d
+
+			// Early termination for perfect matches
+			if c == 0 {
+				break
+			}
+		}
+	}
+
+	return bestDisparity
+}
+
+// refineDisparity returns the subpixel offset of the integer disparity of
+// the left image pixel (x, y), interpolated from the matching costs of its
+// two neighbouring disparities.
+func refineDisparity(
+	cost blockCost,
+	bounds image.Rectangle,
+	x, y, disparity, minCost int,
+	params Parameters,
+) float64 {
+	switch params.Subpixel {
+	case SubpixelParabolic, SubpixelEquiangular:
+	default:
+		return 0
+	}
+
+	// Both neighbours must lie inside the search range and the image
+	if disparity < 1 || disparity >= params.MaxDisparity ||
+		x-disparity-1 < bounds.Min.X {
+		return 0
+	}
+	prev := cost(x, y, x-disparity+1, y)
+	next := cost(x, y, x-disparity-1, y)
+
+	return subpixelOffset(params.Subpixel, prev, minCost, next)
+}
+
+// encodeDisparity returns the fixed-point and the normalized 8-bit
+// representation of a disparity refined by a subpixel offset.
+func encodeDisparity(disparity int, offset float64, params Parameters) (uint16, uint8) {
+	raw := uint16(math.Round((float64(disparity) + offset) * DisparityScale))
+
+	return raw, uint8(int(raw) * 255 / (params.MaxDisparity * DisparityScale))
+}
+
+// subpixelOffset returns the offset, in [-0.5, 0.5], of the interpolated
+// cost minimum from the integer disparity whose cost is cost, given the
+// costs of the previous and next disparities.
+func subpixelOffset(mode SubpixelMode, prev, cost, next int) float64 {
+	var offset float64
+	switch mode {
+	case SubpixelParabolic:
+		denom := prev - 2*cost + next
+		if denom <= 0 {
+			return 0
+		}
+		offset = float64(prev-next) / float64(2*denom)
+	case SubpixelEquiangular:
+		slope := max(prev-cost, next-cost)
+		if slope <= 0 {
+			return 0
+		}
+		offset = float64(prev-next) / float64(2*slope)
+	case SubpixelNone:
+		return 0
+	}
+
+	return max(-0.5, min(0.5, offset))
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+
+	return x
 }
 
 // SumAbsoluteDifferences calculates SAD directly on image data.
+//
+// Block matching computes the same costs with running sums, see runningSAD.
 func SumAbsoluteDifferences(
 	left, right *image.Gray,
 	leftX, leftY, rightX, rightY, blockSize int,
@@ -0,0 +1,221 @@
+package despair
+
+import (
+	"image"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestMatchBlockRecoversShift(t *testing.T) {
+	const disparity = 6
+	left, right := newStereoPair(t, 64, 32, disparity)
+	params := Parameters{BlockSize: 5, MaxDisparity: 16}
+
+	out := matchBlock(InputChunk{Left: left, Right: right, Region: left.Rect}, params)
+
+	want := uint8(disparity * 255 / params.MaxDisparity)
+	for y := params.BlockSize; y < left.Rect.Dy()-params.BlockSize; y++ {
+		for x := disparity + params.BlockSize; x < left.Rect.Dx()-params.BlockSize; x++ {
+			i := y*left.Rect.Dx() + x
+			if out.DisparityData[i] != want {
+				t.Fatalf("disparity at (%d,%d) = %d, want %d", x, y, out.DisparityData[i], want)
+			}
+			if !out.Valid[i] {
+				t.Fatalf("pixel (%d,%d) marked invalid without LR check", x, y)
+			}
+		}
+	}
+}
+
+func TestMatchBlockLRCheck(t *testing.T) {
+	const disparity = 8
+	left, right := newStereoPair(t, 64, 32, disparity)
+	params := Parameters{
+		BlockSize:    3,
+		MaxDisparity: 16,
+		LRCheck:      true,
+		LRThreshold:  0,
+	}
+
+	out := matchBlock(InputChunk{Left: left, Right: right, Region: left.Rect}, params)
+
+	var occluded, invalid int
+	for y := params.BlockSize; y < left.Rect.Dy()-params.BlockSize; y++ {
+		// Interior pixels have a unique match and must survive the check
+		for x := disparity + params.BlockSize; x < left.Rect.Dx()-params.BlockSize; x++ {
+			i := y*left.Rect.Dx() + x
+			if !out.Valid[i] {
+				t.Fatalf("consistent pixel (%d,%d) marked invalid", x, y)
+			}
+		}
+		// Pixels left of the shift have no counterpart in the right image
+		for x := params.BlockSize; x < disparity-1; x++ {
+			i := y*left.Rect.Dx() + x
+			occluded++
+			if !out.Valid[i] {
+				invalid++
+				if out.DisparityData[i] != 0 {
+					t.Fatalf("invalid pixel (%d,%d) has disparity %d", x, y, out.DisparityData[i])
+				}
+			}
+		}
+	}
+	if invalid*2 < occluded {
+		t.Errorf("only %d of %d occluded pixels were invalidated", invalid, occluded)
+	}
+}
+
+func TestAssembleAllChunks(t *testing.T) {
+	bounds := image.Rect(0, 0, 4, 4)
+	outputChan := make(chan OutputChunk, 4)
+	for y := range 4 {
+		region := image.Rect(0, y, 4, y+1)
+		outputChan <- OutputChunk{
+			DisparityData: []uint8{1, 2, 3, 4},
+			Valid:         []bool{true, true, false, true},
+			Region:        region,
+		}
+	}
+	close(outputChan)
+
+	result := Assemble(outputChan, bounds, 4)
+	for y := range 4 {
+		for x := range 4 {
+			if got := result.Disparity.GrayAt(x, y).Y; got != uint8(x+1) {
+				t.Errorf("disparity at (%d,%d) = %d, want %d", x, y, got, x+1)
+			}
+			want := uint8(255)
+			if x == 2 {
+				want = 0
+			}
+			if got := result.Valid.GrayAt(x, y).Y; got != want {
+				t.Errorf("mask at (%d,%d) = %d, want %d", x, y, got, want)
+			}
+		}
+	}
+}
+
+// newStereoPair returns a randomly textured stereo pair where every left
+// pixel at x >= disparity matches the right pixel at x-disparity. Columns
+// left of the shift hold unrelated noise, simulating an occlusion.
+func newStereoPair(t *testing.T, width, height, disparity int) (left, right *image.Gray) {
+	t.Helper()
+	rng := rand.New(rand.NewSource(1))
+	left = image.NewGray(image.Rect(0, 0, width, height))
+	right = image.NewGray(image.Rect(0, 0, width, height))
+	for i := range right.Pix {
+		right.Pix[i] = uint8(rng.Intn(256))
+	}
+	for y := range height {
+		for x := range width {
+			if x < disparity {
+				left.Pix[y*left.Stride+x] = uint8(rng.Intn(256))
+
+				continue
+			}
+			left.Pix[y*left.Stride+x] = right.Pix[y*right.Stride+x-disparity]
+		}
+	}
+
+	return left, right
+}
+
+func TestSubpixelOffset(t *testing.T) {
+	tests := []struct {
+		name             string
+		mode             SubpixelMode
+		prev, cost, next int
+		want             float64
+	}{
+		{"none", SubpixelNone, 10, 0, 20, 0},
+		{"parabolic symmetric", SubpixelParabolic, 10, 0, 10, 0},
+		{"parabolic towards next", SubpixelParabolic, 30, 10, 20, 1.0 / 6},
+		{"parabolic flat", SubpixelParabolic, 5, 5, 5, 0},
+		{"equiangular symmetric", SubpixelEquiangular, 10, 0, 10, 0},
+		{"equiangular towards next", SubpixelEquiangular, 30, 10, 20, 0.25},
+		{"equiangular towards prev", SubpixelEquiangular, 20, 10, 30, -0.25},
+		{"equiangular clamped", SubpixelEquiangular, 100, 10, 10, 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := subpixelOffset(tt.mode, tt.prev, tt.cost, tt.next)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("subpixelOffset() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchBlockSubpixel(t *testing.T) {
+	const width, height = 96, 32
+	// A smooth texture shifted by 4.5 pixels
+	rng := rand.New(rand.NewSource(2))
+	texture := make([]float64, width+8)
+	for i := range texture {
+		texture[i] = rng.Float64()
+	}
+	left := image.NewGray(image.Rect(0, 0, width, height))
+	right := image.NewGray(image.Rect(0, 0, width, height))
+	sample := func(x float64) uint8 {
+		// Cosine interpolated random texture
+		i := int(math.Floor(x / 4))
+		f := (1 - math.Cos((x/4-float64(i))*math.Pi)) / 2
+
+		return uint8(255 * (texture[i]*(1-f) + texture[i+1]*f))
+	}
+	for y := range height {
+		for x := range width {
+			right.Pix[y*right.Stride+x] = sample(float64(x) + 8)
+			left.Pix[y*left.Stride+x] = sample(float64(x) + 8 - 4.5)
+		}
+	}
+
+	for _, mode := range []SubpixelMode{SubpixelParabolic, SubpixelEquiangular} {
+		t.Run(string(mode), func(t *testing.T) {
+			params := Parameters{BlockSize: 7, MaxDisparity: 16, Subpixel: mode}
+			out := matchBlock(InputChunk{Left: left, Right: right, Region: left.Rect}, params)
+
+			var sum float64
+			var n int
+			for y := params.BlockSize; y < height-params.BlockSize; y++ {
+				for x := 16 + params.BlockSize; x < width-params.BlockSize; x++ {
+					sum += float64(out.RawDisparity[y*width+x]) / DisparityScale
+					n++
+				}
+			}
+			if mean := sum / float64(n); math.Abs(mean-4.5) > 0.2 {
+				t.Errorf("mean subpixel disparity = %.3f, want 4.5", mean)
+			}
+		})
+	}
+}
+
+func TestChunkParamsOverrideDefaults(t *testing.T) {
+	const disparity = 6
+	left, right := newStereoPair(t, 64, 32, disparity)
+	params := Parameters{BlockSize: 5, MaxDisparity: 16}
+
+	inputChan, outputChan := SetupConcurrentSAD(2)
+	inputChan <- InputChunk{Left: left, Right: right, Region: left.Rect, Params: &params}
+	close(inputChan)
+	out := <-outputChan
+
+	// The default max disparity of 64 would normalize to a different value
+	want := uint8(disparity * 255 / params.MaxDisparity)
+	if got := out.DisparityData[16*left.Rect.Dx()+32]; got != want {
+		t.Errorf("disparity = %d, want %d computed with the chunk's parameters", got, want)
+	}
+}
+
+func TestRunLeavesDefaultParams(t *testing.T) {
+	before := *DefaultParams()
+	left, right := newStereoPair(t, 64, 32, 4)
+
+	Run(left, right, Parameters{BlockSize: 3, MaxDisparity: 16})
+	RunSad(left, right, 5, 32)
+
+	if after := *DefaultParams(); after != before {
+		t.Errorf("default parameters changed from %+v to %+v", before, after)
+	}
+}
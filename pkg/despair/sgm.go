@@ -0,0 +1,227 @@
+package despair
+
+import (
+	"math"
+)
+
+// sgmDirections lists the aggregation path directions of semi-global
+// matching. The first four are the horizontal and vertical paths, the last
+// four the diagonals used by the 8 path variant.
+var sgmDirections = [8]struct{ dx, dy int }{
+	{1, 0}, {-1, 0}, {0, 1}, {0, -1},
+	{1, 1}, {-1, 1}, {1, -1}, {-1, -1},
+}
+
+// sgmPenalties returns the P1 and P2 penalties to use for params, scaling
+// unset penalties to the block area and matching cost.
+func sgmPenalties(params Parameters) (int32, int32) {
+	area := params.BlockSize * params.BlockSize
+	p1, p2 := 8*area, 32*area
+	if params.Cost == CostCensus {
+		p1, p2 = 2*area, 16*area
+	}
+	if params.P1 > 0 {
+		p1 = params.P1
+	}
+	if params.P2 > 0 {
+		p2 = params.P2
+	}
+
+	return int32(p1), int32(max(p1, p2))
+}
+
+// matchSGM computes the disparity of every pixel in the chunk's region by
+// semi-global matching.
+//
+// Matching costs are computed with the block cost selected by params and
+// aggregated along 4 or 8 paths that are confined to the chunk's region, so
+// the result only depends on the chunk and its parameters.
+func matchSGM(chunk InputChunk, params Parameters) OutputChunk {
+	width, height := chunk.Region.Dx(), chunk.Region.Dy()
+	numDisparities := params.MaxDisparity + 1
+	bounds := chunk.Left.Rect
+	cost := newBlockCost(chunk, params)
+
+	// Pixel-wise matching cost volume, indexed by (y*width+x)*numDisparities+d
+	costs := make([]int32, width*height*numDisparities)
+	for y := range height {
+		globalY := chunk.Region.Min.Y + y
+		for x := range width {
+			globalX := chunk.Region.Min.X + x
+			pixel := costs[(y*width+x)*numDisparities:][:numDisparities]
+			for d := range numDisparities {
+				if globalX-d < bounds.Min.X {
+					// Beyond the left edge, repeat the last valid cost
+					pixel[d] = pixel[d-1]
+
+					continue
+				}
+				pixel[d] = int32(cost(globalX, globalY, globalX-d, globalY))
+			}
+		}
+	}
+
+	aggregated := aggregateSGM(costs, width, height, numDisparities, params)
+
+	data := make([]uint8, width*height)
+	raw := make([]uint16, len(data))
+	valid := make([]bool, len(data))
+	for y := range height {
+		for x := range width {
+			i := y*width + x
+			pixel := aggregated[i*numDisparities:][:numDisparities]
+			disparity := argmin(pixel)
+
+			if params.LRCheck {
+				// The matching right pixel must point back at this pixel.
+				// Right pixels left of the chunk have no aggregated costs
+				// to check against, so their matches fail the check.
+				if x-disparity < 0 {
+					continue
+				}
+				back := sgmRightDisparity(aggregated, width, numDisparities, x-disparity, y)
+				if abs(disparity-back) > params.LRThreshold {
+					continue
+				}
+			}
+
+			var offset float64
+			if disparity > 0 && disparity < params.MaxDisparity &&
+				chunk.Region.Min.X+x-disparity-1 >= bounds.Min.X {
+				offset = subpixelOffset(
+					params.Subpixel,
+					int(pixel[disparity-1]),
+					int(pixel[disparity]),
+					int(pixel[disparity+1]),
+				)
+			}
+
+			valid[i] = true
+			raw[i], data[i] = encodeDisparity(disparity, offset, params)
+		}
+	}
+
+	return OutputChunk{
+		DisparityData: data,
+		RawDisparity:  raw,
+		Valid:         valid,
+		Region:        chunk.Region,
+	}
+}
+
+// aggregateSGM sums the matching costs aggregated along each of the
+// configured paths and returns the resulting cost volume.
+func aggregateSGM(
+	costs []int32,
+	width, height, numDisparities int,
+	params Parameters,
+) []int32 {
+	p1, p2 := sgmPenalties(params)
+	paths := 4
+	if params.Paths >= 8 {
+		paths = 8
+	}
+
+	aggregated := make([]int32, len(costs))
+	// Path costs of the previous and current row
+	prevRow := make([]int32, width*numDisparities)
+	currRow := make([]int32, width*numDisparities)
+
+	for _, dir := range sgmDirections[:paths] {
+		// Walk rows against the path's vertical direction so the previous
+		// pixel on the path is always already aggregated.
+		startY, stepY := 0, 1
+		if dir.dy < 0 {
+			startY, stepY = height-1, -1
+		}
+		startX, stepX := 0, 1
+		if dir.dx < 0 {
+			startX, stepX = width-1, -1
+		}
+
+		for row := range height {
+			y := startY + row*stepY
+			for col := range width {
+				x := startX + col*stepX
+				pixelCost := costs[(y*width+x)*numDisparities:][:numDisparities]
+				current := currRow[x*numDisparities:][:numDisparities]
+
+				px, py := x-dir.dx, y-dir.dy
+				if px < 0 || px >= width || py < 0 || py >= height {
+					// The path starts at this pixel
+					copy(current, pixelCost)
+				} else {
+					var previous []int32
+					if dir.dy == 0 {
+						previous = currRow[px*numDisparities:][:numDisparities]
+					} else {
+						previous = prevRow[px*numDisparities:][:numDisparities]
+					}
+					aggregatePixel(current, previous, pixelCost, p1, p2)
+				}
+
+				total := aggregated[(y*width+x)*numDisparities:][:numDisparities]
+				for d, c := range current {
+					total[d] += c
+				}
+			}
+			prevRow, currRow = currRow, prevRow
+		}
+	}
+
+	return aggregated
+}
+
+// aggregatePixel computes the path cost of a pixel from the path cost of
+// the previous pixel on the path.
+func aggregatePixel(current, previous, pixelCost []int32, p1, p2 int32) {
+	minPrevious := previous[0]
+	for _, c := range previous[1:] {
+		minPrevious = min(minPrevious, c)
+	}
+
+	last := len(previous) - 1
+	for d := range current {
+		best := min(previous[d], minPrevious+p2)
+		if d > 0 {
+			best = min(best, previous[d-1]+p1)
+		}
+		if d < last {
+			best = min(best, previous[d+1]+p1)
+		}
+		current[d] = pixelCost[d] + best - minPrevious
+	}
+}
+
+// sgmRightDisparity returns the disparity that best matches the right image
+// pixel at column x of row y, using the aggregated costs of the left pixels
+// it can correspond to.
+func sgmRightDisparity(aggregated []int32, width, numDisparities, x, y int) int {
+	minCost := int32(math.MaxInt32)
+	var bestDisparity int
+	for d := range numDisparities {
+		if x+d >= width {
+			break
+		}
+		c := aggregated[(y*width+x+d)*numDisparities+d]
+		if c < minCost {
+			minCost = c
+			bestDisparity = d
+		}
+	}
+
+	return bestDisparity
+}
+
+// argmin returns the index of the smallest cost, preferring the smallest
+// index on ties.
+func argmin(costs []int32) int {
+	var best int
+	for d, c := range costs {
+		if c < costs[best] {
+			best = d
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,141 @@
+package despair
+
+import (
+	"bytes"
+	"flag"
+	"image"
+	"image/draw"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestMatchSGMRecoversShift(t *testing.T) {
+	const disparity = 7
+	left, right := newStereoPair(t, 64, 32, disparity)
+
+	for _, paths := range []int{4, 8} {
+		params := Parameters{
+			BlockSize:    3,
+			MaxDisparity: 16,
+			Algorithm:    AlgorithmSGM,
+			Paths:        paths,
+		}
+		out := matchSGM(InputChunk{Left: left, Right: right, Region: left.Rect}, params)
+
+		want := uint8(disparity * 255 / params.MaxDisparity)
+		for y := params.BlockSize; y < left.Rect.Dy()-params.BlockSize; y++ {
+			for x := disparity + params.BlockSize; x < left.Rect.Dx()-params.BlockSize; x++ {
+				if got := out.DisparityData[y*left.Rect.Dx()+x]; got != want {
+					t.Fatalf("%d paths: disparity at (%d,%d) = %d, want %d", paths, x, y, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestAggregatePixel(t *testing.T) {
+	previous := []int32{10, 4, 9, 30}
+	pixelCost := []int32{1, 2, 3, 4}
+	current := make([]int32, len(previous))
+
+	aggregatePixel(current, previous, pixelCost, 2, 8)
+
+	// min(prev[d], prev[d±1]+P1, min(prev)+P2) - min(prev), plus the cost
+	want := []int32{1 + 6 - 4, 2 + 4 - 4, 3 + 6 - 4, 4 + 11 - 4}
+	for d := range want {
+		if current[d] != want[d] {
+			t.Errorf("current[%d] = %d, want %d", d, current[d], want[d])
+		}
+	}
+}
+
+// TestMatchSGMRegression runs semi-global matching on a crop of the
+// testdata pair and compares the result with a golden disparity map.
+func TestMatchSGMRegression(t *testing.T) {
+	crop := image.Rect(1200, 760, 1560, 808)
+	left := cropGray(MustLoadPNG(filepath.Join("..", "..", "testdata", "im0.png")), crop)
+	right := cropGray(MustLoadPNG(filepath.Join("..", "..", "testdata", "im1.png")), crop)
+	params := Parameters{
+		BlockSize:    5,
+		MaxDisparity: 128,
+		Algorithm:    AlgorithmSGM,
+		Paths:        8,
+	}
+
+	// Process horizontal strips the way the output camera does
+	outputChan := make(chan OutputChunk, left.Rect.Dy())
+	var chunks int
+	for y := 0; y < left.Rect.Dy(); y += 16 {
+		outputChan <- match(InputChunk{
+			Left:   left,
+			Right:  right,
+			Region: image.Rect(0, y, left.Rect.Dx(), min(y+16, left.Rect.Dy())),
+		}, params)
+		chunks++
+	}
+	got := Assemble(outputChan, left.Rect, chunks).Disparity
+
+	golden := filepath.Join("testdata", "sgm_im0.png")
+	if *update {
+		MustSavePNG(golden, got)
+	}
+	want, err := LoadPNG(golden)
+	if err != nil {
+		t.Fatalf("failed to load golden file (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(got.Pix, want.Pix) {
+		t.Errorf("disparity map differs from %s", golden)
+	}
+}
+
+// TestMatchSGMLRCheck checks the left-right consistency of semi-global
+// matching on chunks that do not start at the left edge of the image,
+// whose right pixels may lie left of the chunk.
+func TestMatchSGMLRCheck(t *testing.T) {
+	const disparity = 7
+	left, right := newStereoPair(t, 96, 32, disparity)
+	params := Parameters{
+		BlockSize:    3,
+		MaxDisparity: 16,
+		LRCheck:      true,
+		Algorithm:    AlgorithmSGM,
+		Paths:        8,
+	}
+	region := image.Rect(32, 0, 96, 32)
+	out := matchSGM(InputChunk{Left: left, Right: right, Region: region}, params)
+
+	width := region.Dx()
+	for y := params.BlockSize; y < region.Dy()-params.BlockSize; y++ {
+		for x := range width - params.BlockSize {
+			i := y*width + x
+			if x < disparity {
+				// The right pixel lies left of the chunk
+				if out.Valid[i] {
+					t.Fatalf("pixel (%d,%d) without a right pixel in the chunk marked valid", x, y)
+				}
+
+				continue
+			}
+			if !out.Valid[i] {
+				t.Fatalf("consistent pixel (%d,%d) marked invalid", x, y)
+			}
+		}
+	}
+
+	// The pipeline splits the image into chunks of all positions
+	crop := image.Rect(1200, 760, 1456, 824)
+	leftImg := cropGray(MustLoadPNG(filepath.Join("..", "..", "testdata", "im0.png")), crop)
+	rightImg := cropGray(MustLoadPNG(filepath.Join("..", "..", "testdata", "im1.png")), crop)
+	params.MaxDisparity = 128
+	Run(leftImg, rightImg, params)
+}
+
+// cropGray copies a region of img into a new image anchored at the origin.
+func cropGray(img *image.Gray, rect image.Rectangle) *image.Gray {
+	out := image.NewGray(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(out, out.Rect, img, rect.Min, draw.Src)
+
+	return out
+}
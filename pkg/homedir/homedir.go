@@ -180,7 +180,7 @@ var deleteOnce sync.Once
 // schema: stero-image-<timestamp>.png
 func SaveImage(
 	name string,
-	img *image.Gray,
+	img image.Image,
 ) error {
 	dir, err := Dir()
 	if err != nil {
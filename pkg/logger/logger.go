@@ -15,6 +15,7 @@ import (
 type Logger struct {
 	*slog.Logger
 	buffer *bytes.Buffer
+	hub    *hub
 }
 
 // Bytes returns the buffered log.
@@ -22,19 +23,29 @@ func (l Logger) Bytes() []byte {
 	return l.buffer.Bytes()
 }
 
+// Subscribe returns a channel the log entries are sent on as they are
+// logged, and a function that ends the subscription. At most size entries
+// are queued; entries logged while the queue is full are not sent.
+func (l Logger) Subscribe(size int) (<-chan LogEntry, func()) {
+	return l.hub.subscribe(size)
+}
+
 // NewLogger creates a new Logger.
 func NewLogger() Logger {
 	var buffer bytes.Buffer
+	entries := newHub()
 	logger := slog.New(
 		slogmulti.Fanout(
 			NewLogWriter(&buffer),
 			NewLogWriter(os.Stdout),
+			&entryHandler{hub: entries},
 		),
 	)
 	slog.SetDefault(logger)
 
 	return Logger{
 		buffer: &buffer,
+		hub:    entries,
 		Logger: logger,
 	}
 }
@@ -0,0 +1,102 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// hub fans log entries out to subscribers.
+type hub struct {
+	mu   sync.RWMutex
+	subs map[chan LogEntry]struct{}
+}
+
+func newHub() *hub {
+	return &hub{subs: map[chan LogEntry]struct{}{}}
+}
+
+// subscribe registers a subscriber with a queue of size entries.
+func (h *hub) subscribe(size int) (<-chan LogEntry, func()) {
+	ch := make(chan LogEntry, max(1, size))
+	h.mu.Lock()
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+	var once sync.Once
+
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			delete(h.subs, ch)
+			close(ch)
+		})
+	}
+}
+
+// publish delivers an entry to every subscriber whose queue has room.
+// Logging never waits for a subscriber.
+func (h *hub) publish(entry LogEntry) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for ch := range h.subs {
+		select {
+		case ch <- entry:
+		default:
+		}
+	}
+}
+
+// entryHandler is a slog.Handler that publishes records as log entries.
+type entryHandler struct {
+	hub    *hub
+	attrs  []slog.Attr
+	prefix string
+}
+
+// Enabled implements slog.Handler.
+func (h *entryHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= slog.LevelDebug
+}
+
+// Handle implements slog.Handler.
+func (h *entryHandler) Handle(_ context.Context, r slog.Record) error {
+	attrs := make([]slog.Attr, len(h.attrs), len(h.attrs)+r.NumAttrs())
+	copy(attrs, h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		a.Key = h.prefix + a.Key
+		attrs = append(attrs, a)
+
+		return true
+	})
+	h.hub.publish(LogEntry{
+		Level:   r.Level,
+		Time:    r.Time,
+		Message: r.Message,
+		Attrs:   attrs,
+	})
+
+	return nil
+}
+
+// WithAttrs implements slog.Handler.
+func (h *entryHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := *h
+	next.attrs = append(next.attrs[:len(next.attrs):len(next.attrs)], attrs...)
+	for i := len(h.attrs); i < len(next.attrs); i++ {
+		next.attrs[i].Key = h.prefix + next.attrs[i].Key
+	}
+
+	return &next
+}
+
+// WithGroup implements slog.Handler.
+func (h *entryHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	next := *h
+	next.prefix = h.prefix + name + "."
+
+	return &next
+}
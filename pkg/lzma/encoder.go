@@ -733,12 +733,8 @@ DoWhile1:
 		if newLen > availableBytes {
 			newLen = availableBytes
 			distancePairs = 0
-			for {
-				if newLen > z.matchDistances[distancePairs] {
-					distancePairs += 2
-				}
-
-				break
+			for newLen > z.matchDistances[distancePairs] {
+				distancePairs += 2
 			}
 			z.matchDistances[distancePairs] = newLen
 			distancePairs += 2
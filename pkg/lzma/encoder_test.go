@@ -24,6 +24,12 @@ var dataTxt []byte
 //go:embed testdata/data.eos.l3.lzma
 var dataEosL3Lzma []byte
 
+// matchAtEnd is 16 bit image samples whose last match runs past the end of
+// the data.
+//
+//go:embed testdata/match-at-end.bin
+var matchAtEnd []byte
+
 var bench = lzmaBenchmark{
 	descr: "text bench with size == -1",
 	level: 3,
@@ -431,6 +437,60 @@ func TestEncoder(t *testing.T) {
 	}
 }
 
+// Matches longer than the fast bytes of the level are skipped over whole.
+func TestEncoderLongMatch(t *testing.T) {
+	for _, n := range []int{127, 128, 129, 300, 1000} {
+		raw := make([]byte, n+1)
+		raw[n] = 2
+		for _, level := range []int{lzma.BestSpeed, lzma.DefaultCompression, lzma.BestCompression} {
+			var b bytes.Buffer
+			w, err := lzma.NewWriterSizeLevel(&b, int64(len(raw)), level)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = w.Write(raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = w.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := io.ReadAll(lzma.NewReader(&b))
+			if err != nil {
+				t.Fatalf("%d zeros, level %d: %v", n, level, err)
+			}
+			if !bytes.Equal(got, raw) {
+				t.Errorf("%d zeros, level %d: decompressed %d bytes differ", n, level, len(got))
+			}
+		}
+	}
+}
+
+// Matches running past the end of the data are cut to the bytes left.
+func TestEncoderMatchAtEnd(t *testing.T) {
+	var b bytes.Buffer
+	w, err := lzma.NewWriterSize(&b, int64(len(matchAtEnd)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = w.Write(matchAtEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(lzma.NewReader(&b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, matchAtEnd) {
+		t.Error("decompressed bytes differ")
+	}
+}
+
 func BenchmarkEncoder(b *testing.B) {
 	buf := new(bytes.Buffer)
 	start := make(chan bool)
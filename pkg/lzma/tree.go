@@ -108,11 +108,11 @@ func (bt *binTree) normalize() {
 			bt.son[i] -= subValue
 		}
 	}
-	for i := range bt.son {
-		if bt.son[i] <= subValue {
-			bt.son[i] = kEmptyHashValue
+	for i := range bt.hash {
+		if bt.hash[i] <= subValue {
+			bt.hash[i] = kEmptyHashValue
 		} else {
-			bt.son[i] -= subValue
+			bt.hash[i] -= subValue
 		}
 	}
 	bt.iw.reduceOffsets(subValue)
@@ -368,7 +368,10 @@ func (bt *binTree) skip(num uint32) error {
 			}
 		}
 
-		return bt.movePos()
+		err := bt.movePos()
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -0,0 +1,25 @@
+// Package rangecode implements the range coder of the capture firmware,
+// image_capture/encoding/range_code.c, so frames the firmware compresses
+// can be decoded on the host.
+//
+// The coder models the bytes of a frame with their histogram, which it
+// reshapes every 128 bytes to favour the neighbourhood of the current
+// byte, and shifts a byte of the range off whenever the low and high ends
+// agree on it. A range that straddles a byte boundary and falls below the
+// adjustment threshold is moved down so that it no longer does; encoder
+// and decoder must use the same threshold.
+//
+// Encode reproduces range_code byte for byte, including its failures:
+// the model can reduce the count of a byte to zero, or make the range of a
+// rare byte empty, after which range_code gives up and returns 0. It does
+// so on the firmware's own test image, image.bin, at every threshold.
+//
+// range_code writes neither the model nor the last bytes of the range, so
+// a Code carries both: the histogram the model starts from and the four
+// bytes of the final low end that range_code computes but never writes.
+// Code.AppendBinary encodes all of it as a frame payload for Decode.
+//
+// The package is experimental. The firmware's coder loses information when
+// it moves a range, so Decode has to search for the coded bytes and does
+// not decode every code.
+package rangecode
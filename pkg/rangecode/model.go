@@ -0,0 +1,104 @@
+package rangecode
+
+// Symbols is the number of symbols of the model, one per byte value.
+const Symbols = 256
+
+const (
+	// symbolBits is the size of a symbol.
+	symbolBits = 8
+	// topShift is the shift of the most significant symbol of the 32 bit
+	// window of the range.
+	topShift = 32 - symbolBits
+	// topMask isolates the most significant symbol of the window.
+	topMask uint64 = (Symbols - 1) << topShift
+	// adjustInterval is the number of bytes between reshapes of the model.
+	adjustInterval = 128
+	// adjustPercent is the percentage of the frame size moved towards the
+	// neighbourhood of a byte at each reshape.
+	adjustPercent = 10
+	// neighbourhood is the distance of the byte values favoured by a
+	// reshape.
+	neighbourhood = 8
+)
+
+// model is the adaptive model of range_code: the counts of the symbols and
+// their cumulative sums, out of the frame size.
+type model struct {
+	count [Symbols]uint64
+	prev  [Symbols]uint64
+	size  uint64
+}
+
+// newModel creates a model starting from a histogram.
+func newModel(counts [Symbols]uint32) *model {
+	m := &model{}
+	for s, c := range counts {
+		m.count[s] = uint64(c)
+		m.size += uint64(c)
+	}
+	m.computePrev()
+
+	return m
+}
+
+// Histogram counts the bytes of src, the counts the model of Encode starts
+// from.
+func Histogram(src []byte) [Symbols]uint32 {
+	var counts [Symbols]uint32
+	for _, b := range src {
+		counts[b]++
+	}
+
+	return counts
+}
+
+// computePrev computes the cumulative counts, compute_previous_counts.
+func (m *model) computePrev() {
+	for s := 1; s < Symbols; s++ {
+		m.prev[s] = m.prev[s-1] + m.count[s-1]
+	}
+}
+
+// adjust moves percent of the frame size from the counts of the symbols
+// far from b to those within neighbourhood of it, adjust_probabilities.
+// Counts below a hundredth of a percent of the frame size are left alone
+// and the others are not reduced below it.
+func (m *model) adjust(b byte, percent uint64) {
+	sym := int(b)
+	left := min(sym, neighbourhood)
+	right := neighbourhood
+	if sym+neighbourhood > Symbols-1 {
+		right = Symbols - 1 - sym
+	}
+	near := uint64(left + right)
+
+	percentCount := ((m.size / 100) * percent) / (Symbols - near)
+	minCount := m.size / 10000
+
+	var reduction uint64
+	for s := range Symbols { // s := 0; s < Symbols; s++
+		if s >= sym-neighbourhood && s <= sym+neighbourhood {
+			continue
+		}
+		c := m.count[s]
+		switch {
+		case c < minCount:
+		case c < percentCount:
+			m.count[s] = minCount
+			reduction += c - minCount
+		default:
+			m.count[s] -= percentCount
+			reduction += percentCount
+		}
+	}
+
+	increase, extra := reduction/near, reduction%near
+	for s := max(sym-neighbourhood, 0); s <= sym+neighbourhood && s < Symbols; s++ {
+		if s == sym {
+			m.count[s] += extra
+		} else {
+			m.count[s] += increase
+		}
+	}
+	m.computePrev()
+}
@@ -0,0 +1,348 @@
+package rangecode
+
+import (
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// DefaultThreshold is the adjustment threshold of the firmware's demo.
+const DefaultThreshold = 32768
+
+// tailSize is the number of bytes of the final range Code.Tail holds.
+const tailSize = 4
+
+// headerSize is the size of the threshold and histogram of an encoded Code.
+const headerSize = 4 + 4*Symbols
+
+// Code is the range coding of a frame.
+type Code struct {
+	// Threshold is the adjustment threshold the frame was coded with.
+	Threshold int
+	// Counts is the histogram of the frame the model starts from.
+	Counts [Symbols]uint32
+	// Bytes are the bytes range_code writes to its coded buffer.
+	Bytes []byte
+	// Tail is the 32 bit window of the final low end of the range, most
+	// significant byte first, which range_code never writes.
+	Tail [tailSize]byte
+	// Result is what range_code returns: the number of bytes written after
+	// the leading zero bytes, plus one when the most significant bits of
+	// the ends of the final range agree.
+	Result int
+}
+
+// CollapseError is returned when the range of a byte becomes empty, where
+// range_code returns 0.
+type CollapseError struct {
+	// Index is the index of the byte whose range collapsed.
+	Index int
+	// Written is the number of bytes written before the range collapsed.
+	Written int
+}
+
+// Error implements the error interface.
+func (e *CollapseError) Error() string {
+	return fmt.Sprintf("rangecode: range collapsed at byte %d after writing %d bytes", e.Index, e.Written)
+}
+
+// coder is the range and model shared by the encoder and decoder.
+type coder struct {
+	m         *model
+	low, high uint64
+	threshold uint64
+}
+
+func newCoder(counts [Symbols]uint32, threshold int) *coder {
+	return &coder{
+		m:         newModel(counts),
+		high:      1<<32 - 1,
+		threshold: uint64(threshold),
+	}
+}
+
+// narrow narrows the range to a symbol.
+func (c *coder) narrow(sym byte) {
+	size := c.high - c.low + 1
+	c.low += c.m.prev[sym] * size / c.m.size
+	c.high = c.m.count[sym]*size/c.m.size + c.low
+}
+
+// normalize shifts the symbols the ends of the range agree on off the
+// range, passing each to shift, and moves a range too small to tell its
+// ends apart below the byte boundary it straddles. It reports whether the
+// range is still usable.
+func (c *coder) normalize(shift func(sym byte)) bool {
+	for {
+		lowTop, highTop := c.low&topMask, c.high&topMask
+		small := c.high-c.low+1 < c.threshold
+		switch {
+		case lowTop == highTop:
+			shift(byte(lowTop >> topShift))
+			c.low = (c.low &^ topMask) << symbolBits
+			c.high = (c.high &^ topMask) << symbolBits
+		case small:
+			high := lowTop + 1<<topShift - 1
+			delta := c.high - high
+			if delta > c.low {
+				// The range would slide below zero
+				return false
+			}
+			c.low -= delta
+			c.high = high
+		default:
+			return true
+		}
+		if c.high == c.low {
+			return false
+		}
+	}
+}
+
+// update reshapes the model after the byte at index i.
+func (c *coder) update(i int, b byte) {
+	if i%adjustInterval == 0 {
+		c.m.adjust(b, adjustPercent)
+	}
+}
+
+// Encode range codes src with an adjustment threshold as range_code does.
+//
+// When the range collapses it returns a *CollapseError along with a Code
+// holding the bytes written until then.
+func Encode(src []byte, threshold int) (*Code, error) {
+	if threshold <= 0 {
+		return nil, fmt.Errorf("rangecode: invalid adjustment threshold %d", threshold)
+	}
+	code := &Code{Threshold: threshold, Counts: Histogram(src)}
+	if len(src) == 0 {
+		return code, nil
+	}
+	c := newCoder(code.Counts, threshold)
+	leadingZeros := true
+	shift := func(sym byte) {
+		code.Bytes = append(code.Bytes, sym)
+		leadingZeros = leadingZeros && sym == 0
+		if !leadingZeros {
+			code.Result++
+		}
+	}
+
+	for i, b := range src {
+		c.narrow(b)
+		if !c.normalize(shift) {
+			code.Result = 0
+
+			return code, &CollapseError{Index: i, Written: len(code.Bytes)}
+		}
+		c.update(i, b)
+	}
+
+	binary.BigEndian.PutUint32(code.Tail[:], uint32(c.low))
+	if (c.low>>31)&1 == (c.high>>31)&1 {
+		code.Result++
+	}
+
+	return code, nil
+}
+
+// AppendBinary appends the encoding of the code to buf: the threshold and
+// the histogram as little-endian uint32s, then the bytes and the tail.
+func (c *Code) AppendBinary(buf []byte) ([]byte, error) {
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(c.Threshold))
+	for _, count := range c.Counts {
+		buf = binary.LittleEndian.AppendUint32(buf, count)
+	}
+	buf = append(buf, c.Bytes...)
+
+	return append(buf, c.Tail[:]...), nil
+}
+
+const (
+	// maxBacktracks bounds the search of Decode.
+	maxBacktracks = 1 << 16
+	// maxBranches bounds the memory of the search of Decode, a little over
+	// a kilobyte per branch.
+	maxBranches = 1 << 14
+)
+
+// Decode decodes an encoded Code into dst, which must be as long as the
+// coded frame.
+//
+// Moving a range below a byte boundary moves it out of the part of the
+// range of its symbol, so the code value does not always tell the coded
+// symbol apart from its neighbours. Decode tries the symbols whose parts
+// are close enough to the value, most likely first, and backtracks when
+// the symbols shifted off a range do not match the code, a byte value is
+// decoded more often than the histogram allows or the final range does
+// not match the tail. The larger the threshold the more ranges are moved;
+// from 1<<24 on nearly every range is, and Decode gives up on most frames.
+// It also gives up when too many decoded bytes are left to try again.
+func Decode(dst, data []byte) error {
+	if len(data) < headerSize+tailSize {
+		return errors.New("rangecode: code too short")
+	}
+	threshold := int(binary.LittleEndian.Uint32(data))
+	if threshold <= 0 {
+		return fmt.Errorf("rangecode: invalid adjustment threshold %d", threshold)
+	}
+	var counts [Symbols]uint32
+	for s := range counts {
+		counts[s] = binary.LittleEndian.Uint32(data[4+4*s:])
+	}
+	c := newCoder(counts, threshold)
+	if c.m.size != uint64(len(dst)) {
+		return fmt.Errorf("rangecode: code of %d bytes, want %d", c.m.size, len(dst))
+	}
+
+	d := decoder{coder: *c, in: data[headerSize:], pos: tailSize, left: counts}
+	for _, b := range d.in[:tailSize] {
+		d.value = d.value<<symbolBits | uint64(b)
+	}
+
+	// branch is a decoding state with the symbols left to try in it.
+	type branch struct {
+		state decoder
+		i     int
+		alts  []byte
+	}
+	var stack []branch
+	backtracks := 0
+	for i := 0; ; {
+		var cands []byte
+		if i < len(dst) {
+			cands = d.candidates()
+		} else if d.pos == len(d.in) && uint32(d.low) == uint32(d.value) {
+			return nil
+		}
+		if len(cands) == 0 {
+			if len(stack) == 0 || backtracks == maxBacktracks {
+				return fmt.Errorf("rangecode: invalid code at byte %d", i)
+			}
+			backtracks++
+			b := &stack[len(stack)-1]
+			d, i = b.state, b.i
+			cands = b.alts
+			if len(cands) == 1 {
+				stack = stack[:len(stack)-1]
+			} else {
+				b.alts = cands[1:]
+			}
+			cands = cands[:1]
+		} else if len(cands) > 1 {
+			if len(stack) == maxBranches {
+				return fmt.Errorf("rangecode: too many ambiguous bytes at byte %d", i)
+			}
+			stack = append(stack, branch{state: d, i: i, alts: cands[1:]})
+		}
+
+		sym := cands[0]
+		if !d.step(sym) {
+			// candidates only returns symbols the code matches
+			return fmt.Errorf("rangecode: code does not match candidate %d at byte %d", sym, i)
+		}
+		d.left[sym]--
+		dst[i] = sym
+		d.update(i, sym)
+		i++
+	}
+}
+
+// decoder is the state of Decode: the coder, the code and the window of
+// the code value at the coder's range.
+type decoder struct {
+	coder
+	in    []byte
+	pos   int
+	value uint64
+	// left counts the bytes of each value not decoded yet.
+	left [Symbols]uint32
+}
+
+// update reshapes the model after the byte at index i. The model is
+// replaced rather than changed, as the branches of Decode share it.
+func (d *decoder) update(i int, b byte) {
+	if i%adjustInterval == 0 {
+		m := *d.m
+		m.adjust(b, adjustPercent)
+		d.m = &m
+	}
+}
+
+// step narrows and normalizes the range to a symbol, shifting the code
+// value along. It reports whether the symbols shifted off match the code
+// and the code is long enough.
+func (d *decoder) step(sym byte) bool {
+	ok := true
+	usable := d.narrowNormalize(sym, func(top byte) {
+		if top != byte(d.value>>topShift) || d.pos == len(d.in) {
+			ok = false
+
+			return
+		}
+		d.value = (d.value&^topMask)<<symbolBits | uint64(d.in[d.pos])
+		d.pos++
+	})
+
+	return usable && ok
+}
+
+// candidates returns the symbols that may have been coded in the current
+// range, most likely first: those whose part of the range ends at or above
+// the code value and starts at most twice the adjustment threshold above
+// it, that the code matches after normalizing and that leave the value in
+// the range or at most twice the threshold below it.
+func (d *decoder) candidates() []byte {
+	size := d.high - d.low + 1
+	offset := int64(d.value - d.low)
+	slack := 2 * int64(d.threshold)
+
+	type candidate struct {
+		sym  byte
+		dist int64
+	}
+	// Parts of the range are in the order of the symbols, so the search
+	// runs down from the last symbol starting close enough to the value
+	last := sort.Search(Symbols, func(s int) bool {
+		return int64(d.m.prev[s]*size/d.m.size) > offset+slack
+	})
+	var found []candidate
+	for s := last - 1; s >= 0; s-- {
+		if d.m.count[s] == 0 || d.left[s] == 0 {
+			continue
+		}
+		lo := int64(d.m.prev[s] * size / d.m.size)
+		if lo+int64(d.m.count[s]*size/d.m.size) < offset {
+			break
+		}
+		try := *d
+		if !try.step(byte(s)) || try.value > try.high || try.value < try.low && try.low-try.value > uint64(slack) {
+			continue
+		}
+		dist := int64(0)
+		if try.value < try.low {
+			dist = int64(try.low - try.value)
+		}
+		found = append(found, candidate{sym: byte(s), dist: dist})
+	}
+	slices.Reverse(found)
+	slices.SortStableFunc(found, func(a, b candidate) int {
+		return cmp.Compare(a.dist, b.dist)
+	})
+	syms := make([]byte, len(found))
+	for k, c := range found {
+		syms[k] = c.sym
+	}
+
+	return syms
+}
+
+// narrowNormalize narrows the range to a symbol and normalizes it.
+func (c *coder) narrowNormalize(sym byte, shift func(sym byte)) bool {
+	c.narrow(sym)
+
+	return c.normalize(shift)
+}
@@ -0,0 +1,221 @@
+package rangecode
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"runtime"
+	"testing"
+)
+
+// imagePath is the firmware's test image, a 1920x1080 gray frame.
+const imagePath = "../../image_capture/encoding/image.bin"
+
+func readImage(t *testing.T) []byte {
+	t.Helper()
+	img, err := os.ReadFile(imagePath)
+	if err != nil {
+		t.Fatalf("failed to read the firmware's test image: %v", err)
+	}
+
+	return img
+}
+
+// ramp is the input of the demo's second test mode.
+func ramp() []byte {
+	src := make([]byte, 1000)
+	for i := range src {
+		src[i] = byte(i)
+	}
+
+	return src
+}
+
+// The golden values are those of range_code.c on the inputs of demo.c and
+// the first row of image.bin.
+func TestEncodeGolden(t *testing.T) {
+	img := readImage(t)
+	tests := []struct {
+		name      string
+		src       []byte
+		threshold int
+		result    int
+		written   int
+		sha256    string
+	}{
+		{
+			name:      "ramp",
+			src:       ramp(),
+			threshold: 64,
+			result:    999,
+			written:   999,
+			sha256:    "ccf3e00eb952461921bec1a528281f59048a78f9e110c6e3faf35cccdca8e9d2",
+		},
+		{
+			name:      "short",
+			src:       []byte{130, 55, 39, 55, 130, 72, 72, 9, 72, 8, 80, 76, 125, 130, 72, 9},
+			threshold: 32,
+			result:    5,
+			written:   5,
+			sha256:    "14f577742cc1192403f051ec54f4d6b3a5313789c3c0642143bdde0a07fdd6a7",
+		},
+		{
+			name:      "image row",
+			src:       img[:1920],
+			threshold: DefaultThreshold,
+			result:    1666,
+			written:   1665,
+			sha256:    "e712d11237a6cd8844613f801c4c6f497ec44198d4b1c36deae0d8f65b20d6ec",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := Encode(tt.src, tt.threshold)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if code.Result != tt.result {
+				t.Errorf("Encode() result = %d, want %d", code.Result, tt.result)
+			}
+			if len(code.Bytes) != tt.written {
+				t.Errorf("Encode() wrote %d bytes, want %d", len(code.Bytes), tt.written)
+			}
+			sum := sha256.Sum256(code.Bytes)
+			if got := hex.EncodeToString(sum[:]); got != tt.sha256 {
+				t.Errorf("Encode() bytes have SHA-256 %s, want %s", got, tt.sha256)
+			}
+		})
+	}
+}
+
+// range_code gives up on image.bin: a reshape of the model leaves byte
+// 243 with too small a count.
+func TestEncodeGoldenImage(t *testing.T) {
+	img := readImage(t)
+	code, err := Encode(img, DefaultThreshold)
+	var collapse *CollapseError
+	if !errors.As(err, &collapse) {
+		t.Fatalf("Encode() error = %v, want a *CollapseError", err)
+	}
+	if collapse.Index != 5776 || collapse.Written != 6398 {
+		t.Errorf("Encode() collapsed at byte %d after %d bytes, want byte 5776 after 6398 bytes",
+			collapse.Index, collapse.Written)
+	}
+	if code.Result != 0 {
+		t.Errorf("Encode() result = %d, want 0", code.Result)
+	}
+	sum := sha256.Sum256(code.Bytes)
+	want := "840852af3f99d64e50bd76c30b64f0da61a09bcb7236ab641148336beccb7172"
+	if got := hex.EncodeToString(sum[:]); got != want {
+		t.Errorf("Encode() bytes have SHA-256 %s, want %s", got, want)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	img := readImage(t)
+	tests := []struct {
+		name      string
+		src       []byte
+		threshold int
+	}{
+		{"ramp", ramp(), 64},
+		{"short", []byte{130, 55, 39, 55, 130, 72, 72, 9, 72, 8, 80, 76, 125, 130, 72, 9}, 32},
+		{"image row", img[:1920], DefaultThreshold},
+		{"image row small threshold", img[1920*500 : 1920*501], 64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := Encode(tt.src, tt.threshold)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			data, err := code.AppendBinary(nil)
+			if err != nil {
+				t.Fatalf("AppendBinary() error = %v", err)
+			}
+			got := make([]byte, len(tt.src))
+			err = Decode(got, data)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if !bytes.Equal(got, tt.src) {
+				for i := range got {
+					if got[i] != tt.src[i] {
+						t.Fatalf("Decode() byte %d = %d, want %d", i, got[i], tt.src[i])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeWrongSize(t *testing.T) {
+	code, err := Encode(ramp(), 64)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	data, _ := code.AppendBinary(nil)
+	err = Decode(make([]byte, 999), data)
+	if err == nil {
+		t.Error("Decode() accepted a frame of the wrong size")
+	}
+}
+
+// Rows of image.bin take the decoder through ranges moved below byte
+// boundaries, where the code value alone does not tell the symbols apart.
+func TestRoundTripImageRows(t *testing.T) {
+	img := readImage(t)
+	decoded := 0
+	for _, threshold := range []int{64, DefaultThreshold, 1 << 20} {
+		for row := 0; row < 1080; row += 37 {
+			src := img[row*1920 : (row+1)*1920]
+			code, err := Encode(src, threshold)
+			if err != nil {
+				// The firmware gives up on this row as well
+				continue
+			}
+			data, _ := code.AppendBinary(nil)
+			got := make([]byte, len(src))
+			err = Decode(got, data)
+			if err != nil {
+				t.Fatalf("row %d, threshold %d: Decode() error = %v", row, threshold, err)
+			}
+			if !bytes.Equal(got, src) {
+				t.Fatalf("row %d, threshold %d: Decode() returned other bytes", row, threshold)
+			}
+			decoded++
+		}
+	}
+	if decoded == 0 {
+		t.Fatal("Encode() gave up on every row")
+	}
+}
+
+// Codes of noise leave the decoder many symbols to try, each of which it
+// has to keep until the code tells them apart.
+func TestDecodeBounded(t *testing.T) {
+	src := make([]byte, 1920*1080)
+	x := uint32(1)
+	for i := range src {
+		x = x*1664525 + 1013904223
+		src[i] = byte(x >> 28)
+	}
+	for _, threshold := range []int{DefaultThreshold, 1 << 18, 1<<31 - 1} {
+		// The encoder gives up on the noise, but what it wrote is a
+		// frame-sized code all the same
+		code, _ := Encode(src, threshold)
+		data, _ := code.AppendBinary(nil)
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		err := Decode(make([]byte, len(src)), data)
+		runtime.ReadMemStats(&after)
+		if err == nil {
+			t.Fatalf("threshold %d: Decode() decoded noise", threshold)
+		}
+		if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 256<<20 {
+			t.Errorf("threshold %d: Decode() allocated %d MB", threshold, alloc>>20)
+		}
+	}
+}
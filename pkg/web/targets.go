@@ -25,4 +25,10 @@ var (
 var (
 	// LivePageTitle is the title of the live page.
 	LivePageTitle = "Live Camera System"
+
+	// CalibratePageTitle is the title of the calibration page.
+	CalibratePageTitle = "Stereo Calibration"
+
+	// BatchPageTitle is the title of the batch page.
+	BatchPageTitle = "Batch Disparity"
 )